@@ -0,0 +1,250 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/deviantony/pctl/internal/build"
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/compose"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	headerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+)
+
+var BuildCmd = &cobra.Command{
+	Use:   "build [service...]",
+	Short: "Build the images of the services without deploying",
+	Long: `Build the images of the services with build directives, or only of the given
+services, like 'pctl up' does but without touching the stack. Use it to warm the
+build cache of the remote engine or to check a Dockerfile change before deploying.
+Images which are up to date are not rebuilt unless --no-cache is given.
+
+Use --inspect-context <service> to list the files of the build context of a service
+that would be sent to Docker, without building.`,
+	RunE:         runBuild,
+	SilenceUsage: true,
+}
+
+var (
+	// noCache rebuilds the images without the build cache, even when they are up to date
+	noCache bool
+	// pull pulls newer versions of the base images
+	pull bool
+	// progress is the format of the build output: auto, plain, tty or json
+	progress string
+	// inspectContext is the service whose build context is listed
+	inspectContext string
+)
+
+func init() {
+	BuildCmd.Flags().BoolVar(&noCache, "no-cache", false, "Rebuild the images without the build cache, even when they are up to date (sets force_build=true)")
+	BuildCmd.Flags().BoolVar(&pull, "pull", false, "Always pull newer versions of the base images, rebuilding up to date images (sets pull=true)")
+	BuildCmd.Flags().StringVar(&progress, "progress", build.ProgressAuto, "Build output: auto, plain, tty or json")
+	BuildCmd.Flags().StringVar(&inspectContext, "inspect-context", "", "List the files of the build context of the service, without building")
+}
+
+func runBuild(cmd *cobra.Command, args []string) error {
+	if inspectContext != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("--inspect-context cannot be combined with service names")
+		}
+		return runInspect()
+	}
+
+	progressMode, err := resolveProgress(progress)
+	if err != nil {
+		return err
+	}
+	// JSON output is meant for scripts, only the build events and the summary are printed
+	quiet := progressMode == build.ProgressJSON
+	interactive := progressMode == build.ProgressTTY
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if !quiet {
+		fmt.Println(infoStyle.Render("Loading configuration..."))
+		if cfg.Target != "" {
+			fmt.Printf("  Target: %s\n", cfg.Target)
+		}
+		fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
+		fmt.Printf("  Stack Name: %s\n", cfg.StackName)
+		fmt.Printf("  Compose File: %s\n", cfg.ComposeFile)
+		fmt.Println()
+	}
+
+	// Portainer builds the images of git stacks itself, and Kubernetes environments cannot build
+	if cfg.IsGitSource() {
+		return fmt.Errorf("the stack is deployed from a git repository (source: git), Portainer builds its images")
+	}
+	if cfg.IsKubernetes() {
+		return fmt.Errorf("images cannot be built for Kubernetes environments (kind: kubernetes)")
+	}
+
+	services, err := findServicesToBuild(cfg.ComposeFile, args)
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		if !quiet {
+			fmt.Println(infoStyle.Render("No build directives found in the compose file, there is nothing to build"))
+		}
+		return nil
+	}
+
+	buildConfig := cfg.GetBuildConfig()
+	if noCache {
+		buildConfig.ForceBuild = true
+	}
+	if pull {
+		buildConfig.Pull = true
+	}
+	if err := buildConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid build configuration: %w", err)
+	}
+
+	// Create Portainer client
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Edge agents cannot be reached through the Docker proxy the images are built with
+	var environment *portainer.Environment
+	err = runStep(interactive, "Checking environment...", func() error {
+		var fetchErr error
+		environment, fetchErr = client.GetEnvironment(ctx, cfg.EnvironmentID)
+		return fetchErr
+	})
+	if err != nil {
+		if quiet {
+			return fmt.Errorf("failed to check the environment: %w", err)
+		}
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check the environment"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+	if err := cmdutil.CheckEnvironmentKind(cfg, environment); err != nil {
+		return err
+	}
+	if environment.IsEdge() {
+		return fmt.Errorf("environment %d (%s) is an Edge environment, edge agents cannot build images", environment.ID, environment.Name)
+	}
+
+	logger, err := build.NewProgressLogger(progressMode, "BUILD", os.Stdout)
+	if err != nil {
+		return err
+	}
+	orchestrator := build.NewBuildOrchestrator(client, buildConfig, cfg.EnvironmentID, cfg.StackName, logger)
+
+	imageTags, err := orchestrator.BuildServices(ctx, services)
+	if err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+
+	if quiet {
+		return json.NewEncoder(os.Stdout).Encode(map[string]map[string]string{"images": imageTags})
+	}
+	displaySummary(imageTags)
+	return nil
+}
+
+// resolveProgress returns the progress format of the build output, auto is tty on a terminal
+func resolveProgress(progress string) (string, error) {
+	switch progress {
+	case build.ProgressAuto:
+		if term.IsTerminal(os.Stdout.Fd()) {
+			return build.ProgressTTY, nil
+		}
+		return build.ProgressPlain, nil
+	case build.ProgressPlain, build.ProgressTTY, build.ProgressJSON:
+		return progress, nil
+	default:
+		return "", fmt.Errorf("invalid --progress '%s', must be '%s', '%s', '%s' or '%s'", progress, build.ProgressAuto, build.ProgressPlain, build.ProgressTTY, build.ProgressJSON)
+	}
+}
+
+// runStep runs an operation with a spinner on terminals, directly otherwise
+func runStep(interactive bool, message string, operation func() error) error {
+	if !interactive {
+		return operation()
+	}
+	return spinner.RunWithSpinner(message, operation)
+}
+
+// findServicesToBuild returns the services of the compose file to build, all the services with
+// build directives when no name is given
+func findServicesToBuild(composeFile string, names []string) ([]compose.ServiceBuildInfo, error) {
+	content, err := compose.ReadComposeFile(composeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+
+	parsed, err := compose.ParseComposeFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	services, err := parsed.FindServicesToBuild(names)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := parsed.ValidateBuildContexts(); err != nil {
+		return nil, fmt.Errorf("build context validation failed: %w", err)
+	}
+	return services, nil
+}
+
+// displaySummary prints the image tags of the built services
+func displaySummary(imageTags map[string]string) {
+	services := make([]string, 0, len(imageTags))
+	width := len("SERVICE")
+	for service := range imageTags {
+		services = append(services, service)
+		width = max(width, len(service))
+	}
+	sort.Strings(services)
+
+	fmt.Println()
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ %d image(s) ready", len(imageTags))))
+	fmt.Println()
+	fmt.Println(headerStyle.Render(fmt.Sprintf("  %-*s  %s", width, "SERVICE", "IMAGE")))
+	for _, service := range services {
+		fmt.Printf("  %-*s  %s\n", width, service, imageTags[service])
+	}
+	fmt.Println()
+	fmt.Println(infoStyle.Render("The stack was not changed, run 'pctl up' to deploy these images."))
+}
@@ -0,0 +1,82 @@
+package build
+
+import (
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/build"
+	"github.com/deviantony/pctl/internal/config"
+)
+
+// runInspect loads the configuration and prints the build context of the --inspect-context service
+func runInspect() error {
+	// The build context is local, the Portainer credentials are not needed
+	cfg, err := config.LoadWithoutCredentials()
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	return runInspectContext(cfg, inspectContext)
+}
+
+// runInspectContext prints the files of the build context of a service sent to Docker
+func runInspectContext(cfg *config.Config, serviceName string) error {
+	if cfg.ComposeFile == "" {
+		return fmt.Errorf("invalid configuration: compose_file is required")
+	}
+
+	buildConfig := cfg.GetBuildConfig()
+	if err := buildConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid build configuration: %w", err)
+	}
+
+	services, err := findServicesToBuild(cfg.ComposeFile, []string{serviceName})
+	if err != nil {
+		return err
+	}
+	serviceInfo := services[0]
+
+	streamer := build.NewContextTarStreamer(buildConfig.WarnThresholdMB, buildConfig.MaxContextMB, nil)
+	report, err := streamer.InspectContext(serviceInfo.ContextPath, serviceInfo.Build.Dockerfile)
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Failed to inspect build context"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Build context of service %s", serviceName)))
+	fmt.Printf("  Context: %s\n", serviceInfo.ContextPath)
+	fmt.Printf("  Dockerfile: %s\n", serviceInfo.Build.Dockerfile)
+	fmt.Println()
+
+	fmt.Println(headerStyle.Render("Files"))
+	for _, file := range report.Files {
+		fmt.Printf("  %10s  %s\n", build.FormatSize(file.Size), file.Path)
+	}
+	fmt.Println()
+
+	fmt.Println(headerStyle.Render("Largest entries"))
+	for _, entry := range report.Largest(10) {
+		path := entry.Path
+		if entry.IsDir {
+			path += "/"
+		}
+		fmt.Printf("  %10s  %s\n", build.FormatSize(entry.Size), path)
+	}
+	fmt.Println()
+
+	fmt.Printf("%d file(s), %s\n", len(report.Files), build.FormatSize(report.TotalSize))
+	switch {
+	case buildConfig.MaxContextMB > 0 && report.TotalSize > int64(buildConfig.MaxContextMB)*1024*1024:
+		fmt.Println(errorStyle.Render(fmt.Sprintf("✗ The context is above max_context_mb (%d MB), the build would be refused. Exclude files with .dockerignore.", buildConfig.MaxContextMB)))
+	case buildConfig.WarnThresholdMB > 0 && report.TotalSize > int64(buildConfig.WarnThresholdMB)*1024*1024:
+		fmt.Println(warningStyle.Render(fmt.Sprintf("The context is above warn_threshold_mb (%d MB), consider excluding files with .dockerignore.", buildConfig.WarnThresholdMB)))
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/deploy"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+)
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show what 'pctl up' would change in the deployed stack",
+	Long: `Compare the stack deployed in Portainer with the local compose file.
+Services with build directives are not built, their image tags are computed from
+the build contexts. The output lists added, removed and modified services with
+their image, environment, port, volume and network changes, the stack environment
+variable changes (names only) and a unified diff of the compose files.`,
+	RunE:         runDiff,
+	SilenceUsage: true,
+}
+
+// preserveEnv keeps stack environment variables not defined by pctl, overriding preserve_env
+var preserveEnv bool
+
+func init() {
+	DiffCmd.Flags().BoolVar(&preserveEnv, "preserve-env", false, "Keep existing stack environment variables that are not defined by pctl (sets preserve_env=true)")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cmd.Flags().Changed("preserve-env") {
+		cfg.PreserveEnv = preserveEnv
+	}
+
+	fmt.Println(infoStyle.Render("Loading configuration..."))
+	if cfg.Target != "" {
+		fmt.Printf("  Target: %s\n", cfg.Target)
+	}
+	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
+	fmt.Printf("  Stack Name: %s\n", cfg.StackName)
+	fmt.Printf("  Compose File: %s\n", cfg.ComposeFile)
+	fmt.Println()
+
+	// Portainer deploys git stacks from the repository, the local compose file is not used
+	if cfg.IsGitSource() {
+		fmt.Println(infoStyle.Render("The stack is deployed from a git repository (source: git), there is no local compose file to compare."))
+		fmt.Println(infoStyle.Render("Run 'pctl up --dry-run' to see which repository and reference would be deployed."))
+		return nil
+	}
+
+	// Portainer stores the manifests of Kubernetes stacks, not the compose services diff compares
+	if cfg.IsKubernetes() {
+		fmt.Println(infoStyle.Render("The stack is deployed to Kubernetes (kind: kubernetes), its services cannot be compared."))
+		fmt.Println(infoStyle.Render("Run 'pctl up --dry-run' to see whether the deployed stack would change."))
+		return nil
+	}
+
+	// Create Portainer client
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Edge stacks are not regular stacks, FindStack would never find them
+	var edge bool
+	err = spinner.RunWithSpinner("Checking environment...", func() error {
+		var fetchErr error
+		edge, fetchErr = cmdutil.IsEdgeEnvironment(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check the environment"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+	if edge {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("Environment %d is an Edge environment, the stack is deployed as an edge stack which diff cannot compare.", cfg.EnvironmentID)))
+		fmt.Println(infoStyle.Render("Run 'pctl up --dry-run' to see whether the edge stack would be created or updated."))
+		return nil
+	}
+
+	var existingStack *portainer.Stack
+	err = spinner.RunWithSpinner("Checking if stack exists...", func() error {
+		var fetchErr error
+		existingStack, fetchErr = cmdutil.FindStack(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	return showPlan(ctx, client, cfg, existingStack)
+}
+
+// showPlan prepares the compose file without building images and prints the changes a
+// deployment would make to existingStack, which is nil when the stack does not exist yet
+func showPlan(ctx context.Context, client *portainer.Client, cfg *config.Config, existingStack *portainer.Stack) error {
+	prepared, err := deploy.PrepareCompose(ctx, client, cfg, deploy.Options{DryRun: true})
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+
+	var plan *deploy.Plan
+	err = spinner.RunWithSpinner("Comparing with the deployed stack...", func() error {
+		var planErr error
+		plan, planErr = deploy.BuildPlan(ctx, client, cfg, existingStack, prepared)
+		return planErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to compare with the deployed stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	fmt.Println()
+	plan.Print()
+	return nil
+}
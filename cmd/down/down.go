@@ -0,0 +1,335 @@
+package down
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/build"
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/compose"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	headerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+)
+
+var (
+	removeVolumes bool
+	removeImages  bool
+	assumeYes     bool
+)
+
+var DownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Remove the stack from Portainer",
+	Long: `Remove the deployed stack and its containers from Portainer.
+Use --volumes to also remove the named volumes created for the stack and
+--images to remove the images built by pctl for its services.
+You will be asked for confirmation unless --yes is given.`,
+	RunE:         runDown,
+	SilenceUsage: true,
+}
+
+func init() {
+	DownCmd.Flags().BoolVarP(&removeVolumes, "volumes", "v", false, "Also remove named volumes created for the stack")
+	DownCmd.Flags().BoolVar(&removeImages, "images", false, "Also remove images built by pctl for the stack services")
+	DownCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+}
+
+func runDown(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	fmt.Println(infoStyle.Render("Loading configuration..."))
+	if cfg.Target != "" {
+		fmt.Printf("  Target: %s\n", cfg.Target)
+	}
+	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
+	fmt.Printf("  Stack Name: %s\n", cfg.StackName)
+	fmt.Println()
+
+	// Create Portainer client
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Edge agents cannot be reached through the Docker proxy, their stacks are edge stacks
+	var edge bool
+	err = spinner.RunWithSpinner("Checking environment...", func() error {
+		var fetchErr error
+		edge, fetchErr = cmdutil.IsEdgeEnvironment(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check the environment"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+	if edge {
+		return removeEdgeStack(ctx, client, cfg)
+	}
+
+	// Check if stack exists
+	var existingStack *portainer.Stack
+	err = spinner.RunWithSpinnerAndSuccess("Checking if stack exists...", "✓ Stack found", func() error {
+		var fetchErr error
+		existingStack, fetchErr = cmdutil.FindStack(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	if existingStack == nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Stack not found"))
+		fmt.Println()
+		fmt.Printf("Stack '%s' not found in environment %d.\n", cfg.StackName, cfg.EnvironmentID)
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	// Kubernetes stacks have no containers, volumes or images reachable through the Docker proxy
+	if existingStack.IsKubernetes() {
+		return removeKubernetesStack(ctx, client, cfg, existingStack)
+	}
+
+	// Gather the resources that will be removed
+	var (
+		containers []portainer.Container
+		volumes    []portainer.Volume
+		images     []string
+	)
+	err = spinner.RunWithSpinnerAndSuccess("Gathering stack resources...", "✓ Stack resources gathered", func() error {
+		var fetchErr error
+		containers, fetchErr = client.GetStackContainers(ctx, cfg.EnvironmentID, cfg.StackName)
+		if fetchErr != nil {
+			return fetchErr
+		}
+
+		if removeVolumes {
+			volumes, fetchErr = client.GetStackVolumes(ctx, cfg.EnvironmentID, cfg.StackName)
+			if fetchErr != nil {
+				return fetchErr
+			}
+		}
+
+		if removeImages {
+			images, fetchErr = findBuiltImages(ctx, client, cfg, containers)
+			if fetchErr != nil {
+				return fetchErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to gather stack resources"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	fmt.Println()
+	displayPlan(existingStack, containers, volumes, images)
+	fmt.Println()
+
+	// Ask for confirmation
+	if confirmed, err := confirmRemoval(fmt.Sprintf("Remove stack '%s'?", existingStack.Name)); err != nil || !confirmed {
+		return err
+	}
+
+	// Delete the stack, which removes its containers and networks
+	err = spinner.RunWithSpinnerAndSuccess("Removing stack...", "✓ Stack removed", func() error {
+		return client.DeleteStack(ctx, existingStack.ID, cfg.EnvironmentID)
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to remove stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+	cmdutil.ForgetStack(cfg)
+
+	// Volumes and images can only be removed once no container uses them anymore
+	var failures []string
+	if len(volumes) > 0 {
+		_ = spinner.RunWithSpinnerAndSuccess("Removing volumes...", "✓ Volumes processed", func() error {
+			for _, volume := range volumes {
+				if err := client.RemoveVolume(ctx, cfg.EnvironmentID, volume.Name); err != nil && !portainer.IsNotFound(err) {
+					failures = append(failures, fmt.Sprintf("volume %s: %v", volume.Name, err))
+				}
+			}
+			return nil
+		})
+	}
+
+	if len(images) > 0 {
+		_ = spinner.RunWithSpinnerAndSuccess("Removing images...", "✓ Images processed", func() error {
+			for _, image := range images {
+				if err := client.RemoveImage(ctx, cfg.EnvironmentID, image); err != nil && !portainer.IsNotFound(err) {
+					failures = append(failures, fmt.Sprintf("image %s: %v", image, err))
+				}
+			}
+			return nil
+		})
+	}
+
+	if len(failures) > 0 {
+		fmt.Println()
+		fmt.Println(warningStyle.Render("Some resources could not be removed:"))
+		for _, failure := range failures {
+			fmt.Printf("  • %s\n", failure)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack removed successfully!"))
+
+	return nil
+}
+
+// confirmRemoval asks the user to confirm the removal, unless --yes is given
+func confirmRemoval(title string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+
+	confirmed := false
+	confirm := huh.NewConfirm().
+		Title(title).
+		Description("This cannot be undone.").
+		Affirmative("Remove").
+		Negative("Cancel").
+		Value(&confirmed)
+
+	if err := confirm.Run(); err != nil {
+		return false, fmt.Errorf("failed to ask for confirmation (use --yes to skip it): %w", err)
+	}
+
+	if !confirmed {
+		fmt.Println(infoStyle.Render("Aborted, nothing was removed."))
+	}
+	return confirmed, nil
+}
+
+// findBuiltImages returns the image tags built by pctl for the stack services.
+// Services are taken from the compose file and from the labels of the stack containers,
+// so that images are found even if the compose file changed since the last deploy.
+func findBuiltImages(ctx context.Context, client *portainer.Client, cfg *config.Config, containers []portainer.Container) ([]string, error) {
+	services := make(map[string]bool)
+	for _, container := range containers {
+		if name := container.Labels[portainer.ComposeServiceLabel]; name != "" {
+			services[name] = true
+		}
+	}
+
+	if content, err := compose.ReadComposeFile(cfg.ComposeFile); err == nil {
+		if composeFile, err := compose.ParseComposeFile(content); err == nil {
+			for _, name := range composeFile.GetServiceNames() {
+				services[name] = true
+			}
+		}
+	}
+
+	images, err := client.GetImages(ctx, cfg.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagGenerator := build.NewTagGenerator(cfg.StackName, cfg.GetBuildConfig().TagFormat)
+
+	var tags []string
+	for _, image := range images {
+		for _, tag := range image.RepoTags {
+			for service := range services {
+				if tagGenerator.MatchesTag(service, tag) {
+					tags = append(tags, tag)
+					break
+				}
+			}
+		}
+	}
+
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func displayPlan(stack *portainer.Stack, containers []portainer.Container, volumes []portainer.Volume, images []string) {
+	fmt.Println(headerStyle.Render("The following resources will be removed:"))
+	fmt.Printf("  Stack: %s (ID: %d)\n", stack.Name, stack.ID)
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("Containers:"))
+	if len(containers) == 0 {
+		fmt.Println("  none")
+	}
+	for _, container := range containers {
+		name := "unknown"
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		fmt.Printf("  • %s (%s)\n", name, container.Image)
+	}
+
+	if removeVolumes {
+		fmt.Println()
+		fmt.Println(headerStyle.Render("Volumes:"))
+		if len(volumes) == 0 {
+			fmt.Println("  none")
+		}
+		for _, volume := range volumes {
+			fmt.Printf("  • %s\n", volume.Name)
+		}
+	}
+
+	if removeImages {
+		fmt.Println()
+		fmt.Println(headerStyle.Render("Images:"))
+		if len(images) == 0 {
+			fmt.Println("  none")
+		}
+		for _, image := range images {
+			fmt.Printf("  • %s\n", image)
+		}
+	}
+}
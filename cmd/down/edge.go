@@ -0,0 +1,71 @@
+package down
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// removeEdgeStack removes the edge stack of an Edge environment, the Edge agents remove its
+// containers on their next check-in
+func removeEdgeStack(ctx context.Context, client *portainer.Client, cfg *config.Config) error {
+	var stack *portainer.EdgeStack
+	err := spinner.RunWithSpinner("Checking if edge stack exists...", func() error {
+		var fetchErr error
+		stack, fetchErr = client.GetEdgeStack(ctx, cfg.StackName)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing edge stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	if stack == nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Edge stack not found"))
+		fmt.Println()
+		fmt.Printf("Edge stack '%s' not found.\n", cfg.StackName)
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+	fmt.Println(successStyle.Render("✓ Edge stack found"))
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("The following resources will be removed:"))
+	fmt.Printf("  Edge stack: %s (ID: %d)\n", stack.Name, stack.ID)
+	fmt.Printf("  Deployed on: %d environment(s)\n", len(stack.Status))
+	if removeVolumes || removeImages {
+		fmt.Println(warningStyle.Render("  --volumes and --images are not supported for edge stacks, volumes and images are kept on the devices"))
+	}
+	fmt.Println()
+
+	if confirmed, err := confirmRemoval(fmt.Sprintf("Remove edge stack '%s' from every environment?", stack.Name)); err != nil || !confirmed {
+		return err
+	}
+
+	err = spinner.RunWithSpinnerAndSuccess("Removing edge stack...", "✓ Edge stack removed", func() error {
+		return client.DeleteEdgeStack(ctx, stack.ID)
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to remove edge stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Edge stack removed successfully!"))
+	fmt.Println(infoStyle.Render("The Edge agents remove its containers on their next check-in."))
+
+	return nil
+}
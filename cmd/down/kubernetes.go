@@ -0,0 +1,47 @@
+package down
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// removeKubernetesStack removes a Kubernetes stack, Portainer deletes the resources of its
+// manifests from the namespace
+func removeKubernetesStack(ctx context.Context, client *portainer.Client, cfg *config.Config, stack *portainer.Stack) error {
+	fmt.Println()
+	fmt.Println(headerStyle.Render("The following resources will be removed:"))
+	fmt.Printf("  Stack: %s (ID: %d)\n", stack.Name, stack.ID)
+	fmt.Printf("  Namespace: %s, the resources deployed by the stack are deleted\n", cmdutil.StackNamespace(cfg, stack.Namespace))
+	if removeVolumes || removeImages {
+		fmt.Println(warningStyle.Render("  --volumes and --images are not supported for Kubernetes stacks, persistent volume claims and images are kept"))
+	}
+	fmt.Println()
+
+	if confirmed, err := confirmRemoval(fmt.Sprintf("Remove stack '%s'?", stack.Name)); err != nil || !confirmed {
+		return err
+	}
+
+	err := spinner.RunWithSpinnerAndSuccess("Removing stack...", "✓ Stack removed", func() error {
+		return client.DeleteStack(ctx, stack.ID, cfg.EnvironmentID)
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to remove stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+	cmdutil.ForgetStack(cfg)
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack removed successfully!"))
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var (
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+// errFailed makes pctl exit with 1 once a failure has been printed, so that scripts relying
+// on the exit code do not mistake a missing service for a successful command
+var errFailed = &cmdutil.ExitError{Code: 1}
+
+// defaultCommand is run when no command is given
+var defaultCommand = []string{"/bin/sh"}
+
+var (
+	noTTY   bool
+	index   int
+	user    string
+	workDir string
+	envVars []string
+)
+
+var ExecCmd = &cobra.Command{
+	Use:   "exec <service> [command...]",
+	Short: "Run a command in a running service container",
+	Long: `Run a command inside a running container of a stack service.
+Without a command an interactive shell (/bin/sh) is started.
+
+When run from a terminal, a TTY is allocated and the local terminal is wired to
+the container, including resize events. Use -T to disable TTY allocation, which
+is what scripts want: stdout and stderr are kept separate and the exit code of
+the command is returned by pctl. pctl exits with 1 when the command cannot be run,
+for instance when the service has no running container.
+
+Input is only forwarded interactively, with a TTY: the Portainer exec websocket
+cannot signal the end of piped input without ending the session. Without a TTY
+the command runs with its stdin closed, and pctl refuses to run when input is
+piped or redirected to it.`,
+	Example: `  pctl exec web
+  pctl exec web bash
+  pctl exec -T db pg_dump -U postgres app > dump.sql`,
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         runExec,
+	SilenceUsage: true,
+	// The exit code of the command is returned as an error, main reports the other errors
+	SilenceErrors: true,
+}
+
+func init() {
+	// Flags after the service name belong to the command run in the container
+	ExecCmd.Flags().SetInterspersed(false)
+
+	ExecCmd.Flags().BoolVarP(&noTTY, "no-tty", "T", false, "Disable TTY allocation")
+	ExecCmd.Flags().IntVar(&index, "index", 1, "Index of the container if the service has multiple replicas")
+	ExecCmd.Flags().StringVarP(&user, "user", "u", "", "Run the command as this user")
+	ExecCmd.Flags().StringVarP(&workDir, "workdir", "w", "", "Working directory inside the container")
+	ExecCmd.Flags().StringArrayVarP(&envVars, "env", "e", nil, "Set environment variables (KEY=VALUE)")
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errorStyle.Render("✗ Configuration error"))
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr)
+		return errFailed
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	service := args[0]
+	command := args[1:]
+	if len(command) == 0 {
+		command = defaultCommand
+	}
+
+	// Only allocate a TTY when attached to a terminal
+	tty := !noTTY && term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+
+	// Input given without a TTY would be dropped, refuse it rather than run without it
+	if !tty && hasPipedInput(os.Stdin, inputProbeTimeout) {
+		printError("Input cannot be forwarded", "Input is only forwarded with a TTY: the Portainer exec websocket cannot signal the end\n"+
+			"of piped input without ending the session. Copy the input into the container instead,\n"+
+			"or redirect stdin from /dev/null to run the command without it.")
+		return errFailed
+	}
+
+	// Create Portainer client
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	containers, err := client.GetStackContainers(ctx, cfg.EnvironmentID, cfg.StackName)
+	if err != nil {
+		printError("Failed to fetch containers", errors.FormatError(err))
+		return errFailed
+	}
+
+	container, err := selectContainer(containers, service, index)
+	if err != nil {
+		printError("Container not found", err.Error())
+		return errFailed
+	}
+
+	execID, err := client.CreateExec(ctx, cfg.EnvironmentID, container.ID, execConfig(command, tty))
+	if err != nil {
+		printError("Failed to create exec instance", errors.FormatError(err))
+		return errFailed
+	}
+
+	if err := runSession(cmd.Context(), client, cfg.EnvironmentID, execID, tty); err != nil {
+		printError("Exec session failed", errors.FormatError(err))
+		return errFailed
+	}
+
+	// Forward the exit code of the command, which scripts rely on
+	inspect, err := client.InspectExec(ctx, cfg.EnvironmentID, execID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve exit code: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return &cmdutil.ExitError{Code: inspect.ExitCode}
+	}
+
+	return nil
+}
+
+// execConfig returns the exec instance running the command. Stdin is only attached with a
+// TTY: the exec websocket ends the session when the input is closed, so piped input could
+// not be ended without losing the remaining output.
+func execConfig(command []string, tty bool) portainer.ExecConfig {
+	return portainer.ExecConfig{
+		AttachStdin:  tty,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          tty,
+		User:         user,
+		WorkingDir:   workDir,
+		Env:          envVars,
+		Cmd:          command,
+	}
+}
+
+// inputProbeTimeout is how long a pipe given as stdin has to deliver input before the
+// command runs without it
+const inputProbeTimeout = 200 * time.Millisecond
+
+// hasPipedInput reports whether f, the standard input, carries input: a non-empty file, or a
+// pipe delivering data within wait. A terminal, /dev/null or a pipe staying silent has none.
+func hasPipedInput(f *os.File, wait time.Duration) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	switch mode := info.Mode(); {
+	case mode.IsRegular():
+		return info.Size() > 0
+	case mode&(os.ModeNamedPipe|os.ModeSocket) != 0:
+		// The byte read is lost, the command does not run when there is input
+		read := make(chan bool, 1)
+		go func() {
+			n, _ := f.Read(make([]byte, 1))
+			read <- n > 0
+		}()
+		select {
+		case hasInput := <-read:
+			return hasInput
+		case <-time.After(wait):
+			return false
+		}
+	}
+	return false
+}
+
+// runSession attaches to the exec instance and wires the local terminal to it until
+// the command exits. The terminal state is always restored before returning.
+func runSession(ctx context.Context, client *portainer.Client, environmentID int, execID string, tty bool) error {
+	conn, err := client.AttachExec(ctx, environmentID, execID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if tty {
+		state, err := term.MakeRaw(os.Stdin.Fd())
+		if err != nil {
+			return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+		}
+		defer term.Restore(os.Stdin.Fd(), state)
+
+		resize := func() {
+			width, height, err := term.GetSize(os.Stdout.Fd())
+			if err == nil {
+				_ = client.ResizeExec(ctx, environmentID, execID, height, width)
+			}
+		}
+		resize()
+
+		stopResize := watchResize(resize)
+		defer stopResize()
+	}
+
+	// Closing the connection ends the session when the command is interrupted
+	stopClose := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stopClose()
+
+	if tty {
+		// Input ends with the session, the copy goroutine is not waited for
+		go func() {
+			_, _ = io.Copy(conn, os.Stdin)
+		}()
+		_, err = io.Copy(os.Stdout, conn)
+	} else {
+		err = portainer.CopyExecOutput(os.Stdout, os.Stderr, conn)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// selectContainer returns the running container of the service at the given 1-based index.
+// Containers are ordered by name so that the index is stable across calls.
+func selectContainer(containers []portainer.Container, service string, index int) (*portainer.Container, error) {
+	var matches []portainer.Container
+	services := make(map[string]bool)
+	for _, container := range containers {
+		name := container.Labels[portainer.ComposeServiceLabel]
+		if name != "" {
+			services[name] = true
+		}
+		if name == service && container.State == "running" {
+			matches = append(matches, container)
+		}
+	}
+
+	if len(matches) == 0 {
+		available := make([]string, 0, len(services))
+		for name := range services {
+			available = append(available, name)
+		}
+		sort.Strings(available)
+		return nil, fmt.Errorf("no running container found for service '%s' (available services: %s)", service, strings.Join(available, ", "))
+	}
+
+	if index < 1 || index > len(matches) {
+		return nil, fmt.Errorf("service '%s' has %d running container(s), index %d is out of range", service, len(matches), index)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return containerName(matches[i]) < containerName(matches[j])
+	})
+
+	return &matches[index-1], nil
+}
+
+func containerName(container portainer.Container) string {
+	if len(container.Names) > 0 {
+		return strings.TrimPrefix(container.Names[0], "/")
+	}
+	return container.ID
+}
+
+// printError writes errors to stderr so that the command output stays clean
+func printError(title, details string) {
+	fmt.Fprintln(os.Stderr, errorStyle.Render("✗ "+title))
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, details)
+	fmt.Fprintln(os.Stderr)
+}
@@ -0,0 +1,75 @@
+package exec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecConfig_StdinOnlyWithTTY(t *testing.T) {
+	interactive := execConfig([]string{"/bin/sh"}, true)
+	assert.True(t, interactive.AttachStdin)
+	assert.True(t, interactive.Tty)
+
+	// Piped input could not be ended, it is refused rather than forwarded
+	script := execConfig([]string{"cat"}, false)
+	assert.False(t, script.AttachStdin)
+	assert.False(t, script.Tty)
+	assert.True(t, script.AttachStdout)
+	assert.True(t, script.AttachStderr)
+	assert.Equal(t, []string{"cat"}, script.Cmd)
+}
+
+func TestHasPipedInput_File(t *testing.T) {
+	dir := t.TempDir()
+
+	dump := filepath.Join(dir, "dump.sql")
+	require.NoError(t, os.WriteFile(dump, []byte("SELECT 1;\n"), 0644))
+	f, err := os.Open(dump)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.True(t, hasPipedInput(f, time.Second))
+
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, nil, 0644))
+	f, err = os.Open(empty)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.False(t, hasPipedInput(f, time.Second))
+}
+
+func TestHasPipedInput_DevNull(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.False(t, hasPipedInput(f, time.Second))
+}
+
+func TestHasPipedInput_Pipe(t *testing.T) {
+	// echo x | pctl exec -T app cat
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("x\n"))
+	require.NoError(t, err)
+	w.Close()
+	assert.True(t, hasPipedInput(r, time.Second))
+	r.Close()
+
+	// A pipe closed without input
+	r, w, err = os.Pipe()
+	require.NoError(t, err)
+	w.Close()
+	assert.False(t, hasPipedInput(r, time.Second))
+	r.Close()
+
+	// A pipe kept open without input, like the stdin of some CI runners
+	r, w, err = os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+	defer r.Close()
+	assert.False(t, hasPipedInput(r, 50*time.Millisecond))
+}
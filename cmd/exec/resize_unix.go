@@ -0,0 +1,32 @@
+//go:build !windows
+
+package exec
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchResize calls onResize whenever the terminal is resized, until the returned stop function is called
+func watchResize(onResize func()) func() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGWINCH)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigCh:
+				onResize()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(done)
+	}
+}
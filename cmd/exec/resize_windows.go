@@ -0,0 +1,40 @@
+//go:build windows
+
+package exec
+
+import (
+	"os"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// resizePollInterval is how often the console size is checked, Windows has no SIGWINCH
+const resizePollInterval = 250 * time.Millisecond
+
+// watchResize calls onResize whenever the terminal is resized, until the returned stop function is called
+func watchResize(onResize func()) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(resizePollInterval)
+		defer ticker.Stop()
+
+		lastWidth, lastHeight, _ := term.GetSize(os.Stdout.Fd())
+		for {
+			select {
+			case <-ticker.C:
+				width, height, err := term.GetSize(os.Stdout.Fd())
+				if err == nil && (width != lastWidth || height != lastHeight) {
+					lastWidth, lastHeight = width, height
+					onResize()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,108 @@
+package init
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/huh"
+)
+
+// Ways to trust a server certificate that the system does not trust
+const (
+	certificateTrustPin    = "pin"
+	certificateTrustCAFile = "ca_file"
+	certificateTrustAbort  = "abort"
+)
+
+// checkServerCertificate inspects the certificate of an https Portainer URL. When the system
+// does not trust it, the user can pin its fingerprint (trust on first use) or provide the
+// private certificate authority. It returns nil settings when no TLS settings are needed, and
+// ok is false when the user declined to trust the certificate. Connection failures are left
+// to the environments request, which reports them.
+func checkServerCertificate(ctx context.Context, portainerURL string) (settings *config.TLSConfig, ok bool, err error) {
+	if !strings.HasPrefix(portainerURL, "https://") {
+		return nil, true, nil
+	}
+
+	var cert *portainer.ServerCertificate
+	var inspectErr error
+	_ = spinner.RunWithSpinner("Checking the server certificate...", func() error {
+		inspectCtx, cancel := context.WithTimeout(ctx, portainer.DefaultAPITimeout)
+		defer cancel()
+
+		cert, inspectErr = portainer.InspectServerCertificate(inspectCtx, portainerURL)
+		return nil
+	})
+	if inspectErr != nil || cert.Trusted() {
+		return nil, true, nil
+	}
+
+	fmt.Println()
+	fmt.Println(warningStyle.Render("⚠ The server certificate is not trusted by this system"))
+	fmt.Printf("  Subject: %s\n", cert.Certificate.Subject)
+	fmt.Printf("  Issuer: %s\n", cert.Certificate.Issuer)
+	fmt.Printf("  Valid until: %s\n", cert.Certificate.NotAfter.Format("2006-01-02"))
+	fmt.Printf("  SHA-256 fingerprint: %s\n", cert.Fingerprint)
+	fmt.Printf("  Reason: %v\n", cert.VerifyErr)
+	fmt.Println()
+
+	trust := certificateTrustPin
+	var caFile string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Server Certificate").
+				Description("Compare the fingerprint with the one given by your Portainer administrator").
+				Options(
+					huh.NewOption("Trust this certificate and pin its fingerprint (tls.fingerprint)", certificateTrustPin),
+					huh.NewOption("Use my organization's certificate authority (tls.ca_file)", certificateTrustCAFile),
+					huh.NewOption("Do not trust it", certificateTrustAbort),
+				).
+				Value(&trust),
+		),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("CA File").
+				Description("PEM file containing the certificate authority that issued the Portainer certificate").
+				Value(&caFile).
+				Validate(func(str string) error {
+					path, err := config.ExpandHome(strings.TrimSpace(str))
+					if err != nil {
+						return err
+					}
+					_, err = portainer.NewTLSConfig(portainer.TLSOptions{CAFile: path})
+					return err
+				}),
+		).WithHideFunc(func() bool { return trust != certificateTrustCAFile }),
+	)
+
+	if err := form.Run(); err != nil {
+		return nil, false, fmt.Errorf("failed to run form: %w", err)
+	}
+
+	switch trust {
+	case certificateTrustPin:
+		return &config.TLSConfig{Fingerprint: cert.Fingerprint}, true, nil
+	case certificateTrustCAFile:
+		return &config.TLSConfig{CAFile: strings.TrimSpace(caFile)}, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// describeTLS returns a short description of the TLS settings for the summary
+func describeTLS(settings *config.TLSConfig) string {
+	switch {
+	case settings == nil:
+		return "verified with the system certificate authorities"
+	case settings.Fingerprint != "":
+		return fmt.Sprintf("certificate pinned (%s)", settings.Fingerprint)
+	default:
+		return fmt.Sprintf("verified with %s", settings.CAFile)
+	}
+}
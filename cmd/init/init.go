@@ -3,8 +3,10 @@ package init
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/deviantony/pctl/internal/cmdutil"
 	"github.com/deviantony/pctl/internal/compose"
 	"github.com/deviantony/pctl/internal/config"
 	"github.com/deviantony/pctl/internal/errors"
@@ -65,11 +67,14 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 			huh.NewInput().
 				Title("API Token").
-				Description("Enter your Portainer API token (starts with 'ptr_')").
+				Description("Enter your Portainer API token (starts with 'ptr_'), or leave it empty once logged in with 'pctl login <url>'").
 				Value(&formData.APIToken).
 				Validate(func(str string) error {
 					if str == "" {
-						return fmt.Errorf("API token is required")
+						if session, _ := config.LookupSession(formData.PortainerURL); session != nil {
+							return nil
+						}
+						return fmt.Errorf("API token is required, or run 'pctl login %s' first", formData.PortainerURL)
 					}
 					if !strings.HasPrefix(str, "ptr_") {
 						return fmt.Errorf("API token should start with 'ptr_'")
@@ -84,15 +89,41 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to run form: %w", err)
 	}
 
-	// Show spinner while fetching environments
 	fmt.Println()
 
+	// Verify the server certificate before sending the API token
+	tlsSettings, trusted, err := checkServerCertificate(cmd.Context(), formData.PortainerURL)
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Server certificate not trusted"))
+		fmt.Println(infoStyle.Render("Install your certificate authority on this system, or run 'pctl init' again and provide it as a CA file."))
+		return nil
+	}
+
+	probe := &config.Config{
+		PortainerURL: formData.PortainerURL,
+		APIToken:     formData.APIToken,
+		TLS:          tlsSettings,
+	}
+	if formData.APIToken == "" {
+		probe.Session, err = config.LookupSession(formData.PortainerURL)
+		if err != nil {
+			return err
+		}
+	}
+	client, err := cmdutil.NewClient(cmd.Context(), probe)
+	if err != nil {
+		return err
+	}
+
 	// Use the shared spinner utility
 	var environments []portainer.Environment
-	err := spinner.RunWithSpinner("Fetching environments from Portainer...", func() error {
-		client := portainer.NewClient(formData.PortainerURL, formData.APIToken)
+	err = spinner.RunWithSpinner("Fetching environments from Portainer...", func() error {
 		var fetchErr error
-		environments, fetchErr = client.GetEnvironments()
+		environments, fetchErr = client.GetEnvironments(cmd.Context())
 		return fetchErr
 	})
 
@@ -167,14 +198,24 @@ func runInit(cmd *cobra.Command, args []string) error {
 		formData.ComposeFile = config.GetDefaultComposeFile()
 	}
 
+	// Ask where the API token should be stored, so that pctl.yml can be committed.
+	// Without a token, the pctl login session is used.
+	storage := &tokenStorage{Mode: tokenStorageSession}
+	if formData.APIToken != "" {
+		storage, err = askTokenStorage(formData.StackName)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Create and save configuration (include default build configuration)
 	cfg := &config.Config{
 		PortainerURL:  formData.PortainerURL,
-		APIToken:      formData.APIToken,
 		EnvironmentID: formData.EnvironmentID,
 		StackName:     formData.StackName,
 		ComposeFile:   formData.ComposeFile,
 		SkipTLSVerify: config.GetDefaultSkipTLSVerify(), // Use default value
+		TLS:           tlsSettings,
 		Build: &config.BuildConfig{
 			Mode:            config.DefaultBuildMode,
 			Parallel:        config.DefaultBuildParallel,
@@ -186,6 +227,10 @@ func runInit(cmd *cobra.Command, args []string) error {
 		},
 	}
 
+	if err := storage.apply(cfg, formData.APIToken); err != nil {
+		return fmt.Errorf("failed to store API token: %w", err)
+	}
+
 	if err := cfg.Save(); err != nil {
 		return fmt.Errorf("failed to save configuration: %w", err)
 	}
@@ -199,8 +244,16 @@ func runInit(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Environment: %s (ID: %d)\n", getEnvironmentName(environments, formData.EnvironmentID), formData.EnvironmentID)
 	fmt.Printf("  Stack Name: %s\n", formData.StackName)
 	fmt.Printf("  Compose File: %s\n", formData.ComposeFile)
+	fmt.Printf("  API Token: %s\n", storage.describe())
+	if strings.HasPrefix(formData.PortainerURL, "https://") {
+		fmt.Printf("  TLS: %s\n", describeTLS(tlsSettings))
+	}
 	fmt.Println()
-	fmt.Println(infoStyle.Render("You can now use 'pctl deploy' to deploy your stack or 'pctl redeploy' to update an existing stack."))
+	if storage.Mode == tokenStorageEnv {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("Export the token before running pctl: export %sAPI_TOKEN=<token>", config.EnvOverridePrefix)))
+		fmt.Println()
+	}
+	fmt.Println(infoStyle.Render("You can now use 'pctl up' to deploy your stack."))
 
 	return nil
 }
@@ -213,3 +266,113 @@ func getEnvironmentName(environments []portainer.Environment, id int) string {
 	}
 	return "Unknown"
 }
+
+// API token storage options offered by init
+const (
+	tokenStorageConfig  = "config"
+	tokenStorageFile    = "file"
+	tokenStorageEnv     = "env"
+	tokenStorageCommand = "command"
+	tokenStorageSession = "session" // no token, the pctl login session is used
+)
+
+// tokenStorage describes where the API token is kept
+type tokenStorage struct {
+	Mode    string
+	Path    string // token file for tokenStorageFile
+	Command string // command printing the token for tokenStorageCommand
+}
+
+// askTokenStorage asks the user where the API token should be stored
+func askTokenStorage(stackName string) (*tokenStorage, error) {
+	storage := &tokenStorage{
+		Mode: tokenStorageFile,
+		Path: fmt.Sprintf("~/.config/pctl/%s.token", stackName),
+	}
+
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("API Token Storage").
+				Description("Keeping the token out of pctl.yml lets you commit the file safely").
+				Options(
+					huh.NewOption("Separate file (api_token_file)", tokenStorageFile),
+					huh.NewOption(fmt.Sprintf("Environment variable (%sAPI_TOKEN)", config.EnvOverridePrefix), tokenStorageEnv),
+					huh.NewOption("Command output, e.g. a password manager CLI (api_token_command)", tokenStorageCommand),
+					huh.NewOption("In pctl.yml (plain text)", tokenStorageConfig),
+				).
+				Value(&storage.Mode),
+		),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Token File").
+				Description("The token is written to this file with owner-only permissions").
+				Value(&storage.Path).
+				Validate(func(str string) error {
+					if strings.TrimSpace(str) == "" {
+						return fmt.Errorf("token file path is required")
+					}
+					return nil
+				}),
+		).WithHideFunc(func() bool { return storage.Mode != tokenStorageFile }),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Token Command").
+				Description("Shell command printing the token (e.g. op read op://vault/portainer/token)").
+				Value(&storage.Command).
+				Validate(func(str string) error {
+					if strings.TrimSpace(str) == "" {
+						return fmt.Errorf("token command is required")
+					}
+					return nil
+				}),
+		).WithHideFunc(func() bool { return storage.Mode != tokenStorageCommand }),
+	)
+
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run form: %w", err)
+	}
+
+	return storage, nil
+}
+
+// apply sets the token settings of the configuration, writing the token file if needed
+func (s *tokenStorage) apply(cfg *config.Config, token string) error {
+	switch s.Mode {
+	case tokenStorageFile:
+		path, err := config.ExpandHome(s.Path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return fmt.Errorf("failed to create token directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+			return fmt.Errorf("failed to write token file: %w", err)
+		}
+		cfg.APITokenFile = s.Path
+	case tokenStorageCommand:
+		cfg.APITokenCommand = s.Command
+	case tokenStorageEnv, tokenStorageSession:
+		// Nothing is stored, the token is read from the environment or replaced by the session
+	default:
+		cfg.APIToken = token
+	}
+	return nil
+}
+
+// describe returns a short description of the token storage for the summary
+func (s *tokenStorage) describe() string {
+	switch s.Mode {
+	case tokenStorageFile:
+		return fmt.Sprintf("stored in %s", s.Path)
+	case tokenStorageCommand:
+		return fmt.Sprintf("read from '%s'", s.Command)
+	case tokenStorageEnv:
+		return fmt.Sprintf("read from $%sAPI_TOKEN", config.EnvOverridePrefix)
+	case tokenStorageSession:
+		return "none, the 'pctl login' session is used"
+	default:
+		return "stored in pctl.yml"
+	}
+}
@@ -0,0 +1,153 @@
+package login
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/credentials"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+)
+
+var (
+	username      string
+	passwordStdin bool
+)
+
+var LoginCmd = &cobra.Command{
+	Use:   "login [portainer-url]",
+	Short: "Log in to Portainer with a username and password",
+	Long: `Log in to Portainer with a username and password, as an alternative to API tokens.
+The session is stored in ~/.config/pctl/credentials.json and used instead of the API
+token until it expires or 'pctl logout' is run. When it expires, pctl asks for the
+password again.
+
+The Portainer URL defaults to portainer_url of pctl.yml.`,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         runLogin,
+	SilenceUsage: true,
+}
+
+func init() {
+	LoginCmd.Flags().StringVarP(&username, "username", "u", "", "Portainer username")
+	LoginCmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the password from stdin (requires --username)")
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	cfg, err := cmdutil.LoginConfig(args)
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	// Offer the username of the previous session
+	if username == "" {
+		if session, _ := config.LookupSession(cfg.PortainerURL); session != nil {
+			username = session.Username
+		}
+	}
+
+	var password string
+	if passwordStdin {
+		if username == "" {
+			return fmt.Errorf("--username is required with --password-stdin")
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	} else {
+		password, err = askCredentials(cfg.PortainerURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(infoStyle.Render(fmt.Sprintf("Logging in to %s...", cfg.PortainerURL)))
+	fmt.Println()
+
+	var session *credentials.Session
+	err = spinner.RunWithSpinnerAndSuccess("Authenticating...", "✓ Authenticated", func() error {
+		var loginErr error
+		session, loginErr = cmdutil.Login(cmd.Context(), cfg, username, password)
+		return loginErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Login failed"))
+		fmt.Println()
+		if portainer.IsInvalidCredentials(err) {
+			fmt.Println("Invalid username or password.")
+		} else {
+			fmt.Println(errors.FormatError(err))
+		}
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Logged in as %s", session.Username)))
+	fmt.Println()
+	if !session.ExpiresAt.IsZero() {
+		fmt.Printf("  Session expires: %s\n", session.ExpiresAt.Local().Format(time.DateTime))
+		fmt.Println()
+	}
+	fmt.Println(infoStyle.Render("pctl uses this session instead of the API token. Run 'pctl logout' to remove it."))
+
+	return nil
+}
+
+// askCredentials asks for the username, unless given with --username, and the password
+func askCredentials(portainerURL string) (string, error) {
+	var password string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Username").
+				Description(fmt.Sprintf("Your Portainer username on %s", portainerURL)).
+				Value(&username).
+				Validate(func(str string) error {
+					if str == "" {
+						return fmt.Errorf("username is required")
+					}
+					return nil
+				}),
+
+			huh.NewInput().
+				Title("Password").
+				EchoMode(huh.EchoModePassword).
+				Value(&password).
+				Validate(func(str string) error {
+					if str == "" {
+						return fmt.Errorf("password is required")
+					}
+					return nil
+				}),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return "", fmt.Errorf("failed to run form: %w", err)
+	}
+	return password, nil
+}
@@ -0,0 +1,52 @@
+package logout
+
+import (
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+)
+
+var LogoutCmd = &cobra.Command{
+	Use:   "logout [portainer-url]",
+	Short: "Remove the session created by 'pctl login'",
+	Long: `Remove the session of a Portainer instance created by 'pctl login' from
+~/.config/pctl/credentials.json. pctl then uses the API token again.
+
+The Portainer URL defaults to portainer_url of pctl.yml.`,
+	Args:         cobra.MaximumNArgs(1),
+	RunE:         runLogout,
+	SilenceUsage: true,
+}
+
+func runLogout(cmd *cobra.Command, args []string) error {
+	cfg, err := cmdutil.LoginConfig(args)
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	removed, err := cmdutil.Logout(cfg.PortainerURL)
+	if err != nil {
+		return fmt.Errorf("failed to remove session: %w", err)
+	}
+
+	if !removed {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("Not logged in to %s, nothing to do.", cfg.PortainerURL)))
+		return nil
+	}
+
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Logged out of %s", cfg.PortainerURL)))
+	return nil
+}
@@ -0,0 +1,96 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// serviceLabels are the pod labels naming the service a pod belongs to: the one set by the
+// compose conversion, then the usual labels of hand-written manifests
+var serviceLabels = []string{"io.kompose.service", "app.kubernetes.io/name", "app"}
+
+// podLogSources returns the containers of the pods of a Kubernetes stack, filtered by --service.
+// It returns false when there is nothing to display, after printing the reason.
+func podLogSources(ctx context.Context, client *portainer.Client, cfg *config.Config, stack *portainer.Stack) ([]logSource, bool) {
+	namespace := cmdutil.StackNamespace(cfg, stack.Namespace)
+
+	var pods []portainer.Pod
+	err := spinner.RunWithSpinner("Fetching pod information...", func() error {
+		var fetchErr error
+		pods, fetchErr = client.GetStackPods(ctx, cfg.EnvironmentID, namespace, cfg.StackName)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to fetch pod information"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Note: Pod information could not be retrieved."))
+		fmt.Printf("The user must be allowed to list the pods of the namespace %s.\n", namespace)
+		fmt.Println()
+		return nil, false
+	}
+
+	// Pending pods have not started any container yet
+	var started []portainer.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase != "Pending" && (service == "" || podMatchesService(pod, service)) {
+			started = append(started, pod)
+		}
+	}
+	if len(started) == 0 {
+		fmt.Println()
+		if service != "" {
+			fmt.Printf("No pods found for service '%s'\n", service)
+		} else {
+			fmt.Println(infoStyle.Render("No started pods found for this stack"))
+		}
+		return nil, false
+	}
+
+	sort.Slice(started, func(i, j int) bool {
+		return started[i].Metadata.Name < started[j].Metadata.Name
+	})
+
+	var sources []logSource
+	for _, pod := range started {
+		containers := pod.ContainerNames()
+		for _, container := range containers {
+			podName, containerName := pod.Metadata.Name, container
+			name := podName
+			if len(containers) > 1 {
+				name = podName + "/" + containerName
+			}
+			sources = append(sources, logSource{
+				name: name,
+				get: func(ctx context.Context, tail int) ([]portainer.LogEntry, error) {
+					return client.GetPodLogs(ctx, cfg.EnvironmentID, namespace, podName, containerName, tail)
+				},
+				stream: func(ctx context.Context, tail int, onEntry func(portainer.LogEntry)) error {
+					return client.StreamPodLogs(ctx, cfg.EnvironmentID, namespace, podName, containerName, tail, onEntry)
+				},
+			})
+		}
+	}
+	return sources, true
+}
+
+// podMatchesService reports whether a pod runs the given service, from its labels or, when
+// the manifests set none of them, from the name of its workload
+func podMatchesService(pod portainer.Pod, serviceName string) bool {
+	for _, label := range serviceLabels {
+		if value, ok := pod.Metadata.Labels[label]; ok {
+			return value == serviceName
+		}
+	}
+	return strings.HasPrefix(pod.Metadata.Name, serviceName+"-")
+}
@@ -1,9 +1,12 @@
 package logs
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/deviantony/pctl/internal/cmdutil"
 	"github.com/deviantony/pctl/internal/config"
 	"github.com/deviantony/pctl/internal/errors"
 	"github.com/deviantony/pctl/internal/portainer"
@@ -19,11 +22,13 @@ var (
 	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
 	headerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
 	logStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("7"))
+	stderrStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
 )
 
 var (
 	tailLines      int
 	service        string
+	follow         bool
 	nonInteractive bool
 )
 
@@ -32,7 +37,10 @@ var LogsCmd = &cobra.Command{
 	Short: "View stack container logs",
 	Long: `Display logs from containers in your deployed stack.
 By default, shows the last 50 lines from all containers.
-Use --service to filter logs from a specific service.`,
+Use --service to filter logs from a specific service.
+Use --follow to keep streaming new log lines as they are written.
+On Docker Swarm, the logs of the running tasks of every service are shown, and
+on Kubernetes the logs of the containers of every pod.`,
 	RunE:         runLogs,
 	SilenceUsage: true,
 }
@@ -40,6 +48,7 @@ Use --service to filter logs from a specific service.`,
 func init() {
 	LogsCmd.Flags().IntVarP(&tailLines, "tail", "t", 50, "Number of lines to show from the end of logs")
 	LogsCmd.Flags().StringVarP(&service, "service", "s", "", "Show logs from specific service only")
+	LogsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow log output in real time")
 	LogsCmd.Flags().BoolVar(&nonInteractive, "non-interactive", false, "Force non-interactive mode (useful for testing)")
 }
 
@@ -60,18 +69,25 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Println(infoStyle.Render("Loading configuration..."))
+	if cfg.Target != "" {
+		fmt.Printf("  Target: %s\n", cfg.Target)
+	}
 	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
 	fmt.Printf("  Stack Name: %s\n", cfg.StackName)
 	fmt.Println()
 
 	// Create Portainer client
-	client := portainer.NewClientWithTLS(cfg.PortainerURL, cfg.APIToken, cfg.SkipTLSVerify)
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
 
 	// Check if stack exists
 	var existingStack *portainer.Stack
 	err = spinner.RunWithSpinner("Checking if stack exists...", func() error {
 		var fetchErr error
-		existingStack, fetchErr = client.GetStack(cfg.StackName, cfg.EnvironmentID)
+		existingStack, fetchErr = cmdutil.FindStack(ctx, client, cfg)
 		return fetchErr
 	})
 	if err != nil {
@@ -90,18 +106,53 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Stack '%s' not found in environment %d.\n", cfg.StackName, cfg.EnvironmentID)
 		fmt.Println()
 		fmt.Println(infoStyle.Render("To deploy this stack, run:"))
-		fmt.Printf("  %s\n", infoStyle.Render("pctl deploy"))
+		fmt.Printf("  %s\n", infoStyle.Render("pctl up"))
 		fmt.Println()
 		return nil // Exit cleanly without error
 	}
 
 	fmt.Println(successStyle.Render("✓ Stack found"))
 
+	// Swarm stacks run their containers as service tasks, Kubernetes stacks in pods
+	var (
+		sources []logSource
+		ok      bool
+	)
+	switch {
+	case existingStack.IsSwarm():
+		sources, ok = taskLogSources(ctx, client, cfg)
+	case existingStack.IsKubernetes():
+		sources, ok = podLogSources(ctx, client, cfg, existingStack)
+	default:
+		sources, ok = containerLogSources(ctx, client, cfg)
+	}
+	if !ok {
+		return nil // Exit cleanly without error
+	}
+
+	// Display logs for each container
+	fmt.Println()
+	if follow {
+		return followLogs(ctx, sources, nonInteractive)
+	}
+	return displayLogs(ctx, sources, nonInteractive)
+}
+
+// logSource is a container, a swarm task or a pod container, whose logs are displayed
+type logSource struct {
+	name   string
+	get    func(ctx context.Context, tail int) ([]portainer.LogEntry, error)
+	stream func(ctx context.Context, tail int, onEntry func(portainer.LogEntry)) error
+}
+
+// containerLogSources returns the containers of a compose stack, filtered by --service.
+// It returns false when there is nothing to display, after printing the reason.
+func containerLogSources(ctx context.Context, client *portainer.Client, cfg *config.Config) ([]logSource, bool) {
 	// Get containers for the stack
 	var containers []portainer.Container
-	err = spinner.RunWithSpinner("Fetching container information...", func() error {
+	err := spinner.RunWithSpinner("Fetching container information...", func() error {
 		var fetchErr error
-		containers, fetchErr = client.GetStackContainers(cfg.EnvironmentID, cfg.StackName)
+		containers, fetchErr = client.GetStackContainers(ctx, cfg.EnvironmentID, cfg.StackName)
 		return fetchErr
 	})
 	if err != nil {
@@ -113,13 +164,13 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		fmt.Println(infoStyle.Render("Note: Container information could not be retrieved."))
 		fmt.Println("This might be due to Docker API access restrictions or filter issues.")
 		fmt.Println()
-		return nil // Exit cleanly without error
+		return nil, false
 	}
 
 	if len(containers) == 0 {
 		fmt.Println()
 		fmt.Println(infoStyle.Render("No containers found for this stack"))
-		return nil
+		return nil, false
 	}
 
 	// Filter containers by service if specified
@@ -128,13 +179,46 @@ func runLogs(cmd *cobra.Command, args []string) error {
 		if len(containers) == 0 {
 			fmt.Println()
 			fmt.Printf("No containers found for service '%s'\n", service)
-			return nil
+			return nil, false
 		}
 	}
 
-	// Display logs for each container
-	fmt.Println()
-	return displayLogs(client, containers, cfg.EnvironmentID, nonInteractive)
+	// The container list does not tell whether a container has a TTY, which decides how its
+	// logs are framed. Inspect every container once rather than on every fetch.
+	tty := make([]bool, len(containers))
+	err = spinner.RunWithSpinner("Inspecting containers...", func() error {
+		for i, container := range containers {
+			inspect, inspectErr := client.InspectContainer(ctx, cfg.EnvironmentID, container.ID)
+			if inspectErr != nil {
+				return inspectErr
+			}
+			tty[i] = inspect.Config.Tty
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to inspect containers"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil, false
+	}
+
+	sources := make([]logSource, len(containers))
+	for i, container := range containers {
+		containerID, containerTTY := container.ID, tty[i]
+		sources[i] = logSource{
+			name: getPrimaryContainerName(container.Names),
+			get: func(ctx context.Context, tail int) ([]portainer.LogEntry, error) {
+				return client.GetContainerLogs(ctx, cfg.EnvironmentID, containerID, containerTTY, tail)
+			},
+			stream: func(ctx context.Context, tail int, onEntry func(portainer.LogEntry)) error {
+				return client.StreamContainerLogs(ctx, cfg.EnvironmentID, containerID, containerTTY, tail, onEntry)
+			},
+		}
+	}
+	return sources, true
 }
 
 func filterContainersByService(containers []portainer.Container, serviceName string) []portainer.Container {
@@ -172,28 +256,26 @@ func filterContainersByService(containers []portainer.Container, serviceName str
 	return filtered
 }
 
-func displayLogs(client *portainer.Client, containers []portainer.Container, environmentID int, forceNonInteractive bool) error {
+func displayLogs(ctx context.Context, sources []logSource, forceNonInteractive bool) error {
 	// Collect logs for all containers
 	var containerLogs []ContainerLogs
 
-	for _, container := range containers {
-		containerName := getPrimaryContainerName(container.Names)
-
+	for _, source := range sources {
 		// Fetch logs for this container
-		logs, err := client.GetContainerLogs(environmentID, container.ID, tailLines)
+		logs, err := source.get(ctx, tailLines)
 		if err != nil {
-			fmt.Printf("Error fetching logs for %s: %v\n", containerName, err)
-			// Add empty logs entry to maintain container order
+			fmt.Printf("Error fetching logs for %s: %v\n", source.name, err)
+			// Add an error entry to maintain container order
 			containerLogs = append(containerLogs, ContainerLogs{
-				Name: containerName,
-				Logs: fmt.Sprintf("Error fetching logs: %v", err),
+				Name:    source.name,
+				Entries: []portainer.LogEntry{errorEntry("Error fetching logs", err)},
 			})
 			continue
 		}
 
 		containerLogs = append(containerLogs, ContainerLogs{
-			Name: containerName,
-			Logs: logs,
+			Name:    source.name,
+			Entries: logs,
 		})
 	}
 
@@ -204,6 +286,45 @@ func displayLogs(client *portainer.Client, containers []portainer.Container, env
 	return RunViewer(containerLogs)
 }
 
+// followLogs streams the logs until the command context is cancelled (Ctrl-C or termination)
+func followLogs(ctx context.Context, sources []logSource, forceNonInteractive bool) error {
+	containerLogs := make([]ContainerLogs, len(sources))
+	for i, source := range sources {
+		containerLogs[i] = ContainerLogs{Name: source.name}
+	}
+
+	// Stream every container concurrently, the last tailLines lines are included in the stream
+	follower := func(ctx context.Context, onEntry func(containerIdx int, entry portainer.LogEntry)) {
+		var wg sync.WaitGroup
+		for i, source := range sources {
+			wg.Add(1)
+			go func(idx int, source logSource) {
+				defer wg.Done()
+				err := source.stream(ctx, tailLines, func(entry portainer.LogEntry) {
+					onEntry(idx, entry)
+				})
+				if err != nil {
+					onEntry(idx, errorEntry("Error streaming logs", err))
+				}
+			}(i, source)
+		}
+		wg.Wait()
+	}
+
+	if forceNonInteractive {
+		return RunNonInteractiveFollower(ctx, containerLogs, follower)
+	}
+	return RunFollowViewer(ctx, containerLogs, follower)
+}
+
+// errorEntry reports a log retrieval failure inline with the container logs
+func errorEntry(message string, err error) portainer.LogEntry {
+	return portainer.LogEntry{
+		Stream:  portainer.LogStreamStderr,
+		Message: fmt.Sprintf("%s: %v", message, err),
+	}
+}
+
 func getPrimaryContainerName(names []string) string {
 	if len(names) == 0 {
 		return "unknown"
@@ -0,0 +1,93 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// taskLogSources returns the running tasks of a swarm stack, filtered by --service.
+// It returns false when there is nothing to display, after printing the reason.
+func taskLogSources(ctx context.Context, client *portainer.Client, cfg *config.Config) ([]logSource, bool) {
+	var (
+		services []portainer.Service
+		tasks    []portainer.Task
+	)
+	err := spinner.RunWithSpinner("Fetching service information...", func() error {
+		var fetchErr error
+		services, fetchErr = client.GetStackServices(ctx, cfg.EnvironmentID, cfg.StackName)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		tasks, fetchErr = client.GetStackTasks(ctx, cfg.EnvironmentID, cfg.StackName)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to fetch service information"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Note: Service information could not be retrieved."))
+		fmt.Println("The environment must be a swarm manager node for services to be listed.")
+		fmt.Println()
+		return nil, false
+	}
+
+	// Filter services by name if specified, stack services are named <stack>_<service>
+	serviceNames := make(map[string]string, len(services))
+	for _, svc := range services {
+		if service == "" || svc.Spec.Name == service || svc.Spec.Name == cfg.StackName+"_"+service {
+			serviceNames[svc.ID] = svc.Spec.Name
+		}
+	}
+	if service != "" && len(serviceNames) == 0 {
+		fmt.Println()
+		fmt.Printf("No service found with name '%s'\n", service)
+		return nil, false
+	}
+
+	// Only the tasks meant to be running, the ones that were replaced are kept as history
+	var running []portainer.Task
+	for _, task := range portainer.CurrentTasks(tasks) {
+		if _, ok := serviceNames[task.ServiceID]; ok && task.DesiredState == portainer.TaskStateRunning {
+			running = append(running, task)
+		}
+	}
+	if len(running) == 0 {
+		fmt.Println()
+		fmt.Println(infoStyle.Render("No running tasks found for this stack"))
+		return nil, false
+	}
+
+	sort.Slice(running, func(i, j int) bool {
+		a, b := running[i], running[j]
+		if serviceNames[a.ServiceID] != serviceNames[b.ServiceID] {
+			return serviceNames[a.ServiceID] < serviceNames[b.ServiceID]
+		}
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.NodeID < b.NodeID
+	})
+
+	sources := make([]logSource, len(running))
+	for i, task := range running {
+		taskID, taskTTY := task.ID, task.Spec.ContainerSpec.TTY
+		sources[i] = logSource{
+			name: portainer.TaskName(serviceNames[task.ServiceID], task),
+			get: func(ctx context.Context, tail int) ([]portainer.LogEntry, error) {
+				return client.GetTaskLogs(ctx, cfg.EnvironmentID, taskID, taskTTY, tail)
+			},
+			stream: func(ctx context.Context, tail int, onEntry func(portainer.LogEntry)) error {
+				return client.StreamTaskLogs(ctx, cfg.EnvironmentID, taskID, taskTTY, tail, onEntry)
+			},
+		}
+	}
+	return sources, true
+}
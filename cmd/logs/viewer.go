@@ -1,14 +1,17 @@
 package logs
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/deviantony/pctl/internal/portainer"
 )
 
 // LogsViewer represents the TUI model for viewing logs
@@ -16,20 +19,35 @@ type LogsViewer struct {
 	viewport    viewport.Model
 	containers  []ContainerLogs
 	currentIdx  int
+	follow      bool
 	ready       bool
 	width       int
 	height      int
 	headerStyle lipgloss.Style
 	logStyle    lipgloss.Style
+	stderrStyle lipgloss.Style
 	helpStyle   lipgloss.Style
 }
 
 // ContainerLogs holds logs for a single container
 type ContainerLogs struct {
-	Name string
-	Logs string
+	Name    string
+	Entries []portainer.LogEntry
 }
 
+// LogFollower streams log entries for the containers, identified by their index,
+// until ctx is cancelled or all streams end
+type LogFollower func(ctx context.Context, onEntry func(containerIdx int, entry portainer.LogEntry))
+
+// logEntryMsg carries a newly received log entry for a container
+type logEntryMsg struct {
+	containerIdx int
+	entry        portainer.LogEntry
+}
+
+// timestampFormat is used to display log entry timestamps
+const timestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
 // NewLogsViewer creates a new logs viewer
 func NewLogsViewer(containers []ContainerLogs) *LogsViewer {
 	return &LogsViewer{
@@ -43,6 +61,8 @@ func NewLogsViewer(containers []ContainerLogs) *LogsViewer {
 			Padding(0, 1),
 		logStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("7")),
+		stderrStyle: lipgloss.NewStyle().
+			Foreground(lipgloss.Color("9")),
 		helpStyle: lipgloss.NewStyle().
 			Foreground(lipgloss.Color("8")).
 			Italic(true),
@@ -77,8 +97,27 @@ func (m LogsViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.viewport = viewport.New(msg.Width, viewportHeight)
 		m.viewport.SetContent(m.getCurrentContent())
+		if m.follow {
+			m.viewport.GotoBottom()
+		}
 		m.ready = true
 
+	case logEntryMsg:
+		if msg.containerIdx < 0 || msg.containerIdx >= len(m.containers) {
+			return m, nil
+		}
+		m.containers[msg.containerIdx].Entries = append(m.containers[msg.containerIdx].Entries, msg.entry)
+
+		if m.ready && msg.containerIdx == m.currentIdx {
+			// Only auto-scroll when the user is not reading older lines
+			atBottom := m.viewport.AtBottom()
+			m.viewport.SetContent(m.getCurrentContent())
+			if atBottom {
+				m.viewport.GotoBottom()
+			}
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "q", "ctrl+c":
@@ -98,14 +137,12 @@ func (m LogsViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "n", "right":
 			if m.currentIdx < len(m.containers)-1 {
 				m.currentIdx++
-				m.viewport.SetContent(m.getCurrentContent())
-				m.viewport.GotoTop()
+				m.showCurrentContainer()
 			}
 		case "p", "left":
 			if m.currentIdx > 0 {
 				m.currentIdx--
-				m.viewport.SetContent(m.getCurrentContent())
-				m.viewport.GotoTop()
+				m.showCurrentContainer()
 			}
 		}
 	}
@@ -140,12 +177,26 @@ func (m LogsViewer) View() string {
 	content.WriteString("\n\n")
 
 	// Help text
-	help := m.helpStyle.Render("↑/↓: scroll • n/p: next/prev container • g/G: top/bottom • q: quit")
+	helpText := "↑/↓: scroll • n/p: next/prev container • g/G: top/bottom • q: quit"
+	if m.follow {
+		helpText = "following • " + helpText
+	}
+	help := m.helpStyle.Render(helpText)
 	content.WriteString(help)
 
 	return content.String()
 }
 
+// showCurrentContainer loads the current container logs into the viewport
+func (m *LogsViewer) showCurrentContainer() {
+	m.viewport.SetContent(m.getCurrentContent())
+	if m.follow {
+		m.viewport.GotoBottom()
+	} else {
+		m.viewport.GotoTop()
+	}
+}
+
 // getCurrentContent returns the formatted content for the current container
 func (m LogsViewer) getCurrentContent() string {
 	if len(m.containers) == 0 {
@@ -153,33 +204,45 @@ func (m LogsViewer) getCurrentContent() string {
 	}
 
 	container := m.containers[m.currentIdx]
-	if container.Logs == "" {
+	if len(container.Entries) == 0 {
 		return "(no logs available)"
 	}
 
 	// Calculate available width for content (account for viewport width and some padding)
 	availableWidth := m.width - 4 // Leave some padding on the sides
 
-	// Split logs into lines and apply styling
-	lines := strings.Split(strings.TrimSpace(container.Logs), "\n")
+	// Wrap each entry and colour it by stream
 	var styledLines []string
+	for _, entry := range container.Entries {
+		style := m.logStyle
+		if entry.Stream == portainer.LogStreamStderr {
+			style = m.stderrStyle
+		}
 
-	for _, line := range lines {
-		if line != "" {
-			// Clean up Docker log format (remove stream prefixes)
-			cleanLine := cleanDockerLogLine(line)
-
-			// Wrap long lines
-			wrappedLines := wrapText(cleanLine, availableWidth)
-			for _, wrappedLine := range wrappedLines {
-				styledLines = append(styledLines, m.logStyle.Render(wrappedLine))
-			}
+		for _, wrappedLine := range wrapText(formatLogEntry(entry), availableWidth) {
+			styledLines = append(styledLines, style.Render(wrappedLine))
 		}
 	}
 
 	return strings.Join(styledLines, "\n")
 }
 
+// formatLogEntry renders a log entry as a single line of text
+func formatLogEntry(entry portainer.LogEntry) string {
+	if entry.Timestamp.IsZero() {
+		return entry.Message
+	}
+	return entry.Timestamp.Format(timestampFormat) + " " + entry.Message
+}
+
+// entryStyle returns the non-interactive style for a log entry
+func entryStyle(entry portainer.LogEntry) lipgloss.Style {
+	if entry.Stream == portainer.LogStreamStderr {
+		return stderrStyle
+	}
+	return logStyle
+}
+
 // wrapText wraps text to fit within the specified width
 func wrapText(text string, width int) []string {
 	if width <= 0 {
@@ -237,39 +300,44 @@ func wrapText(text string, width int) []string {
 	return lines
 }
 
-// cleanDockerLogLine removes Docker's log format prefixes
-func cleanDockerLogLine(line string) string {
-	// Docker logs come with a prefix like: [8 bytes of stream info][timestamp] actual log
-	// We need to skip the first 8 bytes and find the timestamp
-	if len(line) < 8 {
-		return line
+// RunViewer starts the interactive logs viewer
+func RunViewer(containers []ContainerLogs) error {
+	// Check if we're in an interactive terminal
+	if !isInteractive() {
+		return RunNonInteractiveViewer(containers)
 	}
 
-	// Skip the first 8 bytes (stream info) and look for timestamp
-	content := line[8:]
+	model := NewLogsViewer(containers)
 
-	// Look for timestamp pattern (ISO 8601 format)
-	// Timestamp is usually at the beginning after the stream info
-	if len(content) > 26 && content[0] == '2' && content[4] == '-' && content[7] == '-' {
-		// Found timestamp, return the content as-is
-		return content
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("failed to run logs viewer: %w", err)
 	}
 
-	// If no timestamp found, return the original line
-	return line
+	return nil
 }
 
-// RunViewer starts the interactive logs viewer
-func RunViewer(containers []ContainerLogs) error {
+// RunFollowViewer starts the interactive logs viewer and appends lines from follow
+// as they arrive until the user quits
+func RunFollowViewer(ctx context.Context, containers []ContainerLogs, follow LogFollower) error {
 	// Check if we're in an interactive terminal
 	if !isInteractive() {
-		return RunNonInteractiveViewer(containers)
+		return RunNonInteractiveFollower(ctx, containers, follow)
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	model := NewLogsViewer(containers)
+	model.follow = true
 
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx))
+
+	go follow(ctx, func(containerIdx int, entry portainer.LogEntry) {
+		p.Send(logEntryMsg{containerIdx: containerIdx, entry: entry})
+	})
+
+	if _, err := p.Run(); err != nil && ctx.Err() == nil {
 		return fmt.Errorf("failed to run logs viewer: %w", err)
 	}
 
@@ -314,18 +382,15 @@ func RunNonInteractiveViewer(containers []ContainerLogs) error {
 
 		fmt.Println(headerStyle.Render(fmt.Sprintf("=== %s ===", container.Name)))
 
-		if container.Logs == "" {
+		if len(container.Entries) == 0 {
 			fmt.Println("(no logs available)")
 		} else {
-			lines := strings.Split(strings.TrimSpace(container.Logs), "\n")
-			for _, line := range lines {
-				if line != "" {
-					cleanLine := cleanDockerLogLine(line)
-					// Wrap long lines for non-interactive output
-					wrappedLines := wrapText(cleanLine, width-4) // Leave some padding
-					for _, wrappedLine := range wrappedLines {
-						fmt.Println(logStyle.Render(wrappedLine))
-					}
+			for _, entry := range container.Entries {
+				style := entryStyle(entry)
+				// Wrap long lines for non-interactive output
+				wrappedLines := wrapText(formatLogEntry(entry), width-4) // Leave some padding
+				for _, wrappedLine := range wrappedLines {
+					fmt.Println(style.Render(wrappedLine))
 				}
 			}
 		}
@@ -333,3 +398,27 @@ func RunNonInteractiveViewer(containers []ContainerLogs) error {
 
 	return nil
 }
+
+// RunNonInteractiveFollower prints log lines prefixed with their container name as they arrive
+func RunNonInteractiveFollower(ctx context.Context, containers []ContainerLogs, follow LogFollower) error {
+	// Align the container name prefixes
+	nameWidth := 0
+	for _, container := range containers {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(container.Name))
+	}
+
+	var mu sync.Mutex
+	follow(ctx, func(containerIdx int, entry portainer.LogEntry) {
+		if containerIdx < 0 || containerIdx >= len(containers) {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		prefix := headerStyle.Render(fmt.Sprintf("%-*s |", nameWidth, containers[containerIdx].Name))
+		fmt.Printf("%s %s\n", prefix, entryStyle(entry).Render(formatLogEntry(entry)))
+	})
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package ps
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// edgePollInterval is the delay between two status checks with --wait. Edge agents check in
+// every few seconds to minutes, so polling faster would not report changes sooner.
+const edgePollInterval = 5 * time.Second
+
+// Column widths of the edge status table
+const (
+	environmentColumnWidth = 25
+	edgeStatusColumnWidth  = 24
+	updatedColumnWidth     = 10
+	edgeTableWidth         = environmentColumnWidth + edgeStatusColumnWidth + updatedColumnWidth + 30
+)
+
+// showEdgeStack displays the deployment status of an edge stack on every environment, and
+// polls it with --wait until the deployment settled
+func showEdgeStack(ctx context.Context, client *portainer.Client, cfg *config.Config) error {
+	var (
+		stack  *portainer.EdgeStack
+		groups []portainer.EdgeGroup
+	)
+	err := spinner.RunWithSpinner("Checking if edge stack exists...", func() error {
+		var fetchErr error
+		stack, fetchErr = client.GetEdgeStack(ctx, cfg.StackName)
+		if fetchErr != nil || stack == nil {
+			return fetchErr
+		}
+		groups, fetchErr = client.GetEdgeGroups(ctx)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing edge stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	if stack == nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Edge stack not found"))
+		fmt.Println()
+		fmt.Printf("Edge stack '%s' not found.\n", cfg.StackName)
+		fmt.Println()
+		fmt.Println(infoStyle.Render("To deploy this stack, run:"))
+		fmt.Printf("  %s\n", infoStyle.Render("pctl up"))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	environmentIDs := cmdutil.EdgeStackEnvironments(stack, groups)
+	names := environmentNames(ctx, client, environmentIDs)
+
+	fmt.Println()
+	displayEdgeStackInfo(stack, groups)
+	fmt.Println()
+	displayEdgeStatus(stack, environmentIDs, names)
+
+	if !wait {
+		return nil
+	}
+	return waitEdgeStack(ctx, client, stack, groups, names)
+}
+
+// waitEdgeStack polls the edge stack and reports status changes until every environment
+// settled. An error is returned when the deployment failed on some environments.
+func waitEdgeStack(ctx context.Context, client *portainer.Client, stack *portainer.EdgeStack, groups []portainer.EdgeGroup, names map[int]string) error {
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Waiting for the Edge agents to deploy the stack (Ctrl-C to stop)..."))
+
+	ticker := time.NewTicker(edgePollInterval)
+	defer ticker.Stop()
+
+	for {
+		environmentIDs := cmdutil.EdgeStackEnvironments(stack, groups)
+		if summary := summarizeEdgeStatus(stack, environmentIDs); summary.settled() {
+			fmt.Println()
+			if summary.failed > 0 {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("✗ %s", summary)))
+				return fmt.Errorf("edge stack deployment failed on %d environment(s)", summary.failed)
+			}
+			fmt.Println(successStyle.Render(fmt.Sprintf("✓ %s", summary)))
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			fmt.Println(infoStyle.Render("Stopped waiting, the deployment continues in the background."))
+			return nil
+		case <-ticker.C:
+		}
+
+		updated, err := client.GetEdgeStackByID(ctx, stack.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			fmt.Println()
+			fmt.Println(errorStyle.Render("✗ Failed to get the edge stack status"))
+			fmt.Println()
+			fmt.Println(errors.FormatError(err))
+			fmt.Println()
+			return nil // Exit cleanly without showing usage
+		}
+
+		// Report the environments whose status changed since the last check
+		for _, id := range cmdutil.EdgeStackEnvironments(updated, groups) {
+			previous, current := stack.Status[id].Current(), updated.Status[id].Current()
+			if previous.Type == current.Type {
+				continue
+			}
+			if names[id] == "" {
+				names[id] = fmt.Sprintf("environment %d", id)
+			}
+			line := fmt.Sprintf("  %s: %s → %s", names[id], edgeStatusText(previous.Type), edgeStatusText(current.Type))
+			if current.Error != "" {
+				line += fmt.Sprintf(" (%s)", current.Error)
+			}
+			fmt.Println(line)
+		}
+		stack = updated
+	}
+}
+
+func displayEdgeStackInfo(stack *portainer.EdgeStack, groups []portainer.EdgeGroup) {
+	groupNames := make(map[int]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	var stackGroups []string
+	for _, id := range stack.EdgeGroups {
+		if name, ok := groupNames[id]; ok {
+			stackGroups = append(stackGroups, name)
+		} else {
+			stackGroups = append(stackGroups, fmt.Sprintf("%d", id))
+		}
+	}
+
+	fmt.Println(headerStyle.Render("Edge Stack Information:"))
+	fmt.Printf("  Name: %s\n", stack.Name)
+	fmt.Printf("  ID: %d\n", stack.ID)
+	fmt.Printf("  Edge Groups: %s\n", strings.Join(stackGroups, ", "))
+	if stack.Version > 0 {
+		fmt.Printf("  Version: %d\n", stack.Version)
+	}
+	if stack.CreationDate > 0 {
+		fmt.Printf("  Created: %s\n", time.Unix(stack.CreationDate, 0).Format("2006-01-02 15:04:05"))
+	}
+}
+
+func displayEdgeStatus(stack *portainer.EdgeStack, environmentIDs []int, names map[int]string) {
+	fmt.Println(headerStyle.Render("Deployment Status:"))
+	if len(environmentIDs) == 0 {
+		fmt.Println("  No environments in the edge groups of this stack")
+		return
+	}
+
+	fmt.Printf("%-*s %-*s %-*s %s\n",
+		environmentColumnWidth, headerStyle.Render("ENVIRONMENT"),
+		edgeStatusColumnWidth, headerStyle.Render("STATUS"),
+		updatedColumnWidth, headerStyle.Render("UPDATED"),
+		headerStyle.Render("ERROR"))
+	fmt.Println(strings.Repeat("─", edgeTableWidth))
+	for _, id := range environmentIDs {
+		name := names[id]
+		if name == "" {
+			name = fmt.Sprintf("environment %d", id)
+		}
+
+		current := stack.Status[id].Current()
+		updated := ""
+		if current.Time > 0 {
+			updated = formatAge(time.Unix(current.Time, 0))
+		}
+
+		fmt.Printf("%-*s %-*s %-*s %s\n",
+			environmentColumnWidth, truncate(name, environmentColumnWidth),
+			edgeStatusColumnWidth, edgeStatusText(current.Type),
+			updatedColumnWidth, updated,
+			current.Error)
+	}
+
+	fmt.Println()
+	fmt.Println(summarizeEdgeStatus(stack, environmentIDs))
+}
+
+// environmentNames returns the names of the environments, the IDs are displayed instead when
+// they cannot be retrieved
+func environmentNames(ctx context.Context, client *portainer.Client, environmentIDs []int) map[int]string {
+	names := make(map[int]string, len(environmentIDs))
+	environments, err := client.GetEnvironmentsByID(ctx, environmentIDs)
+	if err != nil {
+		return names
+	}
+	for _, environment := range environments {
+		names[environment.ID] = environment.Name
+	}
+	return names
+}
+
+// edgeSummary counts the environments of an edge stack by deployment outcome
+type edgeSummary struct {
+	deployed int
+	failed   int
+	pending  int
+}
+
+func summarizeEdgeStatus(stack *portainer.EdgeStack, environmentIDs []int) edgeSummary {
+	var summary edgeSummary
+	for _, id := range environmentIDs {
+		switch stack.Status[id].Current().Type {
+		case portainer.EdgeStatusRunning, portainer.EdgeStatusCompleted, portainer.EdgeStatusRemoteUpdateSuccess:
+			summary.deployed++
+		case portainer.EdgeStatusError:
+			summary.failed++
+		default:
+			summary.pending++
+		}
+	}
+	return summary
+}
+
+// settled reports whether every environment deployed the stack or failed to
+func (s edgeSummary) settled() bool {
+	return s.pending == 0
+}
+
+func (s edgeSummary) String() string {
+	return fmt.Sprintf("Deployed on %d environment(s), failed on %d, in progress on %d", s.deployed, s.failed, s.pending)
+}
+
+func edgeStatusText(status int) string {
+	switch status {
+	case portainer.EdgeStatusPending:
+		return "Pending"
+	case portainer.EdgeStatusDeploymentReceived:
+		return "Deployment received"
+	case portainer.EdgeStatusError:
+		return "Error"
+	case portainer.EdgeStatusAcknowledged:
+		return "Acknowledged"
+	case portainer.EdgeStatusRemoved:
+		return "Removed"
+	case portainer.EdgeStatusRemoteUpdateSuccess:
+		return "Updated"
+	case portainer.EdgeStatusImagesPulled:
+		return "Images pulled"
+	case portainer.EdgeStatusRunning:
+		return "Running"
+	case portainer.EdgeStatusDeploying:
+		return "Deploying"
+	case portainer.EdgeStatusRemoving:
+		return "Removing"
+	case portainer.EdgeStatusPausedDeploying:
+		return "Paused"
+	case portainer.EdgeStatusCompleted:
+		return "Completed"
+	default:
+		return fmt.Sprintf("Unknown (%d)", status)
+	}
+}
@@ -0,0 +1,86 @@
+package ps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// Column widths of the Kubernetes pods table
+const (
+	podColumnWidth      = 35
+	readyColumnWidth    = 8
+	podStatusWidth      = 20
+	restartsColumnWidth = 10
+	podsTableWidth      = podColumnWidth + readyColumnWidth + podStatusWidth + restartsColumnWidth + 20
+)
+
+// showKubernetesStack displays the pods of a Kubernetes stack, listed via the Kubernetes proxy
+func showKubernetesStack(ctx context.Context, client *portainer.Client, cfg *config.Config, stackDetails *portainer.StackDetails) error {
+	namespace := cmdutil.StackNamespace(cfg, stackDetails.Namespace)
+
+	var pods []portainer.Pod
+	err := spinner.RunWithSpinnerAndSuccess("Fetching pod information...", "✓ Pod information loaded", func() error {
+		var fetchErr error
+		pods, fetchErr = client.GetStackPods(ctx, cfg.EnvironmentID, namespace, cfg.StackName)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to fetch pod information"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Stack information (pods unavailable):"))
+		fmt.Println()
+		displayStackInfo(stackDetails)
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Note: Pod information could not be retrieved."))
+		fmt.Printf("The user must be allowed to list the pods of the namespace %s.\n", namespace)
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Metadata.Name < pods[j].Metadata.Name
+	})
+
+	fmt.Println()
+	displayStackInfo(stackDetails)
+	fmt.Println()
+	displayPods(pods)
+
+	return nil
+}
+
+func displayPods(pods []portainer.Pod) {
+	fmt.Println(headerStyle.Render("Pods:"))
+	if len(pods) == 0 {
+		fmt.Println("  No pods found for this stack")
+		return
+	}
+
+	fmt.Printf("%-*s %-*s %-*s %-*s %s\n",
+		podColumnWidth, headerStyle.Render("NAME"),
+		readyColumnWidth, headerStyle.Render("READY"),
+		podStatusWidth, headerStyle.Render("STATUS"),
+		restartsColumnWidth, headerStyle.Render("RESTARTS"),
+		headerStyle.Render("AGE"))
+	fmt.Println(strings.Repeat("─", podsTableWidth))
+	for _, pod := range pods {
+		ready, total := pod.Ready()
+		fmt.Printf("%-*s %-*s %-*s %-*d %s\n",
+			podColumnWidth, truncate(pod.Metadata.Name, podColumnWidth),
+			readyColumnWidth, fmt.Sprintf("%d/%d", ready, total),
+			podStatusWidth, truncate(pod.StatusText(), podStatusWidth),
+			restartsColumnWidth, pod.Restarts(),
+			formatAge(pod.Metadata.CreationTimestamp))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/deviantony/pctl/internal/cmdutil"
 	"github.com/deviantony/pctl/internal/config"
 	"github.com/deviantony/pctl/internal/errors"
 	"github.com/deviantony/pctl/internal/portainer"
@@ -17,9 +18,10 @@ import (
 )
 
 var (
-	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
-	infoStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
-	headerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	headerStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
 )
 
 // Column width constants for consistent formatting
@@ -35,11 +37,22 @@ var PsCmd = &cobra.Command{
 	Use:   "ps",
 	Short: "Show stack status and running containers",
 	Long: `Display the status of your deployed stack and its running containers.
-Shows stack information, container status, ports, and resource usage.`,
+Shows stack information, container status, ports, and resource usage.
+On Docker Swarm, the services of the stack and their tasks are shown instead,
+and on Kubernetes the pods of the stack.
+On Edge environments, the deployment status of the edge stack on every environment
+is shown, use --wait to follow it until the deployment settles.`,
 	RunE:         runPs,
 	SilenceUsage: true,
 }
 
+// wait polls the deployment status of an edge stack until every environment settled
+var wait bool
+
+func init() {
+	PsCmd.Flags().BoolVarP(&wait, "wait", "w", false, "Edge stacks: poll the deployment status until every environment is running or failed")
+}
+
 func runPs(cmd *cobra.Command, args []string) error {
 	// Load configuration
 	cfg, err := config.Load()
@@ -57,18 +70,44 @@ func runPs(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Println(infoStyle.Render("Loading configuration..."))
+	if cfg.Target != "" {
+		fmt.Printf("  Target: %s\n", cfg.Target)
+	}
 	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
 	fmt.Printf("  Stack Name: %s\n", cfg.StackName)
 	fmt.Println()
 
 	// Create Portainer client
-	client := portainer.NewClientWithTLS(cfg.PortainerURL, cfg.APIToken, cfg.SkipTLSVerify)
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Edge agents cannot be reached through the Docker proxy, their stacks are edge stacks
+	var edge bool
+	err = spinner.RunWithSpinner("Checking environment...", func() error {
+		var fetchErr error
+		edge, fetchErr = cmdutil.IsEdgeEnvironment(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check the environment"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+	if edge {
+		return showEdgeStack(ctx, client, cfg)
+	}
 
 	// Check if stack exists
 	var existingStack *portainer.Stack
 	err = spinner.RunWithSpinnerAndSuccess("Checking if stack exists...", "✓ Stack found", func() error {
 		var fetchErr error
-		existingStack, fetchErr = client.GetStack(cfg.StackName, cfg.EnvironmentID)
+		existingStack, fetchErr = cmdutil.FindStack(ctx, client, cfg)
 		return fetchErr
 	})
 	if err != nil {
@@ -87,7 +126,7 @@ func runPs(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Stack '%s' not found in environment %d.\n", cfg.StackName, cfg.EnvironmentID)
 		fmt.Println()
 		fmt.Println(infoStyle.Render("To deploy this stack, run:"))
-		fmt.Printf("  %s\n", infoStyle.Render("pctl deploy"))
+		fmt.Printf("  %s\n", infoStyle.Render("pctl up"))
 		fmt.Println()
 		return nil // Exit cleanly without error
 	}
@@ -96,7 +135,7 @@ func runPs(cmd *cobra.Command, args []string) error {
 	var stackDetails *portainer.StackDetails
 	err = spinner.RunWithSpinnerAndSuccess("Fetching stack details...", "✓ Stack details retrieved", func() error {
 		var fetchErr error
-		stackDetails, fetchErr = client.GetStackDetails(existingStack.ID)
+		stackDetails, fetchErr = client.GetStackDetails(ctx, existingStack.ID)
 		return fetchErr
 	})
 	if err != nil {
@@ -108,11 +147,19 @@ func runPs(cmd *cobra.Command, args []string) error {
 		return nil // Exit cleanly without showing usage
 	}
 
+	// Swarm stacks run services, their containers are tasks spread over the cluster nodes
+	if existingStack.IsSwarm() {
+		return showSwarmStack(ctx, client, cfg, stackDetails)
+	}
+	if existingStack.IsKubernetes() {
+		return showKubernetesStack(ctx, client, cfg, stackDetails)
+	}
+
 	// Get containers for the stack
 	var containers []portainer.Container
 	err = spinner.RunWithSpinnerAndSuccess("Fetching container information...", "✓ Container information loaded", func() error {
 		var fetchErr error
-		containers, fetchErr = client.GetStackContainers(cfg.EnvironmentID, cfg.StackName)
+		containers, fetchErr = client.GetStackContainers(ctx, cfg.EnvironmentID, cfg.StackName)
 		return fetchErr
 	})
 	if err != nil {
@@ -144,8 +191,22 @@ func displayStackInfo(stack *portainer.StackDetails) {
 	fmt.Println(headerStyle.Render("Stack Information:"))
 	fmt.Printf("  Name: %s\n", stack.Name)
 	fmt.Printf("  ID: %d\n", stack.ID)
+	fmt.Printf("  Type: %s\n", getTypeText(stack.Type))
 	fmt.Printf("  Status: %s\n", getStatusText(stack.Status))
 	fmt.Printf("  Environment ID: %d\n", stack.EnvironmentID)
+	if stack.SwarmID != "" {
+		fmt.Printf("  Swarm ID: %s\n", stack.SwarmID)
+	}
+	if stack.Namespace != "" {
+		fmt.Printf("  Namespace: %s\n", stack.Namespace)
+	}
+
+	if git := stack.GitConfig; git != nil {
+		fmt.Printf("  Git Repository: %s (%s)\n", git.URL, git.ReferenceName)
+		if len(git.ConfigHash) >= 7 {
+			fmt.Printf("  Deployed Commit: %s\n", git.ConfigHash[:7])
+		}
+	}
 
 	if stack.CreatedAt > 0 {
 		createdTime := time.Unix(stack.CreatedAt, 0)
@@ -209,6 +270,19 @@ func getStatusText(status int) string {
 	}
 }
 
+func getTypeText(stackType int) string {
+	switch stackType {
+	case portainer.StackTypeSwarm:
+		return "Docker Swarm"
+	case portainer.StackTypeCompose:
+		return "Docker Compose"
+	case portainer.StackTypeKubernetes:
+		return "Kubernetes"
+	default:
+		return fmt.Sprintf("Unknown (%d)", stackType)
+	}
+}
+
 func getContainerStatus(container portainer.Container) string {
 	status := container.Status
 	if container.State == "running" {
@@ -0,0 +1,223 @@
+package ps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// Column widths of the swarm services and tasks tables
+const (
+	modeColumnWidth     = 12
+	replicasColumnWidth = 10
+	desiredColumnWidth  = 10
+	stateColumnWidth    = 20
+	servicesTableWidth  = nameColumnWidth + imageColumnWidth + modeColumnWidth + replicasColumnWidth + portsColumnWidth
+	tasksTableWidth     = nameColumnWidth + desiredColumnWidth + stateColumnWidth + 30
+)
+
+// showSwarmStack displays the services of a swarm stack and the tasks running them
+func showSwarmStack(ctx context.Context, client *portainer.Client, cfg *config.Config, stackDetails *portainer.StackDetails) error {
+	var (
+		services []portainer.Service
+		tasks    []portainer.Task
+	)
+	err := spinner.RunWithSpinnerAndSuccess("Fetching service information...", "✓ Service information loaded", func() error {
+		var fetchErr error
+		services, fetchErr = client.GetStackServices(ctx, cfg.EnvironmentID, cfg.StackName)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		tasks, fetchErr = client.GetStackTasks(ctx, cfg.EnvironmentID, cfg.StackName)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to fetch service information"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Stack information (services unavailable):"))
+		fmt.Println()
+		displayStackInfo(stackDetails)
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Note: Service information could not be retrieved."))
+		fmt.Println("The environment must be a swarm manager node for services to be listed.")
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Spec.Name < services[j].Spec.Name
+	})
+
+	fmt.Println()
+	displayStackInfo(stackDetails)
+	fmt.Println()
+	displayServices(services, tasks)
+	fmt.Println()
+	displayTasks(services, tasks)
+
+	return nil
+}
+
+func displayServices(services []portainer.Service, tasks []portainer.Task) {
+	fmt.Println(headerStyle.Render("Services:"))
+	if len(services) == 0 {
+		fmt.Println("  No services found for this stack")
+		return
+	}
+
+	fmt.Printf("%-*s %-*s %-*s %-*s %-*s\n",
+		nameColumnWidth, headerStyle.Render("NAME"),
+		imageColumnWidth, headerStyle.Render("IMAGE"),
+		modeColumnWidth, headerStyle.Render("MODE"),
+		replicasColumnWidth, headerStyle.Render("REPLICAS"),
+		portsColumnWidth, headerStyle.Render("PORTS"))
+	fmt.Println(strings.Repeat("─", servicesTableWidth))
+	for _, service := range services {
+		fmt.Printf("%-*s %-*s %-*s %-*s %-*s\n",
+			nameColumnWidth, truncate(service.Spec.Name, nameColumnWidth),
+			imageColumnWidth, truncate(serviceImage(service), imageColumnWidth),
+			modeColumnWidth, serviceMode(service),
+			replicasColumnWidth, serviceReplicas(service, tasks),
+			portsColumnWidth, formatPublishedPorts(service.Endpoint.Ports))
+	}
+}
+
+func displayTasks(services []portainer.Service, tasks []portainer.Task) {
+	fmt.Println(headerStyle.Render("Tasks:"))
+
+	serviceNames := make(map[string]string, len(services))
+	for _, service := range services {
+		serviceNames[service.ID] = service.Spec.Name
+	}
+
+	// Replaced tasks are kept by Docker as history, only the current ones are shown
+	current := portainer.CurrentTasks(tasks)
+	if len(current) == 0 {
+		fmt.Println("  No tasks found for this stack")
+		return
+	}
+
+	sort.Slice(current, func(i, j int) bool {
+		a, b := current[i], current[j]
+		if serviceNames[a.ServiceID] != serviceNames[b.ServiceID] {
+			return serviceNames[a.ServiceID] < serviceNames[b.ServiceID]
+		}
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.NodeID < b.NodeID
+	})
+
+	fmt.Printf("%-*s %-*s %-*s %s\n",
+		nameColumnWidth, headerStyle.Render("NAME"),
+		desiredColumnWidth, headerStyle.Render("DESIRED"),
+		stateColumnWidth, headerStyle.Render("CURRENT STATE"),
+		headerStyle.Render("ERROR"))
+	fmt.Println(strings.Repeat("─", tasksTableWidth))
+	for _, task := range current {
+		serviceName, ok := serviceNames[task.ServiceID]
+		if !ok {
+			serviceName = task.ServiceID
+		}
+
+		state := task.Status.State
+		if age := formatAge(task.Status.Timestamp); age != "" {
+			state = fmt.Sprintf("%s %s", state, age)
+		}
+
+		fmt.Printf("%-*s %-*s %-*s %s\n",
+			nameColumnWidth, truncate(portainer.TaskName(serviceName, task), nameColumnWidth),
+			desiredColumnWidth, task.DesiredState,
+			stateColumnWidth, state,
+			task.Status.Err)
+	}
+}
+
+// serviceImage returns the image of a service without the digest Docker pins it to
+func serviceImage(service portainer.Service) string {
+	image, _, _ := strings.Cut(service.Spec.TaskTemplate.ContainerSpec.Image, "@")
+	return image
+}
+
+func serviceMode(service portainer.Service) string {
+	if service.Spec.Mode.Global != nil {
+		return "global"
+	}
+	return "replicated"
+}
+
+// serviceReplicas returns the running and desired task counts of a service, e.g. 2/3
+func serviceReplicas(service portainer.Service, tasks []portainer.Task) string {
+	running, scheduled := 0, 0
+	for _, task := range tasks {
+		if task.ServiceID != service.ID || task.DesiredState != portainer.TaskStateRunning {
+			continue
+		}
+		scheduled++
+		if task.Status.State == portainer.TaskStateRunning {
+			running++
+		}
+	}
+
+	// Global services run one task per eligible node, counted from their tasks
+	desired := scheduled
+	if replicated := service.Spec.Mode.Replicated; replicated != nil {
+		desired = int(replicated.Replicas)
+	}
+	return fmt.Sprintf("%d/%d", running, desired)
+}
+
+func formatPublishedPorts(ports []portainer.ServicePort) string {
+	var published []int
+	for _, port := range ports {
+		if port.PublishedPort > 0 {
+			published = append(published, port.PublishedPort)
+		}
+	}
+	if len(published) == 0 {
+		return "none"
+	}
+
+	sort.Ints(published)
+	parts := make([]string, len(published))
+	for i, port := range published {
+		parts[i] = fmt.Sprintf("%d", port)
+	}
+	return truncate(strings.Join(parts, ", "), portsColumnWidth)
+}
+
+// formatAge returns how long ago t was, e.g. "5m ago", empty when t is unknown
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds ago", int(age.Seconds()))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width-3] + "..."
+	}
+	return s
+}
@@ -0,0 +1,181 @@
+package restart
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+)
+
+var RestartCmd = &cobra.Command{
+	Use:   "restart [service...]",
+	Short: "Restart the stack containers",
+	Long: `Restart the containers of your deployed stack.
+Without arguments every container of the stack is restarted. Pass one or more
+service names to only restart the containers of those services.`,
+	RunE:         runRestart,
+	SilenceUsage: true,
+}
+
+func runRestart(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	fmt.Println(infoStyle.Render("Loading configuration..."))
+	if cfg.Target != "" {
+		fmt.Printf("  Target: %s\n", cfg.Target)
+	}
+	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
+	fmt.Printf("  Stack Name: %s\n", cfg.StackName)
+	fmt.Println()
+
+	// Create Portainer client
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Get containers for the stack
+	var containers []portainer.Container
+	err = spinner.RunWithSpinnerAndSuccess("Fetching containers...", "✓ Containers found", func() error {
+		var fetchErr error
+		containers, fetchErr = client.GetStackContainers(ctx, cfg.EnvironmentID, cfg.StackName)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to fetch containers"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	if len(containers) == 0 {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ No running containers found"))
+		fmt.Println()
+		fmt.Printf("Stack '%s' has no running containers in environment %d.\n", cfg.StackName, cfg.EnvironmentID)
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	targets, unknown := filterByService(containers, args)
+	if len(unknown) > 0 {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Unknown service"))
+		fmt.Println()
+		fmt.Printf("No container found for service(s): %s\n", strings.Join(unknown, ", "))
+		fmt.Printf("Available services: %s\n", strings.Join(serviceNames(containers), ", "))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	var failures []string
+	for _, container := range targets {
+		name := containerName(container)
+		err := spinner.RunWithSpinnerAndSuccess(fmt.Sprintf("Restarting %s...", name), fmt.Sprintf("✓ %s restarted", name), func() error {
+			return client.RestartContainer(ctx, cfg.EnvironmentID, container.ID)
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", name, errors.FormatError(err)))
+		}
+	}
+
+	if len(failures) > 0 {
+		fmt.Println()
+		fmt.Println(warningStyle.Render("Some containers could not be restarted:"))
+		for _, failure := range failures {
+			fmt.Printf("  • %s\n", failure)
+		}
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Restarted %d container(s) successfully!", len(targets))))
+
+	return nil
+}
+
+// filterByService returns the containers belonging to the given services, using the
+// Docker Compose service label. All containers are returned when no service is given.
+// Services without any container are returned as unknown.
+func filterByService(containers []portainer.Container, services []string) ([]portainer.Container, []string) {
+	if len(services) == 0 {
+		return containers, nil
+	}
+
+	wanted := make(map[string]bool, len(services))
+	for _, service := range services {
+		wanted[service] = false
+	}
+
+	var targets []portainer.Container
+	for _, container := range containers {
+		service := container.Labels[portainer.ComposeServiceLabel]
+		if _, ok := wanted[service]; ok {
+			wanted[service] = true
+			targets = append(targets, container)
+		}
+	}
+
+	var unknown []string
+	for _, service := range services {
+		if !wanted[service] {
+			unknown = append(unknown, service)
+		}
+	}
+
+	return targets, unknown
+}
+
+// serviceNames returns the sorted unique service names of the containers
+func serviceNames(containers []portainer.Container) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, container := range containers {
+		service := container.Labels[portainer.ComposeServiceLabel]
+		if service != "" && !seen[service] {
+			seen[service] = true
+			names = append(names, service)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containerName(container portainer.Container) string {
+	if len(container.Names) > 0 {
+		return strings.TrimPrefix(container.Names[0], "/")
+	}
+	return container.ID
+}
@@ -0,0 +1,111 @@
+package stop
+
+import (
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+)
+
+var StopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the stack without removing it",
+	Long: `Stop the stack in Portainer. Its containers are stopped but the stack
+definition is kept, so it can be started again later with 'pctl start'.`,
+	RunE:         runStop,
+	SilenceUsage: true,
+}
+
+func runStop(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	fmt.Println(infoStyle.Render("Loading configuration..."))
+	if cfg.Target != "" {
+		fmt.Printf("  Target: %s\n", cfg.Target)
+	}
+	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
+	fmt.Printf("  Stack Name: %s\n", cfg.StackName)
+	fmt.Println()
+
+	// Create Portainer client
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Check if stack exists
+	var existingStack *portainer.Stack
+	err = spinner.RunWithSpinnerAndSuccess("Checking if stack exists...", "✓ Stack found", func() error {
+		var fetchErr error
+		existingStack, fetchErr = cmdutil.FindStack(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	if existingStack == nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Stack not found"))
+		fmt.Println()
+		fmt.Printf("Stack '%s' not found in environment %d.\n", cfg.StackName, cfg.EnvironmentID)
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	if existingStack.Status == portainer.StackStatusInactive {
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Stack is already stopped, nothing to do."))
+		return nil
+	}
+
+	err = spinner.RunWithSpinnerAndSuccess("Stopping stack...", "✓ Stack stopped", func() error {
+		return client.StopStack(ctx, existingStack.ID, cfg.EnvironmentID)
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to stop stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack stopped successfully!"))
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Run 'pctl start' to start it again."))
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/deploy"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// deployEdge creates or updates the edge stack of an Edge environment. Portainer cannot reach
+// the Edge agents synchronously: they pull the new version of the stack on their next check-in.
+func deployEdge(ctx context.Context, client *portainer.Client, cfg *config.Config) error {
+	fmt.Printf("  Environment %d is an Edge environment, the stack is deployed as an edge stack\n", cfg.EnvironmentID)
+	fmt.Println()
+
+	if cfg.IsGitSource() {
+		return fmt.Errorf("git sources are not supported for edge stacks, use source: compose")
+	}
+
+	var (
+		groups        []portainer.EdgeGroup
+		existingStack *portainer.EdgeStack
+	)
+	err := spinner.RunWithSpinner("Checking edge groups and edge stack...", func() error {
+		var fetchErr error
+		groups, fetchErr = cmdutil.ResolveEdgeGroups(ctx, client, cfg)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		existingStack, fetchErr = client.GetEdgeStack(ctx, cfg.StackName)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing edge stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	fmt.Printf("  Edge Groups: %s\n", edgeGroupNames(groups))
+	if existingStack != nil {
+		fmt.Printf("  Found existing edge stack with ID: %d, it will be updated\n", existingStack.ID)
+	} else {
+		fmt.Println("  Edge stack not found, it will be created")
+	}
+	fmt.Println()
+
+	prepared, err := deploy.PrepareEdgeCompose(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Portainer replaces the stack variables, existing ones are only kept when asked
+	env := prepared.Env
+	if existingStack != nil {
+		env = deploy.MergeEnv(prepared.Env, existingStack.EnvVars, cfg.PreserveEnv)
+		if removed := removedEnvNames(existingStack.EnvVars, env); len(removed) > 0 {
+			fmt.Println(warningStyle.Render(fmt.Sprintf("Removing stack environment variables not defined by pctl: %s", strings.Join(removed, ", "))))
+			fmt.Println(warningStyle.Render("Use --preserve-env or preserve_env: true to keep them."))
+		}
+	}
+
+	if dryRun {
+		fmt.Println()
+		if existingStack == nil {
+			fmt.Println(infoStyle.Render(fmt.Sprintf("The edge stack would be created for the edge groups %s.", edgeGroupNames(groups))))
+		} else {
+			fmt.Println(infoStyle.Render(fmt.Sprintf("A new version of the edge stack would be deployed to the edge groups %s.", edgeGroupNames(groups))))
+		}
+		fmt.Println(infoStyle.Render("Dry run, nothing was deployed. Run 'pctl up' to apply these changes."))
+		return nil
+	}
+
+	stackID := 0
+	if existingStack == nil {
+		var stack *portainer.EdgeStack
+		err = spinner.RunWithSpinnerAndSuccess("Creating edge stack...", "✓ Edge stack created", func() error {
+			var createErr error
+			stack, createErr = client.CreateEdgeStack(ctx, cfg.StackName, prepared.ComposeContent, cmdutil.EdgeGroupIDs(groups), env)
+			return createErr
+		})
+		if err == nil {
+			stackID = stack.ID
+		}
+	} else {
+		stackID = existingStack.ID
+		err = spinner.RunWithSpinnerAndSuccess("Updating edge stack...", "✓ Edge stack updated", func() error {
+			return client.UpdateEdgeStack(ctx, existingStack.ID, prepared.ComposeContent, cmdutil.EdgeGroupIDs(groups), env)
+		})
+	}
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to deploy edge stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		displayEdgeIssues()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Edge stack submitted successfully!"))
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Edge Stack Details:"))
+	fmt.Printf("  ID: %d\n", stackID)
+	fmt.Printf("  Name: %s\n", cfg.StackName)
+	fmt.Printf("  Edge Groups: %s\n", edgeGroupNames(groups))
+	displayEnvNames(env)
+	fmt.Println()
+	fmt.Println(infoStyle.Render("The Edge agents deploy the stack on their next check-in."))
+	fmt.Println(infoStyle.Render("Run 'pctl ps --wait' to follow the deployment on every environment."))
+
+	return nil
+}
+
+func edgeGroupNames(groups []portainer.EdgeGroup) string {
+	names := make([]string, len(groups))
+	for i, group := range groups {
+		names[i] = group.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+func displayEdgeIssues() {
+	fmt.Println(infoStyle.Render("Common issues:"))
+	fmt.Println("  • Edge compute features are disabled in the Portainer settings")
+	fmt.Println("  • Edge stacks can only be managed by Portainer administrators")
+	fmt.Println("  • Invalid compose file - verify your docker-compose.yml")
+	fmt.Println()
+}
@@ -0,0 +1,169 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/deploy"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// deployGit creates or redeploys a stack Portainer deploys from a git repository. The local
+// compose file is not used, so no image is built: Portainer builds the services itself.
+func deployGit(ctx context.Context, client *portainer.Client, cfg *config.Config, existingStack *portainer.Stack) error {
+	git, err := cfg.GetGit()
+	if err != nil {
+		return fmt.Errorf("invalid git configuration: %w", err)
+	}
+
+	fmt.Println(infoStyle.Render("Git repository:"))
+	fmt.Printf("  URL: %s\n", git.URL)
+	fmt.Printf("  Reference: %s\n", git.Ref)
+	fmt.Printf("  Compose Path: %s\n", strings.Join(append([]string{git.ComposePath}, git.AdditionalFiles...), ", "))
+	fmt.Println()
+
+	env, err := deploy.LoadStackEnv(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load stack environment variables: %w", err)
+	}
+
+	repo := deploy.GitRepository(git)
+	autoUpdate := deploy.GitAutoUpdate(git, existingStack)
+
+	if dryRun {
+		if existingStack == nil {
+			fmt.Println(infoStyle.Render(fmt.Sprintf("The stack would be created from %s at %s.", git.URL, git.Ref)))
+		} else {
+			fmt.Println(infoStyle.Render(fmt.Sprintf("The stack would be redeployed from %s at %s.", git.URL, git.Ref)))
+		}
+		fmt.Println(infoStyle.Render("Dry run, nothing was deployed. Run 'pctl up' to apply these changes."))
+		return nil
+	}
+
+	if existingStack == nil {
+		return createGitStack(ctx, client, cfg, repo, autoUpdate, env)
+	}
+	return updateGitStack(ctx, client, cfg, existingStack, repo, autoUpdate, env)
+}
+
+func createGitStack(ctx context.Context, client *portainer.Client, cfg *config.Config, repo portainer.GitRepository, autoUpdate *portainer.AutoUpdateSettings, env []portainer.EnvVar) error {
+	var stack *portainer.Stack
+	err := spinner.RunWithSpinnerAndSuccess("Creating stack from git repository...", "✓ Stack created", func() error {
+		// Swarm environments need swarm stacks, created with the ID of the cluster
+		swarmID, createErr := client.GetSwarmID(ctx, cfg.EnvironmentID)
+		if createErr != nil {
+			return createErr
+		}
+		stack, createErr = client.CreateGitStack(ctx, cfg.StackName, swarmID, repo, autoUpdate, env, cfg.EnvironmentID)
+		return createErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to create stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		displayGitIssues()
+		return nil // Exit cleanly without error
+	}
+	cmdutil.RememberStack(cfg, stack.ID)
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack deployed successfully!"))
+	fmt.Println()
+
+	fmt.Println(infoStyle.Render("Stack Details:"))
+	fmt.Printf("  ID: %d\n", stack.ID)
+	fmt.Printf("  Name: %s\n", stack.Name)
+	fmt.Printf("  Environment ID: %d\n", stack.EnvironmentID)
+	displayEnvNames(env)
+	displayAutoUpdate(client, autoUpdate)
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Run 'pctl up' again to redeploy the stack from the repository."))
+
+	return nil
+}
+
+func updateGitStack(ctx context.Context, client *portainer.Client, cfg *config.Config, existingStack *portainer.Stack, repo portainer.GitRepository, autoUpdate *portainer.AutoUpdateSettings, env []portainer.EnvVar) error {
+	// Portainer replaces the stack variables, existing ones are only kept when asked
+	env = deploy.MergeEnv(env, existingStack.Env, cfg.PreserveEnv)
+	if removed := removedEnvNames(existingStack.Env, env); len(removed) > 0 {
+		fmt.Println(warningStyle.Render(fmt.Sprintf("Removing stack environment variables not defined by pctl: %s", strings.Join(removed, ", "))))
+		fmt.Println(warningStyle.Render("Use --preserve-env or preserve_env: true to keep them."))
+	}
+
+	// The reference and auto update settings are saved before redeploying, so that a
+	// change of branch is deployed right away
+	err := spinner.RunWithSpinner("Updating git settings...", func() error {
+		return client.UpdateGitStack(ctx, existingStack.ID, repo, autoUpdate, env, cfg.EnvironmentID)
+	})
+	if err == nil {
+		err = spinner.RunWithSpinner("Pulling repository and redeploying stack...", func() error {
+			return client.RedeployGitStack(ctx, existingStack.ID, repo, env, true, cfg.EnvironmentID)
+		})
+	}
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to update stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		displayGitIssues()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack redeployed successfully!"))
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Stack Details:"))
+	fmt.Printf("  ID: %d\n", existingStack.ID)
+	fmt.Printf("  Name: %s\n", existingStack.Name)
+	fmt.Printf("  Environment ID: %d\n", existingStack.EnvironmentID)
+	displayEnvNames(env)
+	displayAutoUpdate(client, autoUpdate)
+	fmt.Println()
+	fmt.Println(infoStyle.Render("The stack has been redeployed from the latest commit of the repository and images have been pulled."))
+
+	return nil
+}
+
+// displayAutoUpdate shows how Portainer keeps the stack up to date
+func displayAutoUpdate(client *portainer.Client, autoUpdate *portainer.AutoUpdateSettings) {
+	if autoUpdate == nil {
+		return
+	}
+	if autoUpdate.Interval != "" {
+		fmt.Printf("  Auto Update: every %s\n", autoUpdate.Interval)
+	}
+	if autoUpdate.Webhook != "" {
+		fmt.Printf("  Webhook: %s\n", client.WebhookURL(autoUpdate.Webhook))
+	}
+}
+
+// displaySourceMismatch explains that the stack has to be recreated to change its source
+func displaySourceMismatch(cfg *config.Config, existingStack *portainer.Stack) {
+	fmt.Println(errorStyle.Render("✗ Stack source mismatch"))
+	fmt.Println()
+	if existingStack.GitConfig != nil {
+		fmt.Printf("Stack '%s' is deployed from the git repository %s.\n", existingStack.Name, existingStack.GitConfig.URL)
+		fmt.Println("Set 'source: git' in pctl.yml to redeploy it, or remove it with 'pctl down' to deploy the local compose file instead.")
+	} else {
+		fmt.Printf("Stack '%s' was deployed from a local compose file, not from a git repository.\n", existingStack.Name)
+		fmt.Println("Remove it with 'pctl down' to deploy it from the git repository instead.")
+	}
+	fmt.Println()
+}
+
+func displayGitIssues() {
+	fmt.Println(infoStyle.Render("Common issues:"))
+	fmt.Println("  • Repository access - check git.username and git.password, or git.credential_id")
+	fmt.Println("  • Unknown reference - check that git.ref exists in the repository")
+	fmt.Println("  • Invalid compose path - check git.compose_path, relative to the repository root")
+	fmt.Println("  • Port conflicts - check if ports are already in use")
+	fmt.Println()
+}
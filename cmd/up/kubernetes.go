@@ -0,0 +1,165 @@
+package up
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/deploy"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// deployKubernetes creates or updates the stack of a Kubernetes environment. Portainer
+// converts the compose file to manifests, or deploys the configured manifests as-is.
+func deployKubernetes(ctx context.Context, client *portainer.Client, cfg *config.Config) error {
+	kubernetes, err := cfg.GetKubernetes()
+	if err != nil {
+		return fmt.Errorf("invalid kubernetes configuration: %w", err)
+	}
+	fmt.Printf("  Environment %d is a Kubernetes environment, the stack is deployed to the namespace %s\n", cfg.EnvironmentID, kubernetes.Namespace)
+	fmt.Println()
+
+	var existingStack *portainer.Stack
+	err = spinner.RunWithSpinner("Checking if stack exists...", func() error {
+		var fetchErr error
+		existingStack, fetchErr = cmdutil.FindStack(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	if existingStack != nil {
+		fmt.Printf("  Found existing stack with ID: %d, it will be updated\n", existingStack.ID)
+	} else {
+		fmt.Println("  Stack not found, it will be created")
+	}
+	fmt.Println()
+
+	composeFormat := len(kubernetes.Manifests) == 0
+	if existingStack != nil {
+		// Portainer keeps the namespace and the format of the stack it was created with
+		namespace := cmdutil.StackNamespace(cfg, existingStack.Namespace)
+		if namespace != kubernetes.Namespace || existingStack.ComposeFormat != composeFormat {
+			displayKubernetesMismatch(existingStack, namespace)
+			return nil
+		}
+	}
+
+	if len(cfg.Env) > 0 || len(cfg.EnvFile) > 0 {
+		fmt.Println(warningStyle.Render("Kubernetes stacks have no stack environment variables, env and env_file are ignored."))
+		fmt.Println()
+	}
+
+	prepared, err := deploy.PrepareKubernetes(cfg)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return showKubernetesPlan(ctx, client, existingStack, prepared, kubernetes.Namespace)
+	}
+
+	if existingStack == nil {
+		var stack *portainer.Stack
+		err = spinner.RunWithSpinnerAndSuccess("Creating Kubernetes stack...", "✓ Stack created", func() error {
+			var createErr error
+			stack, createErr = client.CreateKubernetesStack(ctx, cfg.StackName, kubernetes.Namespace, prepared.Content, prepared.ComposeFormat, cfg.EnvironmentID)
+			return createErr
+		})
+		if err == nil {
+			cmdutil.RememberStack(cfg, stack.ID)
+			existingStack = stack
+		}
+	} else {
+		err = spinner.RunWithSpinnerAndSuccess("Updating Kubernetes stack...", "✓ Stack updated", func() error {
+			return client.UpdateStack(ctx, existingStack.ID, prepared.Content, nil, false, cfg.EnvironmentID)
+		})
+	}
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to deploy Kubernetes stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		displayKubernetesIssues()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack deployed successfully!"))
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Stack Details:"))
+	fmt.Printf("  ID: %d\n", existingStack.ID)
+	fmt.Printf("  Name: %s\n", cfg.StackName)
+	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
+	fmt.Printf("  Namespace: %s\n", kubernetes.Namespace)
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Run 'pctl ps' to follow the pods of the stack."))
+
+	return nil
+}
+
+// showKubernetesPlan tells whether the deployment would change the manifests of the stack
+func showKubernetesPlan(ctx context.Context, client *portainer.Client, existingStack *portainer.Stack, prepared *deploy.PreparedManifests, namespace string) error {
+	fmt.Println()
+	if existingStack == nil {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("The stack would be created in the namespace %s.", namespace)))
+		fmt.Println(infoStyle.Render("Dry run, nothing was deployed. Run 'pctl up' to apply these changes."))
+		return nil
+	}
+
+	var current string
+	err := spinner.RunWithSpinner("Comparing with the deployed stack...", func() error {
+		var fetchErr error
+		current, fetchErr = client.GetStackFile(ctx, existingStack.ID)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to compare with the deployed stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	if current == prepared.Content {
+		fmt.Println(infoStyle.Render("No changes, the deployed stack is up to date."))
+	} else {
+		fmt.Println(infoStyle.Render(fmt.Sprintf("The stack would be updated in the namespace %s.", namespace)))
+	}
+	fmt.Println(infoStyle.Render("Dry run, nothing was deployed. Run 'pctl up' to apply these changes."))
+	return nil
+}
+
+func displayKubernetesMismatch(existingStack *portainer.Stack, namespace string) {
+	fmt.Println(errorStyle.Render("✗ Kubernetes stack mismatch"))
+	fmt.Println()
+	fmt.Printf("Stack '%s' is deployed in the namespace %s ", existingStack.Name, namespace)
+	if existingStack.ComposeFormat {
+		fmt.Println("from a compose file.")
+	} else {
+		fmt.Println("from Kubernetes manifests.")
+	}
+	fmt.Println("Portainer cannot move a stack to another namespace or switch between a compose file and manifests.")
+	fmt.Println("Update kubernetes.namespace and kubernetes.manifests in pctl.yml, or remove the stack with 'pctl down' first.")
+	fmt.Println()
+}
+
+func displayKubernetesIssues() {
+	fmt.Println(infoStyle.Render("Common issues:"))
+	fmt.Println("  • The namespace does not exist, or the user cannot deploy to it")
+	fmt.Println("  • Invalid manifests - check the files of kubernetes.manifests")
+	fmt.Println("  • Compose features without a Kubernetes equivalent, use kubernetes.manifests instead")
+	fmt.Println()
+}
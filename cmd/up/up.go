@@ -0,0 +1,330 @@
+package up
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/deploy"
+	"github.com/deviantony/pctl/internal/errors"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+)
+
+var UpCmd = &cobra.Command{
+	Use:     "up",
+	Aliases: []string{"deploy", "redeploy"},
+	Short:   "Deploy or update the stack in Portainer",
+	Long: `Deploy your Docker Compose stack to Portainer.
+If the stack does not exist yet it is created, otherwise it is updated with the
+latest compose file and images are pulled. Services with build directives are
+built before deploying. Running 'pctl up' repeatedly is safe.
+On Edge environments, the stack is deployed as an edge stack to the edge groups
+listed in edge.groups. With kind: kubernetes, the compose file (converted by
+Portainer) or the manifests listed in kubernetes.manifests are deployed to the
+kubernetes.namespace of a Kubernetes environment.`,
+	RunE:         runUp,
+	SilenceUsage: true,
+}
+
+var (
+	// forceRebuild toggles forcing build.ForceBuild (which includes no-cache behavior) during this run
+	forceRebuild bool
+	// preserveEnv keeps stack environment variables not defined by pctl, overriding preserve_env
+	preserveEnv bool
+	// dryRun prints the deployment plan without building or deploying
+	dryRun bool
+)
+
+func init() {
+	UpCmd.Flags().BoolVarP(&forceRebuild, "force-rebuild", "f", false, "Force rebuild images (sets force_build=true, which includes no-cache behavior)")
+	UpCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change in the deployed stack without building or deploying (same as 'pctl diff')")
+	UpCmd.Flags().BoolVar(&preserveEnv, "preserve-env", false, "Keep existing stack environment variables that are not defined by pctl (sets preserve_env=true)")
+}
+
+func runUp(cmd *cobra.Command, args []string) error {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Println(errorStyle.Render("✗ Configuration error"))
+		fmt.Println()
+		fmt.Printf("Error: %v\n", err)
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	// Validate configuration
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	fmt.Println(infoStyle.Render("Loading configuration..."))
+	if cfg.Target != "" {
+		fmt.Printf("  Target: %s\n", cfg.Target)
+	}
+	fmt.Printf("  Environment ID: %d\n", cfg.EnvironmentID)
+	fmt.Printf("  Stack Name: %s\n", cfg.StackName)
+	if cfg.IsGitSource() {
+		fmt.Printf("  Source: git repository\n")
+	} else if cfg.IsKubernetes() && cfg.Kubernetes != nil && len(cfg.Kubernetes.Manifests) > 0 {
+		fmt.Printf("  Manifests: %s\n", strings.Join(cfg.Kubernetes.Manifests, ", "))
+	} else {
+		fmt.Printf("  Compose File: %s\n", cfg.ComposeFile)
+	}
+	fmt.Println()
+
+	// Create Portainer client
+	ctx := cmd.Context()
+	client, err := cmdutil.NewClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Flags().Changed("preserve-env") {
+		cfg.PreserveEnv = preserveEnv
+	}
+
+	// Edge agents cannot be reached through the Docker proxy, their stacks are edge stacks,
+	// and Kubernetes environments are deployed to through the Kubernetes proxy
+	var environment *portainer.Environment
+	err = spinner.RunWithSpinner("Checking environment...", func() error {
+		var fetchErr error
+		environment, fetchErr = client.GetEnvironment(ctx, cfg.EnvironmentID)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check the environment"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+	if err := cmdutil.CheckEnvironmentKind(cfg, environment); err != nil {
+		return err
+	}
+	if environment.IsEdge() {
+		return deployEdge(ctx, client, cfg)
+	}
+	if cfg.IsKubernetes() {
+		return deployKubernetes(ctx, client, cfg)
+	}
+
+	// Check if stack exists to decide between create and update
+	var existingStack *portainer.Stack
+	err = spinner.RunWithSpinner("Checking if stack exists...", func() error {
+		var fetchErr error
+		existingStack, fetchErr = cmdutil.FindStack(ctx, client, cfg)
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to check for existing stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without showing usage
+	}
+
+	if existingStack != nil {
+		fmt.Printf("  Found existing stack with ID: %d, it will be updated\n", existingStack.ID)
+	} else {
+		fmt.Println("  Stack not found, it will be created")
+	}
+	fmt.Println()
+
+	// Portainer cannot switch a stack between a compose file and a git repository
+	if existingStack != nil && (existingStack.GitConfig != nil) != cfg.IsGitSource() {
+		displaySourceMismatch(cfg, existingStack)
+		return nil
+	}
+
+	if cfg.IsGitSource() {
+		return deployGit(ctx, client, cfg, existingStack)
+	}
+
+	// Build images and prepare the compose file
+	prepared, err := deploy.PrepareCompose(ctx, client, cfg, deploy.Options{ForceRebuild: forceRebuild, DryRun: dryRun})
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return showPlan(ctx, client, cfg, existingStack, prepared)
+	}
+
+	if existingStack == nil {
+		return createStack(ctx, client, cfg, prepared)
+	}
+	return updateStack(ctx, client, cfg, existingStack, prepared)
+}
+
+func createStack(ctx context.Context, client *portainer.Client, cfg *config.Config, prepared *deploy.PreparedStack) error {
+	var stack *portainer.Stack
+	err := spinner.RunWithSpinnerAndSuccess("Creating new stack...", "✓ Stack created", func() error {
+		// Swarm environments need swarm stacks, created with the ID of the cluster
+		swarmID, fetchErr := client.GetSwarmID(ctx, cfg.EnvironmentID)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		if swarmID != "" {
+			stack, fetchErr = client.CreateSwarmStack(ctx, cfg.StackName, swarmID, prepared.ComposeContent, prepared.Env, cfg.EnvironmentID)
+		} else {
+			stack, fetchErr = client.CreateStack(ctx, cfg.StackName, prepared.ComposeContent, prepared.Env, cfg.EnvironmentID)
+		}
+		return fetchErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to create stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		displayCommonIssues()
+		return nil // Exit cleanly without error
+	}
+	cmdutil.RememberStack(cfg, stack.ID)
+
+	// Display success message
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack deployed successfully!"))
+	fmt.Println()
+
+	fmt.Println(infoStyle.Render("Stack Details:"))
+	fmt.Printf("  ID: %d\n", stack.ID)
+	fmt.Printf("  Name: %s\n", stack.Name)
+	fmt.Printf("  Environment ID: %d\n", stack.EnvironmentID)
+	fmt.Printf("  Status: %d\n", stack.Status)
+	if stack.IsSwarm() {
+		fmt.Printf("  Swarm ID: %s\n", stack.SwarmID)
+	}
+	displayEnvNames(prepared.Env)
+	fmt.Println()
+	if stack.IsSwarm() && prepared.HasBuild {
+		displaySwarmBuildWarning()
+	}
+	fmt.Println(infoStyle.Render("Run 'pctl up' again to update this stack."))
+
+	return nil
+}
+
+func updateStack(ctx context.Context, client *portainer.Client, cfg *config.Config, existingStack *portainer.Stack, prepared *deploy.PreparedStack) error {
+	pullImages := !prepared.HasBuild // Don't pull images if we just built them
+
+	// Portainer replaces the stack variables, existing ones are only kept when asked
+	env := deploy.MergeEnv(prepared.Env, existingStack.Env, cfg.PreserveEnv)
+	if removed := removedEnvNames(existingStack.Env, env); len(removed) > 0 {
+		fmt.Println(warningStyle.Render(fmt.Sprintf("Removing stack environment variables not defined by pctl: %s", strings.Join(removed, ", "))))
+		fmt.Println(warningStyle.Render("Use --preserve-env or preserve_env: true to keep them."))
+	}
+
+	err := spinner.RunWithSpinner("Updating stack...", func() error {
+		return client.UpdateStack(ctx, existingStack.ID, prepared.ComposeContent, env, pullImages, cfg.EnvironmentID)
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to update stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		displayCommonIssues()
+		return nil // Exit cleanly without error
+	}
+
+	// Display success message
+	fmt.Println()
+	fmt.Println(successStyle.Render("✓ Stack redeployed successfully!"))
+	fmt.Println()
+	fmt.Println(infoStyle.Render("Stack Details:"))
+	fmt.Printf("  ID: %d\n", existingStack.ID)
+	fmt.Printf("  Name: %s\n", existingStack.Name)
+	fmt.Printf("  Environment ID: %d\n", existingStack.EnvironmentID)
+	displayEnvNames(env)
+	fmt.Println()
+	if existingStack.IsSwarm() && prepared.HasBuild {
+		displaySwarmBuildWarning()
+	}
+	if pullImages {
+		fmt.Println(infoStyle.Render("The stack has been updated with the latest compose file and images have been pulled."))
+	} else {
+		fmt.Println(infoStyle.Render("The stack has been updated with the latest compose file and freshly built images."))
+	}
+
+	return nil
+}
+
+// showPlan prints the changes the deployment would make without applying them
+func showPlan(ctx context.Context, client *portainer.Client, cfg *config.Config, existingStack *portainer.Stack, prepared *deploy.PreparedStack) error {
+	fmt.Println()
+
+	var plan *deploy.Plan
+	err := spinner.RunWithSpinner("Comparing with the deployed stack...", func() error {
+		var planErr error
+		plan, planErr = deploy.BuildPlan(ctx, client, cfg, existingStack, prepared)
+		return planErr
+	})
+	if err != nil {
+		fmt.Println()
+		fmt.Println(errorStyle.Render("✗ Failed to compare with the deployed stack"))
+		fmt.Println()
+		fmt.Println(errors.FormatError(err))
+		fmt.Println()
+		return nil // Exit cleanly without error
+	}
+
+	fmt.Println()
+	plan.Print()
+	fmt.Println(infoStyle.Render("Dry run, nothing was deployed. Run 'pctl up' to apply these changes."))
+	return nil
+}
+
+// removedEnvNames returns the names of the existing variables missing from env
+func removedEnvNames(existing, env []portainer.EnvVar) []string {
+	kept := make(map[string]bool, len(env))
+	for _, v := range env {
+		kept[v.Name] = true
+	}
+
+	var removed []string
+	for _, v := range existing {
+		if !kept[v.Name] {
+			removed = append(removed, v.Name)
+		}
+	}
+	return removed
+}
+
+// displayEnvNames shows the names of the stack variables, values may be secrets
+func displayEnvNames(env []portainer.EnvVar) {
+	if len(env) > 0 {
+		fmt.Printf("  Environment Variables: %s\n", strings.Join(deploy.EnvNames(env), ", "))
+	}
+}
+
+// displaySwarmBuildWarning reminds that images built by pctl only exist on the node they
+// were built on, while swarm may schedule the service tasks on any node of the cluster
+func displaySwarmBuildWarning() {
+	fmt.Println(warningStyle.Render("The images built by pctl only exist on the swarm node Portainer built them on."))
+	fmt.Println(warningStyle.Render("Constrain these services to that node, or push the images to a registry the other nodes can pull from."))
+	fmt.Println()
+}
+
+func displayCommonIssues() {
+	fmt.Println(infoStyle.Render("Common issues:"))
+	fmt.Println("  • Port conflicts - check if ports are already in use")
+	fmt.Println("  • Invalid compose file - verify your docker-compose.yml")
+	fmt.Println("  • Network issues - check Portainer connectivity")
+	fmt.Println()
+}
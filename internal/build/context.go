@@ -2,35 +2,41 @@ package build
 
 import (
 	"archive/tar"
-	"bufio"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
-	"strings"
 )
 
+// largestEntriesReported is the number of largest files and directories listed when a build
+// context is above the size limits
+const largestEntriesReported = 10
+
 // ContextTarStreamer handles creating tar streams of build contexts
 type ContextTarStreamer struct {
-	WarnThresholdMB int
+	WarnThresholdMB int         // warn about contexts larger than this, 0 disables the warning
+	MaxContextMB    int         // refuse to send contexts larger than this, 0 disables the limit
+	Logger          BuildLogger // receives the size warnings, nil discards them
 }
 
 // NewContextTarStreamer creates a new context tar streamer
-func NewContextTarStreamer(warnThresholdMB int) *ContextTarStreamer {
+func NewContextTarStreamer(warnThresholdMB, maxContextMB int, logger BuildLogger) *ContextTarStreamer {
 	return &ContextTarStreamer{
 		WarnThresholdMB: warnThresholdMB,
+		MaxContextMB:    maxContextMB,
+		Logger:          logger,
 	}
 }
 
-// CreateTarStream creates a tar stream of the build context
-func (cts *ContextTarStreamer) CreateTarStream(contextPath string) (io.ReadCloser, error) {
+// CreateTarStream creates a tar stream of the build context, without the files ignored by
+// its .dockerignore file. dockerfile is relative to the context, empty for the default Dockerfile.
+func (cts *ContextTarStreamer) CreateTarStream(contextPath, dockerfile string) (io.ReadCloser, error) {
 	// Validate context path
 	if !isDirectory(contextPath) {
 		return nil, fmt.Errorf("context path is not a directory: %s", contextPath)
 	}
 
 	// Load .dockerignore patterns
-	ignorePatterns, err := cts.loadDockerignore(contextPath)
+	filter, err := NewContextFilter(contextPath, dockerfile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load .dockerignore: %w", err)
 	}
@@ -45,7 +51,7 @@ func (cts *ContextTarStreamer) CreateTarStream(contextPath string) (io.ReadClose
 		tw := tar.NewWriter(writer)
 		defer tw.Close()
 
-		err := cts.writeContextToTar(contextPath, ignorePatterns, tw)
+		err := cts.writeContextToTar(contextPath, filter, tw)
 		if err != nil {
 			writer.CloseWithError(err)
 			return
@@ -55,73 +61,11 @@ func (cts *ContextTarStreamer) CreateTarStream(contextPath string) (io.ReadClose
 	return reader, nil
 }
 
-// loadDockerignore loads .dockerignore patterns from the context directory
-func (cts *ContextTarStreamer) loadDockerignore(contextPath string) ([]string, error) {
-	dockerignorePath := filepath.Join(contextPath, ".dockerignore")
-
-	// Check if .dockerignore exists
-	if _, err := os.Stat(dockerignorePath); os.IsNotExist(err) {
-		return []string{}, nil // No .dockerignore file
-	}
-
-	file, err := os.Open(dockerignorePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open .dockerignore: %w", err)
-	}
-	defer file.Close()
-
-	var patterns []string
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		patterns = append(patterns, line)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read .dockerignore: %w", err)
-	}
-
-	return patterns, nil
-}
-
 // writeContextToTar writes the build context to a tar writer
-func (cts *ContextTarStreamer) writeContextToTar(contextPath string, ignorePatterns []string, tw *tar.Writer) error {
+func (cts *ContextTarStreamer) writeContextToTar(contextPath string, filter *ContextFilter, tw *tar.Writer) error {
 	var totalSize int64
 
-	err := filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip the root directory itself
-		if path == contextPath {
-			return nil
-		}
-
-		// Get relative path from context
-		relPath, err := filepath.Rel(contextPath, path)
-		if err != nil {
-			return err
-		}
-
-		// Normalize path separators for cross-platform compatibility
-		relPath = filepath.ToSlash(relPath)
-
-		// Check if path should be ignored
-		if cts.shouldIgnore(relPath, ignorePatterns) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
+	return filter.Walk(contextPath, func(path, relPath string, info os.FileInfo) error {
 		// Create tar header
 		header, err := tar.FileInfoHeader(info, "")
 		if err != nil {
@@ -152,130 +96,73 @@ func (cts *ContextTarStreamer) writeContextToTar(contextPath string, ignorePatte
 
 			totalSize += written
 
-			// Check size threshold
-			if cts.WarnThresholdMB > 0 && totalSize > int64(cts.WarnThresholdMB*1024*1024) {
-				// Note: In a real implementation, you might want to emit a warning here
-				// For now, we'll continue but this could be enhanced to emit warnings
+			// Files may have grown since the context was validated, abort the upload
+			if cts.MaxContextMB > 0 && totalSize > megabytes(cts.MaxContextMB) {
+				return fmt.Errorf("build context exceeds max_context_mb (%d MB)", cts.MaxContextMB)
 			}
 		}
 
 		return nil
 	})
-
-	return err
-}
-
-// shouldIgnore checks if a path should be ignored based on .dockerignore patterns
-func (cts *ContextTarStreamer) shouldIgnore(relPath string, patterns []string) bool {
-	for _, pattern := range patterns {
-		if cts.matchesPattern(relPath, pattern) {
-			return true
-		}
-	}
-	return false
-}
-
-// matchesPattern checks if a path matches a .dockerignore pattern
-func (cts *ContextTarStreamer) matchesPattern(relPath, pattern string) bool {
-	// Handle directory patterns (ending with /)
-	if strings.HasSuffix(pattern, "/") {
-		dirPattern := strings.TrimSuffix(pattern, "/")
-		return strings.HasPrefix(relPath, dirPattern+"/") || relPath == dirPattern
-	}
-
-	// Handle wildcard patterns
-	if strings.Contains(pattern, "*") {
-		matched, _ := filepath.Match(pattern, relPath)
-		return matched
-	}
-
-	// Handle exact matches
-	if relPath == pattern {
-		return true
-	}
-
-	// Handle prefix matches
-	if strings.HasPrefix(relPath, pattern+"/") {
-		return true
-	}
-
-	return false
 }
 
 // GetContextSize estimates the size of the build context
-func (cts *ContextTarStreamer) GetContextSize(contextPath string) (int64, error) {
-	ignorePatterns, err := cts.loadDockerignore(contextPath)
+func (cts *ContextTarStreamer) GetContextSize(contextPath, dockerfile string) (int64, error) {
+	report, err := cts.InspectContext(contextPath, dockerfile)
 	if err != nil {
 		return 0, err
 	}
-
-	var totalSize int64
-
-	err = filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if path == contextPath {
-			return nil
-		}
-
-		relPath, err := filepath.Rel(contextPath, path)
-		if err != nil {
-			return err
-		}
-
-		relPath = filepath.ToSlash(relPath)
-
-		if cts.shouldIgnore(relPath, ignorePatterns) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if info.Mode().IsRegular() {
-			totalSize += info.Size()
-		}
-
-		return nil
-	})
-
-	return totalSize, err
+	return report.TotalSize, nil
 }
 
-// ValidateContext validates that a build context is valid
-func (cts *ContextTarStreamer) ValidateContext(contextPath string) error {
+// ValidateContext validates that a build context is valid and checks its size. Contexts above
+// warn_threshold_mb are reported to the logger with their largest entries, contexts above
+// max_context_mb are rejected.
+func (cts *ContextTarStreamer) ValidateContext(contextPath, dockerfile string) error {
 	// Check if context exists and is a directory
 	if !isDirectory(contextPath) {
 		return fmt.Errorf("context path is not a directory: %s", contextPath)
 	}
 
-	// Check if .dockerignore is readable (if it exists)
-	dockerignorePath := filepath.Join(contextPath, ".dockerignore")
-	if _, err := os.Stat(dockerignorePath); err == nil {
-		file, err := os.Open(dockerignorePath)
-		if err != nil {
-			return fmt.Errorf("cannot read .dockerignore: %w", err)
-		}
-		file.Close()
-	}
-
-	// Check context size
-	size, err := cts.GetContextSize(contextPath)
+	// Check context size, which also checks that the ignore file is readable and valid
+	report, err := cts.InspectContext(contextPath, dockerfile)
 	if err != nil {
 		return fmt.Errorf("failed to calculate context size: %w", err)
 	}
 
-	// Warn if context is too large
-	if cts.WarnThresholdMB > 0 && size > int64(cts.WarnThresholdMB*1024*1024) {
-		// In a real implementation, this would emit a warning
-		// For now, we'll just continue
+	overLimit := cts.MaxContextMB > 0 && report.TotalSize > megabytes(cts.MaxContextMB)
+	overThreshold := cts.WarnThresholdMB > 0 && report.TotalSize > megabytes(cts.WarnThresholdMB)
+
+	if overLimit {
+		cts.warnLargestEntries(report, fmt.Sprintf("Build context %s is %s, above max_context_mb (%d MB)",
+			contextPath, FormatSize(report.TotalSize), cts.MaxContextMB))
+		return fmt.Errorf("build context %s is %s, above max_context_mb (%d MB): exclude files with .dockerignore",
+			contextPath, FormatSize(report.TotalSize), cts.MaxContextMB)
+	}
+	if overThreshold {
+		cts.warnLargestEntries(report, fmt.Sprintf("Build context %s is %s, above warn_threshold_mb (%d MB)",
+			contextPath, FormatSize(report.TotalSize), cts.WarnThresholdMB))
 	}
 
 	return nil
 }
 
+// warnLargestEntries logs a size warning with the largest entries of the context
+func (cts *ContextTarStreamer) warnLargestEntries(report *ContextReport, message string) {
+	if cts.Logger == nil {
+		return
+	}
+
+	cts.Logger.LogWarn(message + ", largest entries:")
+	for _, entry := range report.Largest(largestEntriesReported) {
+		path := entry.Path
+		if entry.IsDir {
+			path += "/"
+		}
+		cts.Logger.LogWarn(fmt.Sprintf("  %10s  %s", FormatSize(entry.Size), path))
+	}
+}
+
 // Helper function to check if a path is a directory
 func isDirectory(path string) bool {
 	info, err := os.Stat(path)
@@ -35,8 +35,8 @@ func TestContextTarStreamer_CreateTarStream(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create tar stream
-	streamer := NewContextTarStreamer(0)
-	reader, err := streamer.CreateTarStream(tempDir)
+	streamer := NewContextTarStreamer(0, 0, nil)
+	reader, err := streamer.CreateTarStream(tempDir, "")
 	require.NoError(t, err)
 	defer reader.Close()
 
@@ -60,230 +60,6 @@ func TestContextTarStreamer_CreateTarStream(t *testing.T) {
 	assert.Contains(t, foundFiles, "subdir/file3.txt")
 }
 
-func TestContextTarStreamer_loadDockerignore(t *testing.T) {
-	tempDir := t.TempDir()
-
-	// Create .dockerignore file
-	dockerignorePath := filepath.Join(tempDir, ".dockerignore")
-	dockerignoreContent := `# This is a comment
-*.log
-temp/
-.git/
-node_modules/
-`
-	err := os.WriteFile(dockerignorePath, []byte(dockerignoreContent), 0644)
-	require.NoError(t, err)
-
-	streamer := NewContextTarStreamer(0)
-	patterns, err := streamer.loadDockerignore(tempDir)
-	require.NoError(t, err)
-
-	// Should load patterns, skipping comments and empty lines
-	expected := []string{"*.log", "temp/", ".git/", "node_modules/"}
-	assert.Equal(t, expected, patterns)
-}
-
-func TestContextTarStreamer_loadDockerignore_NotFound(t *testing.T) {
-	tempDir := t.TempDir()
-
-	streamer := NewContextTarStreamer(0)
-	patterns, err := streamer.loadDockerignore(tempDir)
-	require.NoError(t, err)
-
-	// Should return empty slice when .dockerignore doesn't exist
-	assert.Empty(t, patterns)
-}
-
-func TestContextTarStreamer_shouldIgnore(t *testing.T) {
-	streamer := NewContextTarStreamer(0)
-
-	tests := []struct {
-		name     string
-		relPath  string
-		patterns []string
-		expected bool
-	}{
-		{
-			name:     "exact match",
-			relPath:  "file.txt",
-			patterns: []string{"file.txt"},
-			expected: true,
-		},
-		{
-			name:     "prefix match",
-			relPath:  "temp/file.txt",
-			patterns: []string{"temp"},
-			expected: true,
-		},
-		{
-			name:     "wildcard match",
-			relPath:  "app.log",
-			patterns: []string{"*.log"},
-			expected: true,
-		},
-		{
-			name:     "directory pattern",
-			relPath:  "node_modules/package",
-			patterns: []string{"node_modules/"},
-			expected: true,
-		},
-		{
-			name:     "no match",
-			relPath:  "src/main.go",
-			patterns: []string{"*.log", "temp/"},
-			expected: false,
-		},
-		{
-			name:     "multiple patterns",
-			relPath:  "app.log",
-			patterns: []string{"*.log", "temp/", "*.tmp"},
-			expected: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := streamer.shouldIgnore(tt.relPath, tt.patterns)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestContextTarStreamer_shouldIgnore_WildcardPattern(t *testing.T) {
-	streamer := NewContextTarStreamer(0)
-
-	tests := []struct {
-		name     string
-		relPath  string
-		pattern  string
-		expected bool
-	}{
-		{
-			name:     "simple wildcard",
-			relPath:  "app.log",
-			pattern:  "*.log",
-			expected: true,
-		},
-		{
-			name:     "wildcard in middle",
-			relPath:  "src/main.go",
-			pattern:  "src/*.go",
-			expected: true,
-		},
-		{
-			name:     "multiple wildcards",
-			relPath:  "src/test/main_test.go",
-			pattern:  "src/*/main_*.go",
-			expected: true,
-		},
-		{
-			name:     "no wildcard match",
-			relPath:  "src/main.go",
-			pattern:  "*.log",
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := streamer.matchesPattern(tt.relPath, tt.pattern)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestContextTarStreamer_shouldIgnore_DirectoryPattern(t *testing.T) {
-	streamer := NewContextTarStreamer(0)
-
-	tests := []struct {
-		name     string
-		relPath  string
-		pattern  string
-		expected bool
-	}{
-		{
-			name:     "directory with trailing slash",
-			relPath:  "node_modules/package",
-			pattern:  "node_modules/",
-			expected: true,
-		},
-		{
-			name:     "exact directory match",
-			relPath:  "temp",
-			pattern:  "temp/",
-			expected: true,
-		},
-		{
-			name:     "subdirectory match",
-			relPath:  "temp/subdir/file.txt",
-			pattern:  "temp/",
-			expected: true,
-		},
-		{
-			name:     "no directory match",
-			relPath:  "src/main.go",
-			pattern:  "temp/",
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := streamer.matchesPattern(tt.relPath, tt.pattern)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestContextTarStreamer_matchesPattern(t *testing.T) {
-	streamer := NewContextTarStreamer(0)
-
-	tests := []struct {
-		name     string
-		relPath  string
-		pattern  string
-		expected bool
-	}{
-		{
-			name:     "exact match",
-			relPath:  "file.txt",
-			pattern:  "file.txt",
-			expected: true,
-		},
-		{
-			name:     "prefix match",
-			relPath:  "temp/file.txt",
-			pattern:  "temp",
-			expected: true,
-		},
-		{
-			name:     "wildcard match",
-			relPath:  "app.log",
-			pattern:  "*.log",
-			expected: true,
-		},
-		{
-			name:     "directory pattern",
-			relPath:  "node_modules/package",
-			pattern:  "node_modules/",
-			expected: true,
-		},
-		{
-			name:     "no match",
-			relPath:  "src/main.go",
-			pattern:  "*.log",
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := streamer.matchesPattern(tt.relPath, tt.pattern)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
 func TestContextTarStreamer_GetContextSize(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -305,8 +81,8 @@ func TestContextTarStreamer_GetContextSize(t *testing.T) {
 	err = os.WriteFile(file3, []byte("content3"), 0644)
 	require.NoError(t, err)
 
-	streamer := NewContextTarStreamer(0)
-	size, err := streamer.GetContextSize(tempDir)
+	streamer := NewContextTarStreamer(0, 0, nil)
+	size, err := streamer.GetContextSize(tempDir, "")
 	require.NoError(t, err)
 
 	// Should include all files (8 bytes each)
@@ -332,8 +108,8 @@ func TestContextTarStreamer_GetContextSize_WithIgnore(t *testing.T) {
 	err = os.WriteFile(dockerignorePath, []byte(dockerignoreContent), 0644)
 	require.NoError(t, err)
 
-	streamer := NewContextTarStreamer(0)
-	size, err := streamer.GetContextSize(tempDir)
+	streamer := NewContextTarStreamer(0, 0, nil)
+	size, err := streamer.GetContextSize(tempDir, "")
 	require.NoError(t, err)
 
 	// Should only include file1.txt (8 bytes), not file2.log
@@ -350,8 +126,8 @@ func TestContextTarStreamer_ValidateContext(t *testing.T) {
 	err := os.WriteFile(file1, []byte("content1"), 0644)
 	require.NoError(t, err)
 
-	streamer := NewContextTarStreamer(0)
-	err = streamer.ValidateContext(tempDir)
+	streamer := NewContextTarStreamer(0, 0, nil)
+	err = streamer.ValidateContext(tempDir, "")
 	assert.NoError(t, err)
 }
 
@@ -362,15 +138,18 @@ func TestContextTarStreamer_ValidateContext_NotDirectory(t *testing.T) {
 	defer os.Remove(tempFile.Name())
 	tempFile.Close()
 
-	streamer := NewContextTarStreamer(0)
-	err = streamer.ValidateContext(tempFile.Name())
+	streamer := NewContextTarStreamer(0, 0, nil)
+	err = streamer.ValidateContext(tempFile.Name(), "")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context path is not a directory")
 }
 
 func TestNewContextTarStreamer(t *testing.T) {
-	streamer := NewContextTarStreamer(50)
+	logger := &MockBuildLogger{}
+	streamer := NewContextTarStreamer(50, 500, logger)
 	assert.Equal(t, 50, streamer.WarnThresholdMB)
+	assert.Equal(t, 500, streamer.MaxContextMB)
+	assert.Equal(t, logger, streamer.Logger)
 }
 
 func TestIsDirectory(t *testing.T) {
@@ -432,8 +211,8 @@ func TestContextTarStreamer_CreateTarStream_WithDockerignore(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create tar stream
-	streamer := NewContextTarStreamer(0)
-	reader, err := streamer.CreateTarStream(tempDir)
+	streamer := NewContextTarStreamer(0, 0, nil)
+	reader, err := streamer.CreateTarStream(tempDir, "")
 	require.NoError(t, err)
 	defer reader.Close()
 
@@ -470,8 +249,8 @@ func TestContextTarStreamer_ValidateContext_WithDockerignore(t *testing.T) {
 	err = os.WriteFile(dockerignorePath, []byte(dockerignoreContent), 0644)
 	require.NoError(t, err)
 
-	streamer := NewContextTarStreamer(0)
-	err = streamer.ValidateContext(tempDir)
+	streamer := NewContextTarStreamer(0, 0, nil)
+	err = streamer.ValidateContext(tempDir, "")
 	assert.NoError(t, err)
 }
 
@@ -484,9 +263,60 @@ func TestContextTarStreamer_ValidateContext_WithLargeContext(t *testing.T) {
 	err := os.WriteFile(largeFile, []byte(largeContent), 0644)
 	require.NoError(t, err)
 
-	// Set a small threshold (0.5MB)
-	streamer := NewContextTarStreamer(1) // 1MB threshold
-	err = streamer.ValidateContext(tempDir)
-	// Should not error, but might emit warning in real implementation
+	// The context is not above the threshold, there is no warning
+	logger := &MockBuildLogger{}
+	streamer := NewContextTarStreamer(1, 0, logger) // 1MB threshold
+	err = streamer.ValidateContext(tempDir, "")
 	assert.NoError(t, err)
+	assert.Empty(t, logger.warnLogs)
+}
+
+func TestContextTarStreamer_ValidateContext_WarnThreshold(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "assets", "video"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "assets", "video", "intro.mp4"), []byte(strings.Repeat("x", 1024*1024)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "data.bin"), []byte(strings.Repeat("x", 512*1024)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "Dockerfile"), []byte("FROM alpine"), 0644))
+
+	logger := &MockBuildLogger{}
+	streamer := NewContextTarStreamer(1, 0, logger)
+	err := streamer.ValidateContext(tempDir, "")
+	require.NoError(t, err)
+
+	require.Len(t, logger.warnLogs, 4)
+	assert.Contains(t, logger.warnLogs[0], "is 1.5 MB, above warn_threshold_mb (1 MB), largest entries:")
+	assert.Contains(t, logger.warnLogs[1], "1.0 MB  assets/")
+	assert.Contains(t, logger.warnLogs[2], "512.0 KB  data.bin")
+	assert.Contains(t, logger.warnLogs[3], "11 B  Dockerfile")
+}
+
+func TestContextTarStreamer_ValidateContext_MaxContext(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "data.bin"), []byte(strings.Repeat("x", 2*1024*1024)), 0644))
+
+	logger := &MockBuildLogger{}
+	streamer := NewContextTarStreamer(0, 1, logger)
+	err := streamer.ValidateContext(tempDir, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is 2.0 MB, above max_context_mb (1 MB)")
+	require.Len(t, logger.warnLogs, 2)
+	assert.Contains(t, logger.warnLogs[1], "data.bin")
+
+	// Ignored files do not count
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".dockerignore"), []byte("*.bin\n"), 0644))
+	assert.NoError(t, streamer.ValidateContext(tempDir, ""))
+}
+
+func TestContextTarStreamer_CreateTarStream_MaxContext(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "data.bin"), []byte(strings.Repeat("x", 2*1024*1024)), 0644))
+
+	streamer := NewContextTarStreamer(0, 1, nil)
+	reader, err := streamer.CreateTarStream(tempDir, "")
+	require.NoError(t, err)
+	defer reader.Close()
+
+	_, err = io.Copy(io.Discard, reader)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "build context exceeds max_context_mb (1 MB)")
 }
@@ -0,0 +1,217 @@
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/compose"
+)
+
+// serviceDependencies returns, for every service, the services whose image its Dockerfile is
+// built FROM, sorted by name. A service provides an image through the image name set next to
+// its build directive in the compose file, only the services being built are considered.
+func serviceDependencies(services []compose.ServiceBuildInfo) (map[string][]string, error) {
+	providers := make(map[string]string)
+	for _, service := range services {
+		if service.Image != "" {
+			providers[normalizeImageRef(service.Image)] = service.ServiceName
+		}
+	}
+
+	dependencies := make(map[string][]string)
+	if len(providers) == 0 {
+		return dependencies, nil
+	}
+
+	for _, service := range services {
+		bases, err := dockerfileBaseImages(filepath.Join(service.ContextPath, service.Build.Dockerfile), service.Build.Args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the Dockerfile of %s: %w", service.ServiceName, err)
+		}
+
+		seen := make(map[string]bool)
+		for _, base := range bases {
+			provider, ok := providers[normalizeImageRef(base)]
+			if !ok || provider == service.ServiceName || seen[provider] {
+				continue
+			}
+			seen[provider] = true
+			dependencies[service.ServiceName] = append(dependencies[service.ServiceName], provider)
+		}
+		sort.Strings(dependencies[service.ServiceName])
+	}
+	return dependencies, nil
+}
+
+// buildOrder sorts the services so that every service comes after its dependencies, services
+// which do not depend on each other are sorted by name
+func buildOrder(services []compose.ServiceBuildInfo, dependencies map[string][]string) ([]compose.ServiceBuildInfo, error) {
+	byName := make(map[string]compose.ServiceBuildInfo, len(services))
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		byName[service.ServiceName] = service
+		names = append(names, service.ServiceName)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var order []compose.ServiceBuildInfo
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(path[indexOf(path, name):], name)
+			return fmt.Errorf("circular build dependency between services: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, dependency := range dependencies[name] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		order = append(order, byName[name])
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// dockerfileBaseImages returns the images the stages of a Dockerfile are built FROM. Stages
+// built from a previous stage are left out, build arguments are expanded with the given
+// values or the defaults of the ARG instructions preceding the first FROM. A missing
+// Dockerfile has no base images, the build reports it.
+func dockerfileBaseImages(path string, buildArgs map[string]string) ([]string, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	args := make(map[string]string)
+	stages := make(map[string]bool)
+	var bases []string
+	seenFrom := false
+
+	for _, instruction := range dockerfileInstructions(bufio.NewScanner(file)) {
+		fields := strings.Fields(instruction)
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			// Only the arguments declared before the first FROM can be used in FROM
+			if seenFrom {
+				continue
+			}
+			for _, declaration := range fields[1:] {
+				name, value, _ := strings.Cut(declaration, "=")
+				if override, ok := buildArgs[name]; ok {
+					value = override
+				}
+				args[name] = strings.Trim(value, `"'`)
+			}
+		case "FROM":
+			seenFrom = true
+			image, stage := parseFrom(fields[1:])
+			if image == "" {
+				continue
+			}
+			image = os.Expand(image, func(name string) string { return args[name] })
+			if !stages[strings.ToLower(image)] {
+				bases = append(bases, image)
+			}
+			if stage != "" {
+				stages[strings.ToLower(stage)] = true
+			}
+		}
+	}
+	return bases, nil
+}
+
+// dockerfileInstructions returns the instructions of a Dockerfile, with their continuation
+// lines joined and without comments
+func dockerfileInstructions(scanner *bufio.Scanner) []string {
+	var instructions []string
+	var current strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") || (line == "" && current.Len() == 0) {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			current.WriteString(strings.TrimSuffix(line, "\\"))
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(line)
+		if instruction := strings.TrimSpace(current.String()); instruction != "" {
+			instructions = append(instructions, instruction)
+		}
+		current.Reset()
+	}
+	if instruction := strings.TrimSpace(current.String()); instruction != "" {
+		instructions = append(instructions, instruction)
+	}
+	return instructions
+}
+
+// parseFrom returns the image and the stage name of the arguments of a FROM instruction:
+// [--platform=<platform>] <image> [AS <name>]
+func parseFrom(args []string) (image, stage string) {
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return "", ""
+	}
+	image = args[0]
+	if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+		stage = args[2]
+	}
+	return image, stage
+}
+
+// normalizeImageRef returns the canonical form of an image reference, so that alpine,
+// library/alpine:latest and docker.io/library/alpine:latest are equal
+func normalizeImageRef(ref string) string {
+	ref = strings.TrimPrefix(ref, "docker.io/")
+	ref = strings.TrimPrefix(ref, "index.docker.io/")
+	ref = strings.TrimPrefix(ref, "library/")
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+	if !strings.Contains(ref[strings.LastIndex(ref, "/")+1:], ":") {
+		ref += ":latest"
+	}
+	return ref
+}
@@ -0,0 +1,112 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/compose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildService creates the build context of a service with the given Dockerfile
+func buildService(t *testing.T, name, image, dockerfile string) compose.ServiceBuildInfo {
+	t.Helper()
+	contextPath := writeContext(t, map[string]string{"Dockerfile": dockerfile})
+	return compose.ServiceBuildInfo{
+		ServiceName: name,
+		ContextPath: contextPath,
+		Image:       image,
+		Build:       &compose.BuildDirective{Dockerfile: "Dockerfile"},
+	}
+}
+
+func TestDockerfileBaseImages(t *testing.T) {
+	dockerfile := `# syntax=docker/dockerfile:1
+ARG BASE=acme/base
+ARG VERSION
+FROM --platform=$BUILDPLATFORM golang:1.25 AS builder
+RUN go build ./...
+
+FROM ${BASE}:${VERSION} as runtime
+ARG BASE=ignored
+COPY --from=builder /app /app
+
+FROM runtime
+FROM \
+  docker.io/library/alpine
+`
+	path := filepath.Join(t.TempDir(), "Dockerfile")
+	require.NoError(t, os.WriteFile(path, []byte(dockerfile), 0644))
+
+	bases, err := dockerfileBaseImages(path, map[string]string{"VERSION": "2.0"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"golang:1.25", "acme/base:2.0", "docker.io/library/alpine"}, bases)
+}
+
+func TestDockerfileBaseImages_Missing(t *testing.T) {
+	bases, err := dockerfileBaseImages(filepath.Join(t.TempDir(), "Dockerfile"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, bases)
+}
+
+func TestNormalizeImageRef(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: "alpine", expected: "alpine:latest"},
+		{ref: "library/alpine:3.20", expected: "alpine:3.20"},
+		{ref: "docker.io/library/alpine", expected: "alpine:latest"},
+		{ref: "acme/base", expected: "acme/base:latest"},
+		{ref: "registry.local:5000/acme/base", expected: "registry.local:5000/acme/base:latest"},
+		{ref: "acme/base@sha256:abc", expected: "acme/base@sha256:abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			assert.Equal(t, tt.expected, normalizeImageRef(tt.ref))
+		})
+	}
+}
+
+func TestServiceDependencies(t *testing.T) {
+	services := []compose.ServiceBuildInfo{
+		buildService(t, "web", "", "FROM acme/base:latest\n"),
+		buildService(t, "worker", "", "FROM acme/runtime AS run\nFROM acme/base\n"),
+		buildService(t, "base", "acme/base", "FROM alpine\n"),
+		buildService(t, "runtime", "acme/runtime:latest", "FROM acme/base\n"),
+		buildService(t, "tools", "acme/tools", "FROM acme/tools:1.0\n"),
+	}
+
+	dependencies, err := serviceDependencies(services)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"web":     {"base"},
+		"worker":  {"base", "runtime"},
+		"runtime": {"base"},
+	}, dependencies)
+
+	order, err := buildOrder(services, dependencies)
+	require.NoError(t, err)
+	var names []string
+	for _, service := range order {
+		names = append(names, service.ServiceName)
+	}
+	assert.Equal(t, []string{"base", "runtime", "tools", "web", "worker"}, names)
+}
+
+func TestBuildOrder_Cycle(t *testing.T) {
+	services := []compose.ServiceBuildInfo{
+		buildService(t, "a", "acme/a", "FROM acme/b\n"),
+		buildService(t, "b", "acme/b", "FROM acme/a\n"),
+	}
+
+	dependencies, err := serviceDependencies(services)
+	require.NoError(t, err)
+
+	_, err = buildOrder(services, dependencies)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "circular build dependency between services: a -> b -> a")
+}
@@ -0,0 +1,160 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+// dockerignoreFile is the ignore file at the root of a build context
+const dockerignoreFile = ".dockerignore"
+
+// ContextFilter selects the files of a build context sent to Docker, with the .dockerignore
+// semantics of the Docker CLI: ** globs, !exceptions, leading slashes and Dockerfile specific
+// ignore files
+type ContextFilter struct {
+	matcher *patternmatcher.PatternMatcher
+}
+
+// NewContextFilter loads the ignore patterns of a build context. <Dockerfile>.dockerignore, next
+// to the Dockerfile, takes precedence over the .dockerignore file at the root of the context.
+// dockerfile is relative to the context, empty for the default Dockerfile.
+func NewContextFilter(contextPath, dockerfile string) (*ContextFilter, error) {
+	patterns, err := loadDockerignore(contextPath, dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	return newContextFilter(patterns, dockerfile)
+}
+
+// newContextFilter creates the filter of the given ignore patterns
+func newContextFilter(patterns []string, dockerfile string) (*ContextFilter, error) {
+	patterns, err := keepBuildFiles(patterns, dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid .dockerignore pattern: %w", err)
+	}
+	return &ContextFilter{matcher: matcher}, nil
+}
+
+// loadDockerignore reads the ignore patterns of a build context, there are none without ignore file
+func loadDockerignore(contextPath, dockerfile string) ([]string, error) {
+	for _, name := range []string{dockerfilePath(dockerfile) + dockerignoreFile, dockerignoreFile} {
+		file, err := os.Open(filepath.Join(contextPath, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer file.Close()
+
+		patterns, err := ignorefile.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return patterns, nil
+	}
+	return []string{}, nil
+}
+
+// keepBuildFiles adds exceptions for the Dockerfile and the .dockerignore file when they are
+// ignored, the Docker engine reads them from the context like the Docker CLI sends them
+func keepBuildFiles(patterns []string, dockerfile string) ([]string, error) {
+	for _, name := range []string{dockerignoreFile, dockerfilePath(dockerfile)} {
+		ignored, err := patternmatcher.MatchesOrParentMatches(name, patterns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid .dockerignore pattern: %w", err)
+		}
+		if ignored {
+			patterns = append(patterns, "!"+name)
+		}
+	}
+	return patterns, nil
+}
+
+// dockerfilePath returns the slash separated path of the Dockerfile in the context
+func dockerfilePath(dockerfile string) string {
+	if dockerfile == "" {
+		return "Dockerfile"
+	}
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(dockerfile)), "./")
+}
+
+// Ignored reports whether a file of the context, given by its slash separated path relative
+// to the context, is ignored
+func (f *ContextFilter) Ignored(relPath string) (bool, error) {
+	return f.matcher.MatchesOrParentMatches(relPath)
+}
+
+// Walk calls fn in lexical order for the files and directories of the context which are not
+// ignored, relPath is slash separated. Ignored directories are skipped unless an exception
+// pattern may include files below them.
+func (f *ContextFilter) Walk(contextPath string, fn func(path, relPath string, info os.FileInfo) error) error {
+	parents := make(map[string]patternmatcher.MatchInfo)
+
+	return filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Skip the root directory itself
+		if path == contextPath {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(contextPath, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		ignored, matchInfo, err := f.matcher.MatchesUsingParentResults(relPath, parents[parentDir(relPath)])
+		if err != nil {
+			return fmt.Errorf("failed to match %s against .dockerignore: %w", relPath, err)
+		}
+		if info.IsDir() {
+			parents[relPath] = matchInfo
+		}
+
+		if ignored {
+			if info.IsDir() && !f.mayIncludeBelow(relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(path, relPath, info)
+	})
+}
+
+// mayIncludeBelow reports whether an exception pattern may include files of an ignored directory
+func (f *ContextFilter) mayIncludeBelow(dir string) bool {
+	if !f.matcher.Exclusions() {
+		return false
+	}
+	for _, pattern := range f.matcher.Patterns() {
+		if pattern.Exclusion() && strings.HasPrefix(pattern.String()+"/", dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parentDir returns the parent directory of a slash separated relative path, empty at the root
+func parentDir(relPath string) string {
+	dir := path.Dir(relPath)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
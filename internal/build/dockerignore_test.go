@@ -0,0 +1,456 @@
+package build
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ignored reports whether relPath is ignored by the given patterns
+func ignored(t *testing.T, patterns []string, relPath string) bool {
+	t.Helper()
+	filter, err := newContextFilter(patterns, "")
+	require.NoError(t, err)
+	result, err := filter.Ignored(relPath)
+	require.NoError(t, err)
+	return result
+}
+
+// writeContext creates the files of a build context, keyed by slash separated path
+func writeContext(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+// tarFiles returns the sorted regular files of the tar stream of a build context
+func tarFiles(t *testing.T, contextPath, dockerfile string) []string {
+	t.Helper()
+	reader, err := NewContextTarStreamer(0, 0, nil).CreateTarStream(contextPath, dockerfile)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var files []string
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeReg {
+			files = append(files, header.Name)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestLoadDockerignore(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// Create .dockerignore file
+	dockerignorePath := filepath.Join(tempDir, ".dockerignore")
+	dockerignoreContent := `# This is a comment
+*.log
+temp/
+.git/
+node_modules/
+`
+	err := os.WriteFile(dockerignorePath, []byte(dockerignoreContent), 0644)
+	require.NoError(t, err)
+
+	patterns, err := loadDockerignore(tempDir, "")
+	require.NoError(t, err)
+
+	// Should load patterns, skipping comments and empty lines, cleaned like Docker does
+	expected := []string{"*.log", "temp", ".git", "node_modules"}
+	assert.Equal(t, expected, patterns)
+}
+
+func TestLoadDockerignore_NotFound(t *testing.T) {
+	tempDir := t.TempDir()
+
+	patterns, err := loadDockerignore(tempDir, "")
+	require.NoError(t, err)
+
+	// Should return empty slice when .dockerignore doesn't exist
+	assert.Empty(t, patterns)
+}
+
+func TestContextFilter_Ignored(t *testing.T) {
+	tests := []struct {
+		name     string
+		relPath  string
+		patterns []string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			relPath:  "file.txt",
+			patterns: []string{"file.txt"},
+			expected: true,
+		},
+		{
+			name:     "prefix match",
+			relPath:  "temp/file.txt",
+			patterns: []string{"temp"},
+			expected: true,
+		},
+		{
+			name:     "wildcard match",
+			relPath:  "app.log",
+			patterns: []string{"*.log"},
+			expected: true,
+		},
+		{
+			name:     "directory pattern",
+			relPath:  "node_modules/package",
+			patterns: []string{"node_modules/"},
+			expected: true,
+		},
+		{
+			name:     "no match",
+			relPath:  "src/main.go",
+			patterns: []string{"*.log", "temp/"},
+			expected: false,
+		},
+		{
+			name:     "multiple patterns",
+			relPath:  "app.log",
+			patterns: []string{"*.log", "temp/", "*.tmp"},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ignored(t, tt.patterns, tt.relPath)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestContextFilter_Ignored_WildcardPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		relPath  string
+		pattern  string
+		expected bool
+	}{
+		{
+			name:     "simple wildcard",
+			relPath:  "app.log",
+			pattern:  "*.log",
+			expected: true,
+		},
+		{
+			name:     "wildcard in middle",
+			relPath:  "src/main.go",
+			pattern:  "src/*.go",
+			expected: true,
+		},
+		{
+			name:     "multiple wildcards",
+			relPath:  "src/test/main_test.go",
+			pattern:  "src/*/main_*.go",
+			expected: true,
+		},
+		{
+			name:     "no wildcard match",
+			relPath:  "src/main.go",
+			pattern:  "*.log",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ignored(t, []string{tt.pattern}, tt.relPath)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestContextFilter_Ignored_DirectoryPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		relPath  string
+		pattern  string
+		expected bool
+	}{
+		{
+			name:     "directory with trailing slash",
+			relPath:  "node_modules/package",
+			pattern:  "node_modules/",
+			expected: true,
+		},
+		{
+			name:     "exact directory match",
+			relPath:  "temp",
+			pattern:  "temp/",
+			expected: true,
+		},
+		{
+			name:     "subdirectory match",
+			relPath:  "temp/subdir/file.txt",
+			pattern:  "temp/",
+			expected: true,
+		},
+		{
+			name:     "no directory match",
+			relPath:  "src/main.go",
+			pattern:  "temp/",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ignored(t, []string{tt.pattern}, tt.relPath)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestContextFilter_Ignored_SinglePattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		relPath  string
+		pattern  string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			relPath:  "file.txt",
+			pattern:  "file.txt",
+			expected: true,
+		},
+		{
+			name:     "prefix match",
+			relPath:  "temp/file.txt",
+			pattern:  "temp",
+			expected: true,
+		},
+		{
+			name:     "wildcard match",
+			relPath:  "app.log",
+			pattern:  "*.log",
+			expected: true,
+		},
+		{
+			name:     "directory pattern",
+			relPath:  "node_modules/package",
+			pattern:  "node_modules/",
+			expected: true,
+		},
+		{
+			name:     "no match",
+			relPath:  "src/main.go",
+			pattern:  "*.log",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ignored(t, []string{tt.pattern}, tt.relPath)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// TestContextFilter_Golden checks the files sent to Docker against the context the Docker CLI
+// sends for the same .dockerignore
+func TestContextFilter_Golden(t *testing.T) {
+	baseFiles := map[string]string{
+		"Dockerfile":                      "FROM alpine",
+		"README.md":                       "readme",
+		"main.go":                         "package main",
+		"app.log":                         "log",
+		".git/HEAD":                       "ref",
+		"build/out.bin":                   "bin",
+		"docs/guide.md":                   "guide",
+		"docs/keep.md":                    "keep",
+		"node_modules/left-pad/index.js":  "pad",
+		"src/build/main.go":               "package build",
+		"src/lib.c":                       "lib",
+		"src/logs/debug.log":              "log",
+		"src/node_modules/dep/index.js":   "dep",
+		"src/node_modules/dep/readme.txt": "dep",
+	}
+
+	tests := []struct {
+		name         string
+		dockerignore string
+		files        map[string]string
+		dockerfile   string
+		expected     []string
+	}{
+		{
+			name:         "double star matches at any depth",
+			dockerignore: "**/node_modules\n",
+			expected: []string{
+				".dockerignore", "Dockerfile", "README.md", "app.log", ".git/HEAD", "build/out.bin",
+				"docs/guide.md", "docs/keep.md", "main.go", "src/build/main.go", "src/lib.c", "src/logs/debug.log",
+			},
+		},
+		{
+			name:         "directory excludes its content",
+			dockerignore: ".git\nnode_modules/\n",
+			expected: []string{
+				".dockerignore", "Dockerfile", "README.md", "app.log", "build/out.bin", "docs/guide.md", "docs/keep.md",
+				"main.go", "src/build/main.go", "src/lib.c", "src/logs/debug.log",
+				"src/node_modules/dep/index.js", "src/node_modules/dep/readme.txt",
+			},
+		},
+		{
+			name:         "exception keeps a file",
+			dockerignore: "*.md\n!README.md\n",
+			expected: []string{
+				".dockerignore", ".git/HEAD", "Dockerfile", "README.md", "app.log", "build/out.bin",
+				"docs/guide.md", "docs/keep.md", "main.go", "node_modules/left-pad/index.js",
+				"src/build/main.go", "src/lib.c", "src/logs/debug.log",
+				"src/node_modules/dep/index.js", "src/node_modules/dep/readme.txt",
+			},
+		},
+		{
+			name:         "exception below an excluded directory",
+			dockerignore: "docs\n!docs/keep.md\n",
+			expected: []string{
+				".dockerignore", ".git/HEAD", "Dockerfile", "README.md", "app.log", "build/out.bin",
+				"docs/keep.md", "main.go", "node_modules/left-pad/index.js",
+				"src/build/main.go", "src/lib.c", "src/logs/debug.log",
+				"src/node_modules/dep/index.js", "src/node_modules/dep/readme.txt",
+			},
+		},
+		{
+			name:         "leading slash is relative to the context root",
+			dockerignore: "/build\n",
+			expected: []string{
+				".dockerignore", ".git/HEAD", "Dockerfile", "README.md", "app.log",
+				"docs/guide.md", "docs/keep.md", "main.go", "node_modules/left-pad/index.js",
+				"src/build/main.go", "src/lib.c", "src/logs/debug.log",
+				"src/node_modules/dep/index.js", "src/node_modules/dep/readme.txt",
+			},
+		},
+		{
+			name:         "double star with an extension",
+			dockerignore: "**/*.log\n.git\nnode_modules\nsrc/node_modules\n",
+			expected: []string{
+				".dockerignore", "Dockerfile", "README.md", "build/out.bin", "docs/guide.md", "docs/keep.md",
+				"main.go", "src/build/main.go", "src/lib.c",
+			},
+		},
+		{
+			name:         "everything but a directory keeps the build files",
+			dockerignore: "*\n!src\n",
+			expected: []string{
+				".dockerignore", "Dockerfile", "src/build/main.go", "src/lib.c", "src/logs/debug.log",
+				"src/node_modules/dep/index.js", "src/node_modules/dep/readme.txt",
+			},
+		},
+		{
+			name:         "single character and range globs",
+			dockerignore: "app.lo?\nsrc/[a-c]*\n*\n!src\n!app.log\n",
+			expected: []string{
+				".dockerignore", "Dockerfile", "app.log", "src/build/main.go", "src/lib.c", "src/logs/debug.log",
+				"src/node_modules/dep/index.js", "src/node_modules/dep/readme.txt",
+			},
+		},
+		{
+			name:         "last matching pattern wins",
+			dockerignore: "*\n!src\nsrc/node_modules\n!src/node_modules/dep/readme.txt\nsrc/*/*.go\n",
+			expected: []string{
+				".dockerignore", "Dockerfile", "src/lib.c", "src/logs/debug.log", "src/node_modules/dep/readme.txt",
+			},
+		},
+		{
+			name:         "dockerfile specific ignore file takes precedence",
+			dockerignore: "*\n",
+			files: map[string]string{
+				"app/Dockerfile":              "FROM alpine",
+				"app/Dockerfile.dockerignore": "*\n!main.go\n",
+			},
+			dockerfile: "app/Dockerfile",
+			expected:   []string{".dockerignore", "app/Dockerfile", "main.go"},
+		},
+		{
+			name:         "default dockerfile specific ignore file",
+			dockerignore: "*\n",
+			files: map[string]string{
+				"Dockerfile.dockerignore": "**/*.go\n**/*.log\n**/*.md\n.git\n**/node_modules\n",
+			},
+			expected: []string{".dockerignore", "Dockerfile", "Dockerfile.dockerignore", "build/out.bin", "src/lib.c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{".dockerignore": tt.dockerignore}
+			for name, content := range baseFiles {
+				files[name] = content
+			}
+			for name, content := range tt.files {
+				files[name] = content
+			}
+			contextPath := writeContext(t, files)
+
+			expected := append([]string(nil), tt.expected...)
+			sort.Strings(expected)
+			assert.Equal(t, expected, tarFiles(t, contextPath, tt.dockerfile))
+
+			// The size, the hash and the tar stream see the same files
+			var size int64
+			for _, name := range expected {
+				size += int64(len(files[name]))
+			}
+			streamer := NewContextTarStreamer(0, 0, nil)
+			contextSize, err := streamer.GetContextSize(contextPath, tt.dockerfile)
+			require.NoError(t, err)
+			assert.Equal(t, size, contextSize)
+
+			hasher := NewContentHasher()
+			before, err := hasher.HashBuildContext(contextPath, tt.dockerfile, nil)
+			require.NoError(t, err)
+			for name := range files {
+				// The ignore files define the filter itself
+				if !contains(expected, name) && !strings.HasSuffix(name, dockerignoreFile) {
+					require.NoError(t, os.WriteFile(filepath.Join(contextPath, filepath.FromSlash(name)), []byte("changed"), 0644))
+				}
+			}
+			after, err := hasher.HashBuildContext(contextPath, tt.dockerfile, nil)
+			require.NoError(t, err)
+			assert.Equal(t, before, after, "ignored files must not change the hash")
+		})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewContextFilter_InvalidPattern(t *testing.T) {
+	contextPath := writeContext(t, map[string]string{".dockerignore": "[\n"})
+
+	_, err := NewContextFilter(contextPath, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid .dockerignore pattern")
+}
@@ -0,0 +1,128 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ContextFile is a regular file of a build context, its path is slash separated and relative
+// to the context
+type ContextFile struct {
+	Path string
+	Size int64
+}
+
+// ContextEntry is a file or directory of a build context with the size of what it contributes
+// to the context
+type ContextEntry struct {
+	Path  string
+	Size  int64
+	IsDir bool
+}
+
+// ContextReport lists the files of a build context sent to Docker, in lexical order
+type ContextReport struct {
+	Files     []ContextFile
+	TotalSize int64
+}
+
+// InspectContext lists the files of the build context which are not ignored by its
+// .dockerignore file. dockerfile is relative to the context, empty for the default Dockerfile.
+func (cts *ContextTarStreamer) InspectContext(contextPath, dockerfile string) (*ContextReport, error) {
+	if !isDirectory(contextPath) {
+		return nil, fmt.Errorf("context path is not a directory: %s", contextPath)
+	}
+
+	filter, err := NewContextFilter(contextPath, dockerfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load .dockerignore: %w", err)
+	}
+
+	report := &ContextReport{}
+	err = filter.Walk(contextPath, func(_, relPath string, info os.FileInfo) error {
+		if info.Mode().IsRegular() {
+			report.Files = append(report.Files, ContextFile{Path: relPath, Size: info.Size()})
+			report.TotalSize += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Largest returns the n files and directories contributing the most to the context, largest
+// first. The content of a listed directory is not listed again.
+func (r *ContextReport) Largest(n int) []ContextEntry {
+	sizes := make(map[string]int64)
+	for _, file := range r.Files {
+		sizes[file.Path] += file.Size
+		for dir := parentDir(file.Path); dir != ""; dir = parentDir(dir) {
+			sizes[dir+"/"] += file.Size
+		}
+	}
+
+	entries := make([]ContextEntry, 0, len(sizes))
+	for path, size := range sizes {
+		entries = append(entries, ContextEntry{
+			Path:  strings.TrimSuffix(path, "/"),
+			Size:  size,
+			IsDir: strings.HasSuffix(path, "/"),
+		})
+	}
+	// Directories before their content at equal size, then by path for a stable order
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		if len(entries[i].Path) != len(entries[j].Path) {
+			return len(entries[i].Path) < len(entries[j].Path)
+		}
+		return entries[i].Path < entries[j].Path
+	})
+
+	var largest []ContextEntry
+	for _, entry := range entries {
+		if len(largest) == n {
+			break
+		}
+		if !withinListedDir(entry.Path, largest) {
+			largest = append(largest, entry)
+		}
+	}
+	return largest
+}
+
+// withinListedDir reports whether path is inside one of the listed directories
+func withinListedDir(path string, listed []ContextEntry) bool {
+	for _, entry := range listed {
+		if entry.IsDir && strings.HasPrefix(path, entry.Path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatSize formats a size in bytes for humans, e.g. 12.3 MB
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
+
+// megabytes converts a size in MB from the build configuration to bytes
+func megabytes(mb int) int64 {
+	return int64(mb) * 1024 * 1024
+}
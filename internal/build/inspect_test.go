@@ -0,0 +1,76 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextTarStreamer_InspectContext(t *testing.T) {
+	contextPath := writeContext(t, map[string]string{
+		".dockerignore":       "*.log\n",
+		"Dockerfile":          "FROM alpine",
+		"app.log":             "ignored",
+		"src/main.go":         "package main",
+		"src/pkg/util/lib.go": "package util",
+	})
+
+	report, err := NewContextTarStreamer(0, 0, nil).InspectContext(contextPath, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, []ContextFile{
+		{Path: ".dockerignore", Size: 6},
+		{Path: "Dockerfile", Size: 11},
+		{Path: "src/main.go", Size: 12},
+		{Path: "src/pkg/util/lib.go", Size: 12},
+	}, report.Files)
+	assert.Equal(t, int64(41), report.TotalSize)
+}
+
+func TestContextTarStreamer_InspectContext_NotDirectory(t *testing.T) {
+	_, err := NewContextTarStreamer(0, 0, nil).InspectContext("/nonexistent/context", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "context path is not a directory")
+}
+
+func TestContextReport_Largest(t *testing.T) {
+	report := &ContextReport{Files: []ContextFile{
+		{Path: "Dockerfile", Size: 10},
+		{Path: "assets/logo.png", Size: 300},
+		{Path: "assets/video/intro.mp4", Size: 900},
+		{Path: "node_modules/a/index.js", Size: 400},
+		{Path: "node_modules/b/index.js", Size: 400},
+		{Path: "vendor/lib.go", Size: 50},
+	}}
+
+	assert.Equal(t, []ContextEntry{
+		{Path: "assets", Size: 1200, IsDir: true},
+		{Path: "node_modules", Size: 800, IsDir: true},
+		{Path: "vendor", Size: 50, IsDir: true},
+	}, report.Largest(3))
+
+	// The content of listed directories is not repeated
+	assert.Len(t, report.Largest(10), 4)
+	assert.Empty(t, (&ContextReport{}).Largest(10))
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size     int64
+		expected string
+	}{
+		{size: 0, expected: "0 B"},
+		{size: 1023, expected: "1023 B"},
+		{size: 1536, expected: "1.5 KB"},
+		{size: 50 * 1024 * 1024, expected: "50.0 MB"},
+		{size: 3 * 1024 * 1024 * 1024, expected: "3.0 GB"},
+		{size: 2 * 1024 * 1024 * 1024 * 1024, expected: "2.0 TB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatSize(tt.size))
+		})
+	}
+}
@@ -87,48 +87,73 @@ func (l *StyledBuildLogger) LogError(message string) {
 
 // cleanDockerLine parses docker-build JSON lines and returns a concise, pretty string.
 func (l *StyledBuildLogger) cleanDockerLine(line string) string {
+	text, kind := parseDockerLine(line)
+	switch kind {
+	case lineEmpty:
+		return ""
+	case lineStep:
+		return text
+	case lineError:
+		return l.styleError.Render(text)
+	case lineSuccess:
+		return l.styleSuccess.Render(text)
+	default:
+		return l.styleDim.Render(text)
+	}
+}
+
+// dockerLineKind classifies a line of docker build output
+type dockerLineKind int
+
+const (
+	lineEmpty   dockerLineKind = iota // nothing to display
+	lineOutput                        // output of a build step, or a line which is not JSON
+	lineStep                          // step, intermediate image or success message
+	lineError                         // build error
+	lineSuccess                       // ID of the built image
+)
+
+// parseDockerLine extracts the text of a docker-build JSON line and its kind
+func parseDockerLine(line string) (string, dockerLineKind) {
 	line = strings.TrimSpace(line)
 	if line == "" {
-		return ""
+		return "", lineEmpty
 	}
 
 	if line[0] != '{' {
-		return l.styleDim.Render(line)
+		return line, lineOutput
 	}
 
 	var m map[string]any
 	if err := json.Unmarshal([]byte(line), &m); err != nil {
-		return l.styleDim.Render(line)
+		return line, lineOutput
 	}
 
 	if s, ok := m["stream"].(string); ok {
 		s = strings.TrimSpace(s)
 		if s == "" {
-			return ""
+			return "", lineEmpty
 		}
 		if strings.HasPrefix(s, "Step ") || strings.HasPrefix(s, "Successfully") || strings.HasPrefix(s, "---") {
-			return s
-		}
-		if strings.HasPrefix(s, "Running in ") || strings.HasPrefix(s, "Removed intermediate container ") {
-			return l.styleDim.Render(s)
+			return s, lineStep
 		}
-		return l.styleDim.Render(s)
+		return s, lineOutput
 	}
 
 	if ed, ok := m["errorDetail"].(map[string]any); ok {
 		if msg, ok := ed["message"].(string); ok && msg != "" {
-			return l.styleError.Render(msg)
+			return msg, lineError
 		}
 	}
 	if msg, ok := m["error"].(string); ok && msg != "" {
-		return l.styleError.Render(msg)
+		return msg, lineError
 	}
 
 	if aux, ok := m["aux"].(map[string]any); ok {
 		if id, ok := aux["ID"].(string); ok && id != "" {
-			return l.styleSuccess.Render("Built " + id)
+			return "Built " + id, lineSuccess
 		}
 	}
 
-	return l.styleDim.Render(line)
+	return line, lineOutput
 }
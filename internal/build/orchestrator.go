@@ -2,11 +2,14 @@ package build
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/charmbracelet/lipgloss"
@@ -38,6 +41,33 @@ type BuildResult struct {
 	ImageTag    string
 	Success     bool
 	Error       error
+	NotBuilt    bool // the build did not run, or was aborted, because a dependency failed or the builds were cancelled
+}
+
+// BuildError lists every service which failed to build
+type BuildError struct {
+	Failed   []BuildResult // services whose build failed, in build order
+	NotBuilt []string      // services not built because a dependency failed or the builds were cancelled
+}
+
+func (e *BuildError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "build failed for %d service(s)", len(e.Failed))
+	for _, result := range e.Failed {
+		fmt.Fprintf(&b, "\n  %s: %v", result.ServiceName, result.Error)
+	}
+	if len(e.NotBuilt) > 0 {
+		fmt.Fprintf(&b, "\n  not built: %s", strings.Join(e.NotBuilt, ", "))
+	}
+	return b.String()
+}
+
+// buildPlan holds the order, the dependencies and the image tags of the services to build
+type buildPlan struct {
+	order        []compose.ServiceBuildInfo // every service comes after its dependencies
+	dependencies map[string][]string        // service -> services whose image its Dockerfile is built FROM
+	tags         map[string]string          // service -> image tag
+	bases        map[string]bool            // services other services are built FROM
 }
 
 // NewBuildOrchestrator creates a new build orchestrator
@@ -51,98 +81,265 @@ func NewBuildOrchestrator(client *portainer.Client, buildConfig *config.BuildCon
 	}
 }
 
-// BuildServices builds all services with build directives
-func (bo *BuildOrchestrator) BuildServices(servicesWithBuild []compose.ServiceBuildInfo) (map[string]string, error) {
+// BuildServices builds all services with build directives. A service whose Dockerfile is built
+// FROM the image of another service is built once that service is built. Builds not started
+// yet are skipped once ctx is cancelled, or after the first failure with fail_fast, running
+// builds are aborted.
+func (bo *BuildOrchestrator) BuildServices(ctx context.Context, servicesWithBuild []compose.ServiceBuildInfo) (map[string]string, error) {
 	if len(servicesWithBuild) == 0 {
 		return make(map[string]string), nil
 	}
 
 	bo.logger.LogInfo(fmt.Sprintf("Building %d service(s) with build directives", len(servicesWithBuild)))
 
+	plan, err := bo.planBuilds(servicesWithBuild)
+	if err != nil {
+		return nil, err
+	}
+	for _, serviceInfo := range plan.order {
+		if dependencies := plan.dependencies[serviceInfo.ServiceName]; len(dependencies) > 0 {
+			bo.logger.LogService(serviceInfo.ServiceName, fmt.Sprintf("Built after %s, its Dockerfile uses their image", strings.Join(dependencies, ", ")))
+		}
+	}
+
 	// Determine parallelism
-	parallel := bo.getParallelism()
+	parallel := bo.getParallelism(ctx)
 	bo.logger.LogInfo(fmt.Sprintf("Using parallelism: %d", parallel))
 
+	// The builds are cancelled on the first failure with fail_fast
+	buildCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Create semaphore for controlling parallelism
 	semaphore := make(chan struct{}, parallel)
 	var wg sync.WaitGroup
-	results := make(chan BuildResult, len(servicesWithBuild))
 
-	// Build services in parallel
-	for _, service := range servicesWithBuild {
+	// A service's channel is closed once its result is recorded
+	done := make(map[string]chan struct{}, len(plan.order))
+	for _, serviceInfo := range plan.order {
+		done[serviceInfo.ServiceName] = make(chan struct{})
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]BuildResult, len(plan.order))
+	failFastTriggered := false
+	record := func(result BuildResult) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case result.Success:
+			bo.logger.LogInfo(fmt.Sprintf("✓ Built %s -> %s", result.ServiceName, result.ImageTag))
+		case result.NotBuilt || failFastTriggered || ctx.Err() != nil:
+			// Aborted builds fail with the cancellation, not with a build error
+			result.NotBuilt = true
+			bo.logger.LogWarn(fmt.Sprintf("%s not built: %v", result.ServiceName, result.Error))
+		default:
+			bo.logger.LogError(fmt.Sprintf("✗ Failed to build %s: %v", result.ServiceName, result.Error))
+			if bo.config.FailFast {
+				failFastTriggered = true
+				cancel()
+			}
+		}
+		results[result.ServiceName] = result
+	}
+	succeeded := func(serviceName string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return results[serviceName].Success
+	}
+
+	// Build services in parallel, once their dependencies are built
+	for _, service := range plan.order {
 		wg.Add(1)
 		go func(serviceInfo compose.ServiceBuildInfo) {
 			defer wg.Done()
+			defer close(done[serviceInfo.ServiceName])
+
+			for _, dependency := range plan.dependencies[serviceInfo.ServiceName] {
+				<-done[dependency]
+				if !succeeded(dependency) {
+					record(BuildResult{ServiceName: serviceInfo.ServiceName, NotBuilt: true, Error: fmt.Errorf("dependency %s was not built", dependency)})
+					return
+				}
+			}
+
 			semaphore <- struct{}{}        // Acquire semaphore
 			defer func() { <-semaphore }() // Release semaphore
 
-			result := bo.buildService(serviceInfo)
-			results <- result
+			if err := buildCtx.Err(); err != nil {
+				record(BuildResult{ServiceName: serviceInfo.ServiceName, NotBuilt: true, Error: err})
+				return
+			}
+
+			// Images built by pctl cannot be pulled, the services built FROM them are built without pull
+			pull := bo.config.Pull && len(plan.dependencies[serviceInfo.ServiceName]) == 0
+			record(bo.buildService(buildCtx, serviceInfo, plan.tags[serviceInfo.ServiceName], plan.bases[serviceInfo.ServiceName], pull))
 		}(service)
 	}
 
 	// Wait for all builds to complete
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+	wg.Wait()
 
 	// Collect results
 	imageTags := make(map[string]string)
-	var buildErrors []error
-
-	for result := range results {
-		if result.Success {
+	buildErr := &BuildError{}
+	for _, serviceInfo := range plan.order {
+		result := results[serviceInfo.ServiceName]
+		switch {
+		case result.Success:
 			imageTags[result.ServiceName] = result.ImageTag
-			bo.logger.LogInfo(fmt.Sprintf("✓ Built %s -> %s", result.ServiceName, result.ImageTag))
-		} else {
-			buildErrors = append(buildErrors, fmt.Errorf("failed to build %s: %w", result.ServiceName, result.Error))
-			bo.logger.LogError(fmt.Sprintf("✗ Failed to build %s: %v", result.ServiceName, result.Error))
+		case result.NotBuilt:
+			buildErr.NotBuilt = append(buildErr.NotBuilt, result.ServiceName)
+		default:
+			buildErr.Failed = append(buildErr.Failed, result)
 		}
 	}
 
 	// Check for build failures
-	if len(buildErrors) > 0 {
-		return nil, fmt.Errorf("build failed for %d service(s): %v", len(buildErrors), buildErrors[0])
+	if len(buildErr.Failed) > 0 {
+		return nil, buildErr
+	}
+	if len(buildErr.NotBuilt) > 0 {
+		return nil, fmt.Errorf("build cancelled, not built: %s: %w", strings.Join(buildErr.NotBuilt, ", "), ctx.Err())
 	}
 
 	bo.logger.LogInfo(fmt.Sprintf("Successfully built %d service(s)", len(imageTags)))
 	return imageTags, nil
 }
 
-// buildService builds a single service
-func (bo *BuildOrchestrator) buildService(serviceInfo compose.ServiceBuildInfo) BuildResult {
-	serviceName := serviceInfo.ServiceName
-	bo.logger.LogService(serviceName, "Starting build...")
+// ResolveTags returns the image tags the services would be built with, without building them
+func (bo *BuildOrchestrator) ResolveTags(servicesWithBuild []compose.ServiceBuildInfo) (map[string]string, error) {
+	plan, err := bo.planBuilds(servicesWithBuild)
+	if err != nil {
+		return nil, err
+	}
+	return plan.tags, nil
+}
+
+// planBuilds orders the services after the services whose image they are built FROM, and
+// resolves their image tags
+func (bo *BuildOrchestrator) planBuilds(servicesWithBuild []compose.ServiceBuildInfo) (*buildPlan, error) {
+	dependencies, err := serviceDependencies(servicesWithBuild)
+	if err != nil {
+		return nil, err
+	}
+	if bo.config.Mode == config.BuildModeLoad {
+		if err := checkLoadModeDependencies(dependencies); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := buildOrder(servicesWithBuild, dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &buildPlan{
+		order:        order,
+		dependencies: dependencies,
+		tags:         make(map[string]string),
+		bases:        make(map[string]bool),
+	}
+	for _, serviceInfo := range order {
+		for _, dependency := range dependencies[serviceInfo.ServiceName] {
+			plan.bases[dependency] = true
+		}
+
+		imageTag, err := bo.imageTag(serviceInfo, dependencies[serviceInfo.ServiceName], plan.tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve tag for %s: %w", serviceInfo.ServiceName, err)
+		}
+		plan.tags[serviceInfo.ServiceName] = imageTag
+	}
+	return plan, nil
+}
+
+// checkLoadModeDependencies rejects services built FROM the image of another service in load
+// mode: the images are streamed to the remote engine, the local builder cannot resolve them
+func checkLoadModeDependencies(dependencies map[string][]string) error {
+	var dependents []string
+	for service, bases := range dependencies {
+		if len(bases) > 0 {
+			dependents = append(dependents, fmt.Sprintf("%s (FROM %s)", service, strings.Join(bases, ", ")))
+		}
+	}
+	if len(dependents) == 0 {
+		return nil
+	}
+	sort.Strings(dependents)
+	return fmt.Errorf("build mode '%s' cannot build services FROM the image of another service of the stack, "+
+		"images are loaded to the remote engine and not to the local builder: %s. Use build mode '%s'",
+		config.BuildModeLoad, strings.Join(dependents, ", "), config.BuildModeRemoteBuild)
+}
+
+// imageTag generates the image tag of a service from the content hash of its build context.
+// The tags of its dependencies are part of the hash, so that the service is rebuilt when the
+// image it is built FROM changes.
+func (bo *BuildOrchestrator) imageTag(serviceInfo compose.ServiceBuildInfo, dependencies []string, tags map[string]string) (string, error) {
+	hashedArgs := serviceInfo.Build.Args
+	if len(dependencies) > 0 {
+		hashedArgs = make(map[string]string, len(serviceInfo.Build.Args)+len(dependencies))
+		for key, value := range serviceInfo.Build.Args {
+			hashedArgs[key] = value
+		}
+		for _, dependency := range dependencies {
+			hashedArgs["pctl:base:"+dependency] = tags[dependency]
+		}
+	}
 
-	// Generate content hash
 	hasher := NewContentHasher()
 	contentHash, err := hasher.HashBuildContext(
 		serviceInfo.ContextPath,
 		serviceInfo.Build.Dockerfile,
-		serviceInfo.Build.Args,
+		hashedArgs,
 	)
 	if err != nil {
-		return BuildResult{
-			ServiceName: serviceName,
-			Success:     false,
-			Error:       fmt.Errorf("failed to generate content hash: %w", err),
-		}
+		return "", fmt.Errorf("failed to generate content hash: %w", err)
 	}
 
-	// Generate image tag
 	tagGenerator := NewTagGenerator(bo.stackName, bo.config.TagFormat)
-	imageTag := tagGenerator.GenerateTag(serviceName, contentHash)
+	return tagGenerator.GenerateTag(serviceInfo.ServiceName, contentHash), nil
+}
 
-	// Check if image already exists (unless force build is enabled)
-	if !bo.config.ForceBuild {
-		exists, err := bo.client.ImageExists(bo.envID, imageTag)
+// baseImageTags returns the names the image of a service is also tagged with. Images other
+// services are built FROM are tagged with the image name of the compose file.
+func baseImageTags(serviceInfo compose.ServiceBuildInfo, isBase bool) []string {
+	if !isBase || serviceInfo.Image == "" {
+		return nil
+	}
+	return []string{serviceInfo.Image}
+}
+
+// buildService builds a single service, pulling newer base images when pull is set
+func (bo *BuildOrchestrator) buildService(ctx context.Context, serviceInfo compose.ServiceBuildInfo, imageTag string, isBase, pull bool) BuildResult {
+	serviceName := serviceInfo.ServiceName
+	bo.logger.LogService(serviceName, "Starting build...")
+	extraTags := baseImageTags(serviceInfo, isBase)
+
+	// Check if image already exists (unless force build is enabled, or newer base images must
+	// be pulled: they are not part of the image tag, and the services built FROM a pulled
+	// image are rebuilt on its new version)
+	if !bo.config.ForceBuild && !bo.config.Pull {
+		exists, err := bo.client.ImageExists(ctx, bo.envID, imageTag)
 		if err != nil {
 			bo.logger.LogWarn(fmt.Sprintf("Could not check if image exists for %s: %v", serviceName, err))
 		} else if exists {
 			// Styled message for unchanged service (skipping build)
 			skipStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Bold(true)
 			bo.logger.LogService(serviceName, skipStyle.Render("No changes detected; skipping build")+fmt.Sprintf(" (image: %s)", imageTag))
+
+			// The services built FROM this image must get this version of it
+			for _, tag := range extraTags {
+				if err := bo.client.TagImage(ctx, bo.envID, imageTag, tag); err != nil {
+					return BuildResult{
+						ServiceName: serviceName,
+						Success:     false,
+						Error:       fmt.Errorf("failed to tag %s as %s: %w", imageTag, tag, err),
+					}
+				}
+			}
+
 			return BuildResult{
 				ServiceName: serviceName,
 				ImageTag:    imageTag,
@@ -156,6 +353,12 @@ func (bo *BuildOrchestrator) buildService(serviceInfo compose.ServiceBuildInfo)
 		// Force rebuild requested via CLI/config
 		forceStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)
 		bo.logger.LogService(serviceName, forceStyle.Render("Force rebuild requested; rebuilding service (no-cache)"))
+	} else if pull {
+		pullStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
+		bo.logger.LogService(serviceName, pullStyle.Render("Pull requested; rebuilding service with newer base images"))
+	} else if bo.config.Pull {
+		pullStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
+		bo.logger.LogService(serviceName, pullStyle.Render("Pull requested; rebuilding service on the base image built by pctl, without pull"))
 	} else {
 		changeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("12")).Bold(true)
 		bo.logger.LogService(serviceName, changeStyle.Render("Changes detected; triggering build"))
@@ -164,9 +367,9 @@ func (bo *BuildOrchestrator) buildService(serviceInfo compose.ServiceBuildInfo)
 	// Build based on mode
 	switch bo.config.Mode {
 	case config.BuildModeRemoteBuild:
-		return bo.buildRemote(serviceInfo, imageTag)
+		return bo.buildRemote(ctx, serviceInfo, imageTag, extraTags, pull)
 	case config.BuildModeLoad:
-		return bo.buildLocal(serviceInfo, imageTag)
+		return bo.buildLocal(ctx, serviceInfo, imageTag, extraTags)
 	default:
 		return BuildResult{
 			ServiceName: serviceName,
@@ -177,13 +380,22 @@ func (bo *BuildOrchestrator) buildService(serviceInfo compose.ServiceBuildInfo)
 }
 
 // buildRemote builds the service on the remote Docker engine
-func (bo *BuildOrchestrator) buildRemote(serviceInfo compose.ServiceBuildInfo, imageTag string) BuildResult {
+func (bo *BuildOrchestrator) buildRemote(ctx context.Context, serviceInfo compose.ServiceBuildInfo, imageTag string, extraTags []string, pull bool) BuildResult {
 	serviceName := serviceInfo.ServiceName
 	bo.logger.LogService(serviceName, "Building on remote engine...")
 
+	// Check the size of the context before uploading it
+	streamer := NewContextTarStreamer(bo.config.WarnThresholdMB, bo.config.MaxContextMB, bo.logger)
+	if err := streamer.ValidateContext(serviceInfo.ContextPath, serviceInfo.Build.Dockerfile); err != nil {
+		return BuildResult{
+			ServiceName: serviceName,
+			Success:     false,
+			Error:       err,
+		}
+	}
+
 	// Create context tar stream
-	streamer := NewContextTarStreamer(bo.config.WarnThresholdMB)
-	ctxTar, err := streamer.CreateTarStream(serviceInfo.ContextPath)
+	ctxTar, err := streamer.CreateTarStream(serviceInfo.ContextPath, serviceInfo.Build.Dockerfile)
 	if err != nil {
 		return BuildResult{
 			ServiceName: serviceName,
@@ -196,10 +408,12 @@ func (bo *BuildOrchestrator) buildRemote(serviceInfo compose.ServiceBuildInfo, i
 	// Prepare build options (force build implies no-cache)
 	buildOpts := portainer.BuildOptions{
 		Tag:        imageTag,
+		ExtraTags:  extraTags,
 		Dockerfile: serviceInfo.Build.Dockerfile,
 		BuildArgs:  serviceInfo.Build.Args,
 		Target:     serviceInfo.Build.Target,
 		NoCache:    bo.config.ForceBuild,
+		Pull:       pull,
 	}
 
 	// Merge extra build args
@@ -211,7 +425,7 @@ func (bo *BuildOrchestrator) buildRemote(serviceInfo compose.ServiceBuildInfo, i
 	}
 
 	// Build on remote
-	err = bo.client.BuildImage(bo.envID, ctxTar, buildOpts, func(line string) {
+	err = bo.client.BuildImage(ctx, bo.envID, ctxTar, buildOpts, func(line string) {
 		bo.logger.LogService(serviceName, line)
 	})
 
@@ -231,12 +445,12 @@ func (bo *BuildOrchestrator) buildRemote(serviceInfo compose.ServiceBuildInfo, i
 }
 
 // buildLocal builds the service locally and loads it to the remote engine
-func (bo *BuildOrchestrator) buildLocal(serviceInfo compose.ServiceBuildInfo, imageTag string) BuildResult {
+func (bo *BuildOrchestrator) buildLocal(ctx context.Context, serviceInfo compose.ServiceBuildInfo, imageTag string, extraTags []string) BuildResult {
 	serviceName := serviceInfo.ServiceName
 	bo.logger.LogService(serviceName, "Building locally...")
 
 	// Build locally using docker buildx
-	imageTar, err := bo.buildLocalImage(serviceInfo, imageTag)
+	imageTar, err := bo.buildLocalImage(ctx, serviceInfo, imageTag, extraTags)
 	if err != nil {
 		return BuildResult{
 			ServiceName: serviceName,
@@ -248,7 +462,7 @@ func (bo *BuildOrchestrator) buildLocal(serviceInfo compose.ServiceBuildInfo, im
 
 	// Load image to remote engine
 	bo.logger.LogService(serviceName, "Loading image to remote engine...")
-	err = bo.client.LoadImage(bo.envID, imageTar, func(line string) {
+	err = bo.client.LoadImage(ctx, bo.envID, imageTar, func(line string) {
 		bo.logger.LogService(serviceName, line)
 	})
 
@@ -268,7 +482,7 @@ func (bo *BuildOrchestrator) buildLocal(serviceInfo compose.ServiceBuildInfo, im
 }
 
 // buildLocalImage builds an image locally and returns a tar stream
-func (bo *BuildOrchestrator) buildLocalImage(serviceInfo compose.ServiceBuildInfo, imageTag string) (io.ReadCloser, error) {
+func (bo *BuildOrchestrator) buildLocalImage(ctx context.Context, serviceInfo compose.ServiceBuildInfo, imageTag string, extraTags []string) (io.ReadCloser, error) {
 	// Create pipe for streaming
 	reader, writer := io.Pipe()
 
@@ -288,14 +502,22 @@ func (bo *BuildOrchestrator) buildLocalImage(serviceInfo compose.ServiceBuildInf
 		args = append(args, "--output", "type=docker,dest=-")
 		args = append(args, "--progress", "plain")
 
-		// Add tag
+		// Add tags
 		args = append(args, "-t", imageTag)
+		for _, tag := range extraTags {
+			args = append(args, "-t", tag)
+		}
 
 		// Add no-cache if force build is specified
 		if bo.config.ForceBuild {
 			args = append(args, "--no-cache")
 		}
 
+		// Pull newer versions of the base images if requested
+		if bo.config.Pull {
+			args = append(args, "--pull")
+		}
+
 		// Add build args
 		for key, value := range serviceInfo.Build.Args {
 			args = append(args, "--build-arg", fmt.Sprintf("%s=%s", key, value))
@@ -314,8 +536,8 @@ func (bo *BuildOrchestrator) buildLocalImage(serviceInfo compose.ServiceBuildInf
 		// Add context path
 		args = append(args, serviceInfo.ContextPath)
 
-		// Execute docker buildx build
-		cmd := exec.Command("docker", args...)
+		// Execute docker buildx build, killed when ctx is cancelled
+		cmd := exec.CommandContext(ctx, "docker", args...)
 
 		// Stream tar archive to the pipe via stdout ONLY
 		cmd.Stdout = writer
@@ -343,10 +565,10 @@ func (bo *BuildOrchestrator) buildLocalImage(serviceInfo compose.ServiceBuildInf
 }
 
 // getParallelism determines the number of parallel builds
-func (bo *BuildOrchestrator) getParallelism() int {
+func (bo *BuildOrchestrator) getParallelism(ctx context.Context) int {
 	if bo.config.Parallel == config.BuildParallelAuto {
 		// Try to get remote CPU count
-		info, err := bo.client.GetDockerInfo(bo.envID)
+		info, err := bo.client.GetDockerInfo(ctx, bo.envID)
 		if err != nil {
 			// Fallback to local CPU count
 			return max(1, runtime.NumCPU()-1)
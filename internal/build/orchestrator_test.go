@@ -1,13 +1,24 @@
 package build
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/deviantony/pctl/internal/compose"
 	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockBuildLogger is a mock implementation of BuildLogger
@@ -16,21 +27,30 @@ type MockBuildLogger struct {
 	infoLogs    []string
 	warnLogs    []string
 	errorLogs   []string
+	mu          sync.Mutex
 }
 
 func (m *MockBuildLogger) LogService(serviceName, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.serviceLogs = append(m.serviceLogs, fmt.Sprintf("%s: %s", serviceName, message))
 }
 
 func (m *MockBuildLogger) LogInfo(message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.infoLogs = append(m.infoLogs, message)
 }
 
 func (m *MockBuildLogger) LogWarn(message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.warnLogs = append(m.warnLogs, message)
 }
 
 func (m *MockBuildLogger) LogError(message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.errorLogs = append(m.errorLogs, message)
 }
 
@@ -383,3 +403,272 @@ func TestBuildOrchestrator_BuildServices_ConfigurationValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildOrchestrator_ResolveTags(t *testing.T) {
+	contextDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte("FROM alpine\n"), 0644))
+
+	buildConfig := &config.BuildConfig{
+		Mode:      config.BuildModeRemoteBuild,
+		TagFormat: "pctl-{{stack}}-{{service}}:{{hash}}",
+	}
+	// Resolving tags does not talk to Portainer
+	orchestrator := NewBuildOrchestrator(nil, buildConfig, 1, "myapp", &MockBuildLogger{})
+
+	services := []compose.ServiceBuildInfo{
+		{
+			ServiceName: "web",
+			ContextPath: contextDir,
+			Build:       &compose.BuildDirective{Dockerfile: "Dockerfile"},
+		},
+	}
+
+	tags, err := orchestrator.ResolveTags(services)
+	require.NoError(t, err)
+	require.Contains(t, tags, "web")
+	assert.Regexp(t, `^pctl-myapp-web:[0-9a-f]+$`, tags["web"])
+
+	// Tags are stable for unchanged contexts
+	again, err := orchestrator.ResolveTags(services)
+	require.NoError(t, err)
+	assert.Equal(t, tags, again)
+
+	// A change in the context changes the tag
+	require.NoError(t, os.WriteFile(filepath.Join(contextDir, "app.txt"), []byte("changed"), 0644))
+	changed, err := orchestrator.ResolveTags(services)
+	require.NoError(t, err)
+	assert.NotEqual(t, tags["web"], changed["web"])
+}
+
+func TestBuildOrchestrator_ResolveTags_MissingContext(t *testing.T) {
+	buildConfig := &config.BuildConfig{TagFormat: "pctl-{{stack}}-{{service}}:{{hash}}"}
+	orchestrator := NewBuildOrchestrator(nil, buildConfig, 1, "myapp", &MockBuildLogger{})
+
+	_, err := orchestrator.ResolveTags([]compose.ServiceBuildInfo{
+		{
+			ServiceName: "web",
+			ContextPath: filepath.Join(t.TempDir(), "missing"),
+			Build:       &compose.BuildDirective{Dockerfile: "Dockerfile"},
+		},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to resolve tag for web")
+}
+
+// buildServer fakes the Docker API of Portainer: images exist when exists is set, builds of
+// the images in failures fail, and every build is recorded once finished
+type buildServer struct {
+	mu       sync.Mutex
+	finished []string        // image tags of the finished builds, in order
+	pulls    []string        // pull query of the builds, in order
+	pulled   map[string]bool // repository -> built with pull
+	tagged   []string
+	exists   bool
+	failures map[string]bool // repository -> fail its builds
+	delay    time.Duration
+}
+
+func (s *buildServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/json"):
+		if s.exists {
+			w.Write([]byte(`{"Id": "sha256:1234"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case strings.HasSuffix(r.URL.Path, "/tag"):
+		s.mu.Lock()
+		s.tagged = append(s.tagged, r.URL.Query().Get("repo"))
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasSuffix(r.URL.Path, "/docker/build"):
+		io.Copy(io.Discard, r.Body)
+		tags := r.URL.Query()["t"]
+		repository, _, _ := strings.Cut(tags[0], ":")
+		s.mu.Lock()
+		s.pulls = append(s.pulls, r.URL.Query().Get("pull"))
+		if s.pulled == nil {
+			s.pulled = make(map[string]bool)
+		}
+		s.pulled[repository] = r.URL.Query().Get("pull") == "true"
+		s.mu.Unlock()
+
+		if s.failures[repository] {
+			w.Write([]byte(`{"error": "RUN make failed"}`))
+			return
+		}
+
+		select {
+		case <-time.After(s.delay):
+		case <-r.Context().Done():
+			return
+		}
+		s.mu.Lock()
+		s.finished = append(s.finished, strings.Join(tags, ","))
+		s.mu.Unlock()
+		w.Write([]byte(`{"stream": "Successfully built"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestOrchestrator(t *testing.T, server *buildServer, buildConfig *config.BuildConfig) (*BuildOrchestrator, *MockBuildLogger) {
+	t.Helper()
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	if buildConfig.Mode == "" {
+		buildConfig.Mode = config.BuildModeRemoteBuild
+	}
+	buildConfig.TagFormat = "{{service}}:{{hash}}"
+	logger := &MockBuildLogger{}
+	return NewBuildOrchestrator(portainer.NewClient(httpServer.URL, "token"), buildConfig, 1, "myapp", logger), logger
+}
+
+func TestBuildOrchestrator_BuildServices_DependencyOrder(t *testing.T) {
+	server := &buildServer{delay: 20 * time.Millisecond}
+	orchestrator, _ := newTestOrchestrator(t, server, &config.BuildConfig{Parallel: "4"})
+
+	services := []compose.ServiceBuildInfo{
+		buildService(t, "app", "", "FROM acme/base\n"),
+		buildService(t, "worker", "", "FROM acme/base:latest\n"),
+		buildService(t, "base", "acme/base", "FROM alpine\n"),
+	}
+
+	tags, err := orchestrator.BuildServices(context.Background(), services)
+	require.NoError(t, err)
+	assert.Len(t, tags, 3)
+
+	// The base image is built, and tagged with its compose image name, before the services using it
+	require.Len(t, server.finished, 3)
+	assert.Equal(t, tags["base"]+",acme/base", server.finished[0])
+
+	// The tag of a service changes with the tag of its base image
+	resolved, err := orchestrator.ResolveTags(services)
+	require.NoError(t, err)
+	assert.Equal(t, tags, resolved)
+
+	require.NoError(t, os.WriteFile(filepath.Join(services[2].ContextPath, "base.txt"), []byte("changed"), 0644))
+	changed, err := orchestrator.ResolveTags(services)
+	require.NoError(t, err)
+	assert.NotEqual(t, tags["base"], changed["base"])
+	assert.NotEqual(t, tags["app"], changed["app"])
+}
+
+func TestBuildOrchestrator_BuildServices_AggregatedErrors(t *testing.T) {
+	server := &buildServer{failures: map[string]bool{"api": true, "base": true}}
+	orchestrator, _ := newTestOrchestrator(t, server, &config.BuildConfig{Parallel: "4"})
+
+	services := []compose.ServiceBuildInfo{
+		buildService(t, "api", "", "FROM alpine\n"),
+		buildService(t, "app", "", "FROM acme/base\n"),
+		buildService(t, "base", "acme/base", "FROM alpine\n"),
+		buildService(t, "web", "", "FROM nginx\n"),
+	}
+
+	_, err := orchestrator.BuildServices(context.Background(), services)
+	require.Error(t, err)
+
+	var buildErr *BuildError
+	require.True(t, errors.As(err, &buildErr))
+	require.Len(t, buildErr.Failed, 2)
+	assert.Equal(t, "api", buildErr.Failed[0].ServiceName)
+	assert.Equal(t, "base", buildErr.Failed[1].ServiceName)
+	assert.Equal(t, []string{"app"}, buildErr.NotBuilt)
+
+	assert.Contains(t, err.Error(), "build failed for 2 service(s)")
+	assert.Contains(t, err.Error(), "api: remote build failed: build error: RUN make failed")
+	assert.Contains(t, err.Error(), "base: remote build failed")
+	assert.Contains(t, err.Error(), "not built: app")
+
+	// Without fail_fast, the other builds complete
+	assert.Len(t, server.finished, 1)
+}
+
+func TestBuildOrchestrator_BuildServices_FailFast(t *testing.T) {
+	server := &buildServer{failures: map[string]bool{"api": true}, delay: 5 * time.Second}
+	orchestrator, logger := newTestOrchestrator(t, server, &config.BuildConfig{Parallel: "3", FailFast: true})
+
+	services := []compose.ServiceBuildInfo{
+		buildService(t, "api", "", "FROM alpine\n"),
+		buildService(t, "web", "", "FROM nginx\n"),
+		buildService(t, "worker", "", "FROM alpine\n"),
+	}
+
+	start := time.Now()
+	_, err := orchestrator.BuildServices(context.Background(), services)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second, "remaining builds must be cancelled")
+
+	var buildErr *BuildError
+	require.True(t, errors.As(err, &buildErr))
+	require.Len(t, buildErr.Failed, 1)
+	assert.Equal(t, "api", buildErr.Failed[0].ServiceName)
+	assert.ElementsMatch(t, []string{"web", "worker"}, buildErr.NotBuilt)
+	assert.Empty(t, server.finished)
+	assert.Len(t, logger.errorLogs, 1)
+}
+
+func TestBuildOrchestrator_BuildServices_PullRebuildsExistingImages(t *testing.T) {
+	services := []compose.ServiceBuildInfo{buildService(t, "web", "", "FROM nginx\n")}
+
+	// Up to date images are skipped
+	server := &buildServer{exists: true}
+	orchestrator, _ := newTestOrchestrator(t, server, &config.BuildConfig{Parallel: "1"})
+	_, err := orchestrator.BuildServices(context.Background(), services)
+	require.NoError(t, err)
+	assert.Empty(t, server.pulls)
+
+	// Unless newer base images must be pulled
+	server = &buildServer{exists: true}
+	orchestrator, _ = newTestOrchestrator(t, server, &config.BuildConfig{Parallel: "1", Pull: true})
+	tags, err := orchestrator.BuildServices(context.Background(), services)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"true"}, server.pulls)
+	assert.Equal(t, []string{tags["web"]}, server.finished)
+}
+
+func TestBuildOrchestrator_BuildServices_LoadModeRejectsBaseImages(t *testing.T) {
+	server := &buildServer{}
+	orchestrator, _ := newTestOrchestrator(t, server, &config.BuildConfig{Mode: config.BuildModeLoad, Parallel: "1"})
+
+	services := []compose.ServiceBuildInfo{
+		buildService(t, "app", "", "FROM acme/base\n"),
+		buildService(t, "base", "acme/base", "FROM alpine\n"),
+		buildService(t, "web", "", "FROM nginx\n"),
+	}
+
+	// The local builder could not resolve acme/base, nothing is built
+	_, err := orchestrator.BuildServices(context.Background(), services)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "build mode 'load' cannot build services FROM the image of another service")
+	assert.Contains(t, err.Error(), "app (FROM base)")
+	assert.Empty(t, server.finished)
+	assert.Empty(t, server.pulls)
+
+	_, err = orchestrator.ResolveTags(services)
+	require.Error(t, err)
+
+	// Services without in-stack base images are built in load mode
+	tags, err := orchestrator.ResolveTags(services[1:])
+	require.NoError(t, err)
+	assert.Len(t, tags, 2)
+}
+
+func TestBuildOrchestrator_BuildServices_PullSkipsInStackBaseImages(t *testing.T) {
+	server := &buildServer{exists: true}
+	orchestrator, _ := newTestOrchestrator(t, server, &config.BuildConfig{Parallel: "2", Pull: true})
+
+	services := []compose.ServiceBuildInfo{
+		buildService(t, "app", "", "FROM acme/base\n"),
+		buildService(t, "base", "acme/base", "FROM alpine\n"),
+		buildService(t, "web", "", "FROM nginx\n"),
+	}
+
+	_, err := orchestrator.BuildServices(context.Background(), services)
+	require.NoError(t, err)
+
+	// acme/base is only on the remote engine, app is rebuilt on its new version without pull
+	assert.Equal(t, map[string]bool{"app": false, "base": true, "web": true}, server.pulled)
+}
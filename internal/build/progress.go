@@ -0,0 +1,129 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Progress output formats of the build logs
+const (
+	ProgressAuto  = "auto"  // tty on a terminal, plain otherwise
+	ProgressPlain = "plain" // unstyled lines, for CI logs
+	ProgressTTY   = "tty"   // styled lines
+	ProgressJSON  = "json"  // one JSON object per line, for scripts
+)
+
+// NewProgressLogger returns the build logger of a progress format, auto must be resolved by
+// the caller. Plain and JSON loggers write to out.
+func NewProgressLogger(progress, prefix string, out io.Writer) (BuildLogger, error) {
+	switch progress {
+	case ProgressTTY:
+		return NewStyledBuildLogger(prefix), nil
+	case ProgressPlain:
+		return NewPlainBuildLogger(prefix, out), nil
+	case ProgressJSON:
+		return NewJSONBuildLogger(out), nil
+	default:
+		return nil, fmt.Errorf("invalid progress '%s', must be '%s', '%s', '%s' or '%s'", progress, ProgressAuto, ProgressPlain, ProgressTTY, ProgressJSON)
+	}
+}
+
+// PlainBuildLogger writes unstyled build logs, one line per message
+type PlainBuildLogger struct {
+	prefix string
+	out    io.Writer
+	mu     sync.Mutex
+}
+
+// NewPlainBuildLogger creates a logger writing unstyled lines to out
+func NewPlainBuildLogger(prefix string, out io.Writer) *PlainBuildLogger {
+	return &PlainBuildLogger{prefix: prefix, out: out}
+}
+
+// LogService logs a service-specific message, docker build JSON lines are reduced to their text
+func (l *PlainBuildLogger) LogService(serviceName, message string) {
+	text, kind := parseDockerLine(message)
+	switch kind {
+	case lineEmpty:
+		return
+	case lineError:
+		l.println(fmt.Sprintf("[%s] %s: ERROR: %s", l.prefix, serviceName, text))
+	default:
+		l.println(fmt.Sprintf("[%s] %s: %s", l.prefix, serviceName, text))
+	}
+}
+
+// LogInfo logs an info message
+func (l *PlainBuildLogger) LogInfo(message string) {
+	l.println(fmt.Sprintf("[%s] %s", l.prefix, message))
+}
+
+// LogWarn logs a warning message
+func (l *PlainBuildLogger) LogWarn(message string) {
+	l.println(fmt.Sprintf("[%s] WARN: %s", l.prefix, message))
+}
+
+// LogError logs an error message
+func (l *PlainBuildLogger) LogError(message string) {
+	l.println(fmt.Sprintf("[%s] ERROR: %s", l.prefix, message))
+}
+
+func (l *PlainBuildLogger) println(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(l.out, line)
+}
+
+// JSONBuildEvent is a line of the JSON build logs
+type JSONBuildEvent struct {
+	Level   string `json:"level"`             // info | warn | error
+	Service string `json:"service,omitempty"` // service the message is about, empty for global messages
+	Message string `json:"message"`
+}
+
+// JSONBuildLogger writes build logs as JSON objects, one per line
+type JSONBuildLogger struct {
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewJSONBuildLogger creates a logger writing JSON lines to out
+func NewJSONBuildLogger(out io.Writer) *JSONBuildLogger {
+	return &JSONBuildLogger{encoder: json.NewEncoder(out)}
+}
+
+// LogService logs a service-specific message, docker build JSON lines are reduced to their text
+func (l *JSONBuildLogger) LogService(serviceName, message string) {
+	text, kind := parseDockerLine(message)
+	switch kind {
+	case lineEmpty:
+		return
+	case lineError:
+		l.write(JSONBuildEvent{Level: "error", Service: serviceName, Message: text})
+	default:
+		l.write(JSONBuildEvent{Level: "info", Service: serviceName, Message: text})
+	}
+}
+
+// LogInfo logs an info message
+func (l *JSONBuildLogger) LogInfo(message string) {
+	l.write(JSONBuildEvent{Level: "info", Message: message})
+}
+
+// LogWarn logs a warning message
+func (l *JSONBuildLogger) LogWarn(message string) {
+	l.write(JSONBuildEvent{Level: "warn", Message: message})
+}
+
+// LogError logs an error message
+func (l *JSONBuildLogger) LogError(message string) {
+	l.write(JSONBuildEvent{Level: "error", Message: message})
+}
+
+func (l *JSONBuildLogger) write(event JSONBuildEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_ = l.encoder.Encode(event)
+}
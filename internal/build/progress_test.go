@@ -0,0 +1,84 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProgressLogger(t *testing.T) {
+	var out bytes.Buffer
+
+	logger, err := NewProgressLogger(ProgressTTY, "BUILD", &out)
+	require.NoError(t, err)
+	assert.IsType(t, &StyledBuildLogger{}, logger)
+
+	logger, err = NewProgressLogger(ProgressPlain, "BUILD", &out)
+	require.NoError(t, err)
+	assert.IsType(t, &PlainBuildLogger{}, logger)
+
+	logger, err = NewProgressLogger(ProgressJSON, "BUILD", &out)
+	require.NoError(t, err)
+	assert.IsType(t, &JSONBuildLogger{}, logger)
+
+	// auto is resolved by the caller
+	_, err = NewProgressLogger(ProgressAuto, "BUILD", &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid progress 'auto'")
+}
+
+func TestPlainBuildLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewPlainBuildLogger("BUILD", &out)
+
+	logger.LogInfo("Building 1 service(s) with build directives")
+	logger.LogService("web", `{"stream": "Step 1/2 : FROM alpine\n"}`)
+	logger.LogService("web", `{"stream": "\n"}`)
+	logger.LogService("web", `{"errorDetail": {"message": "COPY failed"}, "error": "COPY failed"}`)
+	logger.LogService("web", "#5 [2/2] RUN make")
+	logger.LogWarn("Build context is large")
+	logger.LogError("✗ Failed to build web")
+
+	assert.Equal(t, []string{
+		"[BUILD] Building 1 service(s) with build directives",
+		"[BUILD] web: Step 1/2 : FROM alpine",
+		"[BUILD] web: ERROR: COPY failed",
+		"[BUILD] web: #5 [2/2] RUN make",
+		"[BUILD] WARN: Build context is large",
+		"[BUILD] ERROR: ✗ Failed to build web",
+	}, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
+
+func TestJSONBuildLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewJSONBuildLogger(&out)
+
+	logger.LogInfo("Using parallelism: 2")
+	logger.LogService("web", `{"stream": "Step 1/2 : FROM alpine\n"}`)
+	logger.LogService("web", `{"stream": "\n"}`)
+	logger.LogService("web", `{"error": "COPY failed"}`)
+	logger.LogService("web", `{"aux": {"ID": "sha256:abc123"}}`)
+	logger.LogWarn("Build context is large")
+	logger.LogError("build failed")
+
+	var events []JSONBuildEvent
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var event JSONBuildEvent
+		require.NoError(t, decoder.Decode(&event))
+		events = append(events, event)
+	}
+
+	assert.Equal(t, []JSONBuildEvent{
+		{Level: "info", Message: "Using parallelism: 2"},
+		{Level: "info", Service: "web", Message: "Step 1/2 : FROM alpine"},
+		{Level: "error", Service: "web", Message: "COPY failed"},
+		{Level: "info", Service: "web", Message: "Built sha256:abc123"},
+		{Level: "warn", Message: "Build context is large"},
+		{Level: "error", Message: "build failed"},
+	}, events)
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -44,6 +45,24 @@ func (tg *TagGenerator) GenerateTagWithTimestamp(serviceName string) string {
 	return tg.GenerateTag(serviceName, timestamp)
 }
 
+// MatchesTag reports whether tag could have been generated for the service with the
+// configured format, whatever its hash or timestamp
+func (tg *TagGenerator) MatchesTag(serviceName, tag string) bool {
+	pattern := regexp.QuoteMeta(tg.TagFormat)
+
+	// QuoteMeta escapes the template braces, so match the escaped variables
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{{stack}}"), regexp.QuoteMeta(tg.StackName))
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{{service}}"), regexp.QuoteMeta(serviceName))
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{{hash}}"), "[0-9a-f]+")
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{{timestamp}}"), "[0-9]+")
+
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(tag)
+}
+
 // ContentHasher handles generation of content hashes for build contexts
 type ContentHasher struct{}
 
@@ -63,8 +82,7 @@ func (ch *ContentHasher) HashBuildContext(contextPath string, dockerfilePath str
 	}
 
 	// Load .dockerignore patterns
-	streamer := NewContextTarStreamer(0)
-	ignorePatterns, err := streamer.loadDockerignore(absContext)
+	filter, err := NewContextFilter(absContext, dockerfilePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to load .dockerignore: %w", err)
 	}
@@ -103,28 +121,7 @@ func (ch *ContentHasher) HashBuildContext(contextPath string, dockerfilePath str
 
 	// Walk the context and hash file paths + contents, respecting .dockerignore
 	var files []string
-	err = filepath.Walk(absContext, func(path string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		if path == absContext {
-			return nil
-		}
-		// Compute slash-normalized relative path
-		rel, err := filepath.Rel(absContext, path)
-		if err != nil {
-			return err
-		}
-		rel = filepath.ToSlash(rel)
-
-		// Respect .dockerignore
-		if streamer.shouldIgnore(rel, ignorePatterns) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
+	err = filter.Walk(absContext, func(_, rel string, info os.FileInfo) error {
 		if info.Mode().IsRegular() {
 			files = append(files, rel)
 		}
@@ -50,6 +50,66 @@ func TestTagGenerator_GenerateTag_TimestampVariable(t *testing.T) {
 	assert.True(t, len(tag) > len("app-service:"))
 }
 
+func TestTagGenerator_MatchesTag(t *testing.T) {
+	tests := []struct {
+		name      string
+		tagFormat string
+		service   string
+		tag       string
+		expected  bool
+	}{
+		{
+			name:      "default format with hash",
+			tagFormat: "pctl-{{stack}}-{{service}}:{{hash}}",
+			service:   "web",
+			tag:       "pctl-my-stack-web:0123456789ab",
+			expected:  true,
+		},
+		{
+			name:      "different service",
+			tagFormat: "pctl-{{stack}}-{{service}}:{{hash}}",
+			service:   "api",
+			tag:       "pctl-my-stack-web:0123456789ab",
+			expected:  false,
+		},
+		{
+			name:      "different stack",
+			tagFormat: "pctl-{{stack}}-{{service}}:{{hash}}",
+			service:   "web",
+			tag:       "pctl-other-stack-web:0123456789ab",
+			expected:  false,
+		},
+		{
+			name:      "timestamp format",
+			tagFormat: "{{stack}}/{{service}}:{{timestamp}}",
+			service:   "web",
+			tag:       "my-stack/web:1700000000",
+			expected:  true,
+		},
+		{
+			name:      "non-numeric timestamp",
+			tagFormat: "{{stack}}/{{service}}:{{timestamp}}",
+			service:   "web",
+			tag:       "my-stack/web:latest",
+			expected:  false,
+		},
+		{
+			name:      "dots in format are literal",
+			tagFormat: "registry.local/{{stack}}-{{service}}:{{hash}}",
+			service:   "web",
+			tag:       "registryXlocal/my-stack-web:abc123",
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tg := NewTagGenerator("my-stack", tt.tagFormat)
+			assert.Equal(t, tt.expected, tg.MatchesTag(tt.service, tt.tag))
+		})
+	}
+}
+
 func TestContentHasher_HashBuildContext(t *testing.T) {
 	// Create a temporary directory structure
 	tempDir := t.TempDir()
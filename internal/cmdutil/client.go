@@ -0,0 +1,92 @@
+// Package cmdutil provides helpers shared by the pctl commands
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+)
+
+// NewClient creates the Portainer client described by a validated configuration. The pctl login
+// session is used when there is one, the API token otherwise. An expired session is renewed
+// interactively; without a terminal, the API token configured next to it is used instead. It fails when the TLS certificate files cannot be loaded or the proxy URL is invalid.
+func NewClient(ctx context.Context, cfg *config.Config) (*portainer.Client, error) {
+	if cfg.Session == nil {
+		return newClient(cfg, cfg.APIToken, "")
+	}
+
+	if cfg.Session.Expired() {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			token, err := cfg.FallbackAPIToken()
+			if err != nil {
+				return nil, err
+			}
+			if token != "" {
+				return newClient(cfg, token, "")
+			}
+		}
+		if err := renewSession(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return newClient(cfg, "", cfg.Session.Token)
+}
+
+// newClient creates a Portainer client authenticated with apiToken or jwt, anonymous when both are empty
+func newClient(cfg *config.Config, apiToken, jwt string) (*portainer.Client, error) {
+	// TLS, timeouts and retry settings are checked by cfg.Validate
+	settings, _ := cfg.GetTLS()
+	timeouts, _ := cfg.GetTimeouts()
+	retry, _ := cfg.GetRetry()
+
+	tlsConfig, err := portainer.NewTLSConfig(portainer.TLSOptions{
+		SkipVerify:  cfg.SkipTLSVerify,
+		CAFile:      settings.CAFile,
+		CertFile:    settings.CertFile,
+		KeyFile:     settings.KeyFile,
+		ServerName:  settings.ServerName,
+		Fingerprint: settings.Fingerprint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid tls configuration: %w", err)
+	}
+
+	proxy, err := portainer.NewProxyFunc(cfg.ProxyURL, cfg.NoProxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy configuration: %w", err)
+	}
+
+	var dial portainer.DialFunc
+	if cfg.SSHJump != "" {
+		dial = portainer.SSHJumpDialer(cfg.SSHJump, "")
+	}
+
+	return portainer.NewClientWithOptions(cfg.PortainerURL, apiToken, portainer.ClientOptions{
+		JWT:          jwt,
+		TLSConfig:    tlsConfig,
+		Proxy:        proxy,
+		DialContext:  dial,
+		APITimeout:   timeouts.API,
+		BuildTimeout: timeouts.Build,
+		LoadTimeout:  timeouts.Load,
+		Retry: portainer.RetryPolicy{
+			MaxAttempts:    retry.MaxAttempts,
+			InitialBackoff: retry.InitialBackoff,
+			MaxBackoff:     retry.MaxBackoff,
+		},
+		OnRetry: reportRetry,
+	}), nil
+}
+
+// reportRetry shows retries under the running spinner so that a flaky connection is visible
+func reportRetry(event portainer.RetryEvent) {
+	spinner.Notify(fmt.Sprintf("⚠ %v, retrying in %s (attempt %d/%d)",
+		event.Err, event.Delay.Round(10*time.Millisecond), event.Attempt+1, event.MaxAttempts))
+}
@@ -0,0 +1,105 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+)
+
+// IsEdgeEnvironment reports whether the configured environment is an Edge environment, whose
+// stacks are deployed as edge stacks because the Docker proxy cannot reach the Edge agents
+func IsEdgeEnvironment(ctx context.Context, client *portainer.Client, cfg *config.Config) (bool, error) {
+	environment, err := client.GetEnvironment(ctx, cfg.EnvironmentID)
+	if err != nil {
+		return false, err
+	}
+	return environment.IsEdge(), nil
+}
+
+// ResolveEdgeGroups returns the edge groups configured in edge.groups, in the configured order
+func ResolveEdgeGroups(ctx context.Context, client *portainer.Client, cfg *config.Config) ([]portainer.EdgeGroup, error) {
+	names, err := cfg.GetEdgeGroups()
+	if err != nil {
+		return nil, fmt.Errorf("invalid edge configuration: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("environment %d is an Edge environment: set edge.groups to the edge groups the stack is deployed to", cfg.EnvironmentID)
+	}
+
+	groups, err := client.GetEdgeGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]portainer.EdgeGroup, len(groups))
+	for _, group := range groups {
+		byName[group.Name] = group
+	}
+
+	var (
+		resolved []portainer.EdgeGroup
+		unknown  []string
+	)
+	for _, name := range names {
+		group, ok := byName[name]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		resolved = append(resolved, group)
+	}
+
+	if len(unknown) > 0 {
+		available := make([]string, 0, len(groups))
+		for _, group := range groups {
+			available = append(available, group.Name)
+		}
+		sort.Strings(available)
+		return nil, fmt.Errorf("edge group(s) not found: %s (available edge groups: %s)", strings.Join(unknown, ", "), strings.Join(available, ", "))
+	}
+
+	return resolved, nil
+}
+
+// EdgeGroupIDs returns the IDs of the edge groups
+func EdgeGroupIDs(groups []portainer.EdgeGroup) []int {
+	ids := make([]int, len(groups))
+	for i, group := range groups {
+		ids[i] = group.ID
+	}
+	return ids
+}
+
+// EdgeStackEnvironments returns the sorted IDs of the environments an edge stack is deployed to:
+// the environments which reported a status, and the members of its static edge groups which
+// did not report yet. Members of dynamic groups are only known once they report.
+func EdgeStackEnvironments(stack *portainer.EdgeStack, groups []portainer.EdgeGroup) []int {
+	seen := make(map[int]bool)
+	for id := range stack.Status {
+		seen[id] = true
+	}
+
+	stackGroups := make(map[int]bool, len(stack.EdgeGroups))
+	for _, id := range stack.EdgeGroups {
+		stackGroups[id] = true
+	}
+	for _, group := range groups {
+		if !stackGroups[group.ID] || group.Dynamic {
+			continue
+		}
+		for _, id := range group.Endpoints {
+			seen[id] = true
+		}
+	}
+
+	ids := make([]int, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,68 @@
+package cmdutil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEdgeGroupsServer(t *testing.T) *portainer.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/edge_groups", r.URL.Path)
+		w.Write([]byte(`[{"Id": 1, "Name": "fleet-eu", "Endpoints": [3, 5]}, {"Id": 2, "Name": "fleet-us", "Endpoints": [7]}, {"Id": 3, "Name": "canary", "Dynamic": true}]`))
+	}))
+	t.Cleanup(server.Close)
+	return portainer.NewClient(server.URL, "test-token")
+}
+
+func TestResolveEdgeGroups(t *testing.T) {
+	client := newEdgeGroupsServer(t)
+	cfg := &config.Config{EnvironmentID: 3, Edge: &config.EdgeConfig{Groups: []string{"fleet-us", "fleet-eu"}}}
+
+	groups, err := ResolveEdgeGroups(context.Background(), client, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, EdgeGroupIDs(groups))
+}
+
+func TestResolveEdgeGroups_Unknown(t *testing.T) {
+	client := newEdgeGroupsServer(t)
+	cfg := &config.Config{EnvironmentID: 3, Edge: &config.EdgeConfig{Groups: []string{"fleet-eu", "fleet-asia"}}}
+
+	_, err := ResolveEdgeGroups(context.Background(), client, cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "edge group(s) not found: fleet-asia")
+	assert.Contains(t, err.Error(), "available edge groups: canary, fleet-eu, fleet-us")
+}
+
+func TestResolveEdgeGroups_NotConfigured(t *testing.T) {
+	cfg := &config.Config{EnvironmentID: 3}
+
+	// The groups are not listed when none are configured
+	_, err := ResolveEdgeGroups(context.Background(), portainer.NewClient("http://127.0.0.1:0", "test-token"), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "set edge.groups")
+}
+
+func TestEdgeStackEnvironments(t *testing.T) {
+	groups := []portainer.EdgeGroup{
+		{ID: 1, Name: "fleet-eu", Endpoints: []int{3, 5}},
+		{ID: 2, Name: "fleet-us", Endpoints: []int{7}},
+		{ID: 3, Name: "canary", Dynamic: true},
+	}
+	stack := &portainer.EdgeStack{
+		EdgeGroups: []int{1, 3},
+		Status: map[int]portainer.EdgeStackStatus{
+			5:  {EnvironmentID: 5},
+			11: {EnvironmentID: 11}, // member of the dynamic group
+		},
+	}
+
+	assert.Equal(t, []int{3, 5, 11}, EdgeStackEnvironments(stack, groups))
+}
@@ -0,0 +1,13 @@
+package cmdutil
+
+import "fmt"
+
+// ExitError makes pctl exit with the given code, without printing an error. Commands return
+// it instead of calling os.Exit so that their deferred cleanup runs.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
@@ -0,0 +1,19 @@
+package cmdutil
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitError(t *testing.T) {
+	err := fmt.Errorf("exec failed: %w", &ExitError{Code: 3})
+
+	var exitErr *ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 3, exitErr.Code)
+	assert.Equal(t, "exec failed: exit status 3", err.Error())
+}
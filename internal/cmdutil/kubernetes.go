@@ -0,0 +1,34 @@
+package cmdutil
+
+import (
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+)
+
+// CheckEnvironmentKind verifies that the kind setting matches the environment: Kubernetes
+// environments are only reached through the Kubernetes proxy, Docker ones through the Docker proxy
+func CheckEnvironmentKind(cfg *config.Config, environment *portainer.Environment) error {
+	switch {
+	case cfg.IsKubernetes() && environment.IsEdge():
+		return fmt.Errorf("environment %d (%s) is an Edge environment, kind: kubernetes is not supported for edge stacks", environment.ID, environment.Name)
+	case cfg.IsKubernetes() && !environment.IsKubernetes():
+		return fmt.Errorf("environment %d (%s) is not a Kubernetes environment, remove kind: kubernetes", environment.ID, environment.Name)
+	case !cfg.IsKubernetes() && environment.IsKubernetes() && !environment.IsEdge():
+		return fmt.Errorf("environment %d (%s) is a Kubernetes environment, set kind: kubernetes", environment.ID, environment.Name)
+	}
+	return nil
+}
+
+// StackNamespace returns the namespace of a Kubernetes stack, the configured one when Portainer
+// did not report it
+func StackNamespace(cfg *config.Config, namespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	if kubernetes, err := cfg.GetKubernetes(); err == nil {
+		return kubernetes.Namespace
+	}
+	return config.DefaultKubernetesNamespace
+}
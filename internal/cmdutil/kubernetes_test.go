@@ -0,0 +1,48 @@
+package cmdutil
+
+import (
+	"testing"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEnvironmentKind(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		envType int
+		wantErr string
+	}{
+		{name: "docker", kind: "", envType: portainer.EnvironmentTypeAgentOnDocker},
+		{name: "kubernetes", kind: config.KindKubernetes, envType: portainer.EnvironmentTypeAgentOnKubernetes},
+		{name: "edge docker", kind: config.KindDocker, envType: portainer.EnvironmentTypeEdgeAgentOnDocker},
+		{name: "edge kubernetes without kind", kind: "", envType: portainer.EnvironmentTypeEdgeAgentOnKubernetes},
+		{name: "kind missing", kind: "", envType: portainer.EnvironmentTypeKubernetesLocal, wantErr: "set kind: kubernetes"},
+		{name: "not kubernetes", kind: config.KindKubernetes, envType: portainer.EnvironmentTypeDocker, wantErr: "remove kind: kubernetes"},
+		{name: "kubernetes edge", kind: config.KindKubernetes, envType: portainer.EnvironmentTypeEdgeAgentOnKubernetes, wantErr: "not supported for edge stacks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Kind: tt.kind}
+			err := CheckEnvironmentKind(cfg, &portainer.Environment{ID: 5, Name: "prod", Type: tt.envType})
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "environment 5 (prod)")
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestStackNamespace(t *testing.T) {
+	cfg := &config.Config{Kind: config.KindKubernetes, Kubernetes: &config.KubernetesConfig{Namespace: "shop"}}
+	assert.Equal(t, "shop-legacy", StackNamespace(cfg, "shop-legacy"))
+	assert.Equal(t, "shop", StackNamespace(cfg, ""))
+	assert.Equal(t, config.DefaultKubernetesNamespace, StackNamespace(&config.Config{}, ""))
+}
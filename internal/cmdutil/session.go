@@ -0,0 +1,154 @@
+package cmdutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/credentials"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/spinner"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
+)
+
+// LoginConfig returns the connection settings used by pctl login and logout: the Portainer URL
+// given on the command line or portainer_url of pctl.yml. The TLS and proxy settings of pctl.yml
+// are kept when it describes the same instance.
+func LoginConfig(args []string) (*config.Config, error) {
+	var cfg *config.Config
+	if _, err := os.Stat(config.ConfigFileName); err == nil {
+		cfg, err = config.LoadWithoutCredentials()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) == 0 {
+		if cfg == nil || cfg.PortainerURL == "" {
+			return nil, fmt.Errorf("no Portainer URL: run the command next to pctl.yml or give the URL of the instance")
+		}
+		return cfg, nil
+	}
+
+	portainerURL := args[0]
+	if err := portainer.ValidateURL(portainerURL); err != nil {
+		return nil, err
+	}
+	if cfg == nil || strings.TrimRight(cfg.PortainerURL, "/") != strings.TrimRight(portainerURL, "/") {
+		return &config.Config{PortainerURL: portainerURL}, nil
+	}
+	return cfg, nil
+}
+
+// Login authenticates against the Portainer instance of the configuration with a username and
+// password, and stores the session in the credential store of the user. The configuration then
+// uses the session.
+func Login(ctx context.Context, cfg *config.Config, username, password string) (*credentials.Session, error) {
+	client, err := newClient(cfg, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := client.Authenticate(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// A token without a readable expiry is used until Portainer rejects it
+	expiresAt, _ := portainer.TokenExpiry(token)
+
+	session := credentials.Session{
+		PortainerURL: cfg.PortainerURL,
+		Username:     username,
+		Token:        token,
+		ExpiresAt:    expiresAt,
+	}
+	err = updateCredentials(func(store *credentials.Store) bool {
+		store.Set(session)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Session = &session
+	return &session, nil
+}
+
+// Logout removes the session of a Portainer instance from the credential store of the user,
+// and reports whether there was one
+func Logout(portainerURL string) (bool, error) {
+	var removed bool
+	err := updateCredentials(func(store *credentials.Store) bool {
+		removed = store.Remove(portainerURL)
+		return removed
+	})
+	return removed, err
+}
+
+// renewSession asks for the password of an expired session and logs in again. Without a
+// terminal to prompt on, e.g. in CI, the user is told to run pctl login.
+func renewSession(ctx context.Context, cfg *config.Config) error {
+	session := cfg.Session
+	expired := fmt.Errorf("the Portainer session of %s expired on %s, run 'pctl login' to log in again",
+		session.Username, session.ExpiresAt.Local().Format(time.DateTime))
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return expired
+	}
+
+	var password string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Session Expired").
+				Description(fmt.Sprintf("Enter the password of %s to log in to %s again", session.Username, cfg.PortainerURL)).
+				EchoMode(huh.EchoModePassword).
+				Value(&password).
+				Validate(func(str string) error {
+					if str == "" {
+						return fmt.Errorf("password is required")
+					}
+					return nil
+				}),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return expired
+	}
+
+	err := spinner.RunWithSpinner("Logging in...", func() error {
+		_, loginErr := Login(ctx, cfg, session.Username, password)
+		return loginErr
+	})
+	if err != nil {
+		if portainer.IsInvalidCredentials(err) {
+			return fmt.Errorf("failed to log in again: invalid username or password")
+		}
+		return fmt.Errorf("failed to log in again: %w", err)
+	}
+	return nil
+}
+
+// updateCredentials applies update to the credential store of the user and saves it when
+// update reports a change
+func updateCredentials(update func(*credentials.Store) bool) error {
+	dir, err := credentials.DefaultDir()
+	if err != nil {
+		return err
+	}
+
+	store, err := credentials.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	if !update(store) {
+		return nil
+	}
+	return store.Save(dir)
+}
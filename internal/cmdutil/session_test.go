@@ -0,0 +1,167 @@
+package cmdutil
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/credentials"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth":
+			fmt.Fprintf(w, `{"jwt": %q}`, token)
+		case "/api/endpoints":
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode([]portainer.Environment{{ID: 1, Name: "local"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLoginAndLogout(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	encode := base64.RawURLEncoding.EncodeToString
+	token := encode([]byte(`{"alg":"HS256"}`)) + "." + encode([]byte(`{"exp":4102444800}`)) + ".signature"
+	server := newAuthServer(t, token)
+
+	cfg := &config.Config{PortainerURL: server.URL}
+	session, err := Login(context.Background(), cfg, "admin", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", session.Username)
+	assert.Equal(t, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), session.ExpiresAt.UTC())
+	assert.Equal(t, session, cfg.Session)
+
+	store, err := credentials.Load(filepath.Join(dir, "pctl"))
+	require.NoError(t, err)
+	stored, ok := store.Get(server.URL)
+	require.True(t, ok)
+	assert.Equal(t, token, stored.Token)
+
+	// The client uses the session
+	client, err := NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+	_, err = client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+
+	removed, err := Logout(server.URL)
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = Logout(server.URL)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestNewClient_ExpiredSession(t *testing.T) {
+	cfg := &config.Config{
+		PortainerURL: "https://portainer.example.com",
+		Session: &credentials.Session{
+			PortainerURL: "https://portainer.example.com",
+			Username:     "admin",
+			Token:        "expired",
+			ExpiresAt:    time.Now().Add(-time.Hour),
+		},
+	}
+
+	if term.IsTerminal(os.Stdin.Fd()) {
+		t.Skip("the expired session would be renewed interactively")
+	}
+
+	// Without a terminal, the user is told to log in again
+	_, err := NewClient(context.Background(), cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "session of admin expired")
+	assert.Contains(t, err.Error(), "pctl login")
+}
+
+func TestNewClient_ExpiredSessionFallsBackToAPIToken(t *testing.T) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		t.Skip("the expired session would be renewed interactively")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "ptr_from_file" || r.Header.Get("Authorization") != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode([]portainer.Environment{{ID: 1, Name: "local"}})
+	}))
+	t.Cleanup(server.Close)
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("ptr_from_file\n"), 0600))
+
+	cfg := &config.Config{
+		PortainerURL: server.URL,
+		APITokenFile: tokenPath,
+		Session: &credentials.Session{
+			PortainerURL: server.URL,
+			Username:     "admin",
+			Token:        "expired",
+			ExpiresAt:    time.Now().Add(-time.Hour),
+		},
+	}
+
+	// Without a terminal, the API token is used instead of asking to log in again
+	client, err := NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+	_, err = client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+}
+
+func TestLoginConfig(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	_, err := LoginConfig(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Portainer URL")
+
+	cfg, err := LoginConfig([]string{"https://portainer.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://portainer.example.com", cfg.PortainerURL)
+
+	_, err = LoginConfig([]string{"portainer.example.com"})
+	require.Error(t, err)
+
+	content := "portainer_url: https://portainer.example.com\nproxy_url: http://proxy.example.com:3128\napi_token_file: missing\n"
+	require.NoError(t, os.WriteFile(config.ConfigFileName, []byte(content), 0644))
+
+	// The settings of pctl.yml are used for its instance, without reading the token
+	cfg, err = LoginConfig(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.example.com:3128", cfg.ProxyURL)
+
+	cfg, err = LoginConfig([]string{"https://portainer.example.com/"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://proxy.example.com:3128", cfg.ProxyURL)
+
+	cfg, err = LoginConfig([]string{"https://portainer.prod.example.com"})
+	require.NoError(t, err)
+	assert.Empty(t, cfg.ProxyURL)
+}
@@ -0,0 +1,90 @@
+package cmdutil
+
+import (
+	"context"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/state"
+)
+
+// FindStack looks up the configured stack, nil when it does not exist. The stack ID is
+// cached in the local state, so that the stack is fetched directly instead of listing
+// every stack of the environment. A cached ID is only used while it designates the same stack.
+func FindStack(ctx context.Context, client *portainer.Client, cfg *config.Config) (*portainer.Stack, error) {
+	return findStack(ctx, client, cfg, state.DirName)
+}
+
+// RememberStack caches the ID of the configured stack, e.g. once it is created
+func RememberStack(cfg *config.Config, stackID int) {
+	updateState(state.DirName, func(s *state.State) bool {
+		s.SetStackID(cfg.PortainerURL, cfg.EnvironmentID, cfg.StackName, stackID)
+		return true
+	})
+}
+
+// ForgetStack removes the cached ID of the configured stack, e.g. once it is removed
+func ForgetStack(cfg *config.Config) {
+	updateState(state.DirName, forget(cfg))
+}
+
+func findStack(ctx context.Context, client *portainer.Client, cfg *config.Config, dir string) (*portainer.Stack, error) {
+	// The state is only a cache, the stack is listed when it cannot be read
+	local, err := state.Load(dir)
+	if err != nil {
+		local = &state.State{}
+	}
+
+	id, cached := local.StackID(cfg.PortainerURL, cfg.EnvironmentID, cfg.StackName)
+	if cached {
+		details, err := client.GetStackDetails(ctx, id)
+		switch {
+		case err == nil && details.Name == cfg.StackName && details.EnvironmentID == cfg.EnvironmentID:
+			return details.Stack(), nil
+		case err != nil && !portainer.IsNotFound(err):
+			return nil, err
+		}
+		// The stack was removed or its ID reused outside of pctl, look it up again
+	}
+
+	stack, err := client.GetStack(ctx, cfg.StackName, cfg.EnvironmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case stack != nil && (!cached || stack.ID != id):
+		updateState(dir, func(s *state.State) bool {
+			s.SetStackID(cfg.PortainerURL, cfg.EnvironmentID, cfg.StackName, stack.ID)
+			return true
+		})
+	case stack == nil && cached:
+		updateState(dir, forget(cfg))
+	}
+
+	return stack, nil
+}
+
+// forget returns a state update removing the cached ID of the configured stack
+func forget(cfg *config.Config) func(*state.State) bool {
+	return func(s *state.State) bool {
+		if _, ok := s.StackID(cfg.PortainerURL, cfg.EnvironmentID, cfg.StackName); !ok {
+			return false
+		}
+		s.ForgetStack(cfg.PortainerURL, cfg.EnvironmentID, cfg.StackName)
+		return true
+	}
+}
+
+// updateState applies update to the local state and saves it when update reports a change.
+// Failures are ignored: the state is a cache and pctl works without it, e.g. in a read-only
+// directory.
+func updateState(dir string, update func(*state.State) bool) {
+	local, err := state.Load(dir)
+	if err != nil {
+		local = &state.State{}
+	}
+	if update(local) {
+		_ = local.Save(dir)
+	}
+}
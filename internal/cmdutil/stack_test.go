@@ -0,0 +1,153 @@
+package cmdutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/deviantony/pctl/internal/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePortainer serves the stacks endpoints and counts the stack listings
+type fakePortainer struct {
+	stacks   []portainer.Stack
+	listings atomic.Int32
+}
+
+func (f *fakePortainer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/api/stacks" {
+		f.listings.Add(1)
+		json.NewEncoder(w).Encode(f.stacks)
+		return
+	}
+	for _, stack := range f.stacks {
+		if r.URL.Path == fmt.Sprintf("/api/stacks/%d", stack.ID) {
+			json.NewEncoder(w).Encode(portainer.StackDetails{ID: stack.ID, Name: stack.Name, EnvironmentID: stack.EnvironmentID, Status: stack.Status})
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"message": "Unable to find a stack with the specified identifier inside the database"}`))
+}
+
+func newStackTest(t *testing.T, stacks ...portainer.Stack) (*fakePortainer, *portainer.Client, *config.Config, string) {
+	t.Helper()
+	fake := &fakePortainer{stacks: stacks}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{PortainerURL: server.URL, EnvironmentID: 1, StackName: "myapp"}
+	return fake, portainer.NewClient(server.URL, "test-token"), cfg, filepath.Join(t.TempDir(), state.DirName)
+}
+
+func TestFindStack_CachesStackID(t *testing.T) {
+	fake, client, cfg, dir := newStackTest(t, portainer.Stack{ID: 5, Name: "myapp", EnvironmentID: 1, Status: 1})
+
+	stack, err := findStack(context.Background(), client, cfg, dir)
+	require.NoError(t, err)
+	require.NotNil(t, stack)
+	assert.Equal(t, 5, stack.ID)
+	assert.Equal(t, int32(1), fake.listings.Load())
+
+	local, err := state.Load(dir)
+	require.NoError(t, err)
+	id, ok := local.StackID(cfg.PortainerURL, 1, "myapp")
+	assert.True(t, ok)
+	assert.Equal(t, 5, id)
+
+	// The cached ID is revalidated without listing the stacks
+	stack, err = findStack(context.Background(), client, cfg, dir)
+	require.NoError(t, err)
+	require.NotNil(t, stack)
+	assert.Equal(t, 5, stack.ID)
+	assert.Equal(t, "myapp", stack.Name)
+	assert.Equal(t, int32(1), fake.listings.Load())
+}
+
+func TestFindStack_StaleCache(t *testing.T) {
+	tests := []struct {
+		name   string
+		stacks []portainer.Stack
+	}{
+		{
+			name:   "cached stack removed",
+			stacks: []portainer.Stack{{ID: 8, Name: "myapp", EnvironmentID: 1}},
+		},
+		{
+			name:   "cached ID reused by another stack",
+			stacks: []portainer.Stack{{ID: 5, Name: "other", EnvironmentID: 1}, {ID: 8, Name: "myapp", EnvironmentID: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, client, cfg, dir := newStackTest(t, tt.stacks...)
+
+			local := &state.State{}
+			local.SetStackID(cfg.PortainerURL, 1, "myapp", 5)
+			require.NoError(t, local.Save(dir))
+
+			stack, err := findStack(context.Background(), client, cfg, dir)
+			require.NoError(t, err)
+			require.NotNil(t, stack)
+			assert.Equal(t, 8, stack.ID)
+			assert.Equal(t, int32(1), fake.listings.Load())
+
+			local, err = state.Load(dir)
+			require.NoError(t, err)
+			id, _ := local.StackID(cfg.PortainerURL, 1, "myapp")
+			assert.Equal(t, 8, id)
+		})
+	}
+}
+
+func TestFindStack_NotFound(t *testing.T) {
+	_, client, cfg, dir := newStackTest(t, portainer.Stack{ID: 3, Name: "other", EnvironmentID: 1})
+
+	stack, err := findStack(context.Background(), client, cfg, dir)
+	require.NoError(t, err)
+	assert.Nil(t, stack)
+
+	// Nothing to remember, the state directory is not created
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFindStack_RemovedStackIsForgotten(t *testing.T) {
+	_, client, cfg, dir := newStackTest(t)
+
+	local := &state.State{}
+	local.SetStackID(cfg.PortainerURL, 1, "myapp", 5)
+	require.NoError(t, local.Save(dir))
+
+	stack, err := findStack(context.Background(), client, cfg, dir)
+	require.NoError(t, err)
+	assert.Nil(t, stack)
+
+	local, err = state.Load(dir)
+	require.NoError(t, err)
+	_, ok := local.StackID(cfg.PortainerURL, 1, "myapp")
+	assert.False(t, ok)
+}
+
+func TestFindStack_InvalidStateIgnored(t *testing.T) {
+	_, client, cfg, dir := newStackTest(t, portainer.Stack{ID: 5, Name: "myapp", EnvironmentID: 1})
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, state.FileName), []byte("{not json"), 0644))
+
+	stack, err := findStack(context.Background(), client, cfg, dir)
+	require.NoError(t, err)
+	require.NotNil(t, stack)
+	assert.Equal(t, 5, stack.ID)
+}
@@ -0,0 +1,239 @@
+package compose
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind describes how an item changed between two compose files
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// FieldChange is a change of a service field. For list and map fields such as ports or
+// environment, Key identifies the entry that changed.
+type FieldChange struct {
+	Field string
+	Kind  ChangeKind
+	Key   string
+	Old   string
+	New   string
+}
+
+// ServiceDiff lists the changes of a single service
+type ServiceDiff struct {
+	Name    string
+	Kind    ChangeKind
+	Changes []FieldChange
+}
+
+// ComposeDiff is a semantic diff between a deployed and a local compose file
+type ComposeDiff struct {
+	Services []ServiceDiff
+	Volumes  []FieldChange // top-level named volumes
+	Networks []FieldChange // top-level networks
+}
+
+// HasChanges reports whether the compose files differ semantically
+func (d *ComposeDiff) HasChanges() bool {
+	return len(d.Services) > 0 || len(d.Volumes) > 0 || len(d.Networks) > 0
+}
+
+// DiffComposeFiles compares the deployed compose content with the local one, service by service.
+// An empty deployed content means the stack does not exist yet.
+func DiffComposeFiles(deployedContent, localContent string) (*ComposeDiff, error) {
+	deployed, err := ParseComposeFile(deployedContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse deployed compose file: %w", err)
+	}
+
+	local, err := ParseComposeFile(localContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse local compose file: %w", err)
+	}
+
+	diff := &ComposeDiff{}
+
+	for _, name := range unionKeys(deployed.Services, local.Services) {
+		oldService, inDeployed := deployed.Services[name]
+		newService, inLocal := local.Services[name]
+
+		switch {
+		case !inDeployed:
+			diff.Services = append(diff.Services, ServiceDiff{Name: name, Kind: ChangeAdded})
+		case !inLocal:
+			diff.Services = append(diff.Services, ServiceDiff{Name: name, Kind: ChangeRemoved})
+		default:
+			changes := diffService(asMap(oldService), asMap(newService))
+			if len(changes) > 0 {
+				diff.Services = append(diff.Services, ServiceDiff{Name: name, Kind: ChangeModified, Changes: changes})
+			}
+		}
+	}
+
+	diff.Volumes = diffEntries("volumes", normalizeMapEntries(deployed.Volumes), normalizeMapEntries(local.Volumes))
+	diff.Networks = diffEntries("networks", normalizeMapEntries(deployed.Networks), normalizeMapEntries(local.Networks))
+
+	return diff, nil
+}
+
+// diffService compares the fields of a service. Image, environment, ports, volumes and
+// networks are compared entry by entry, other fields are compared as a whole.
+func diffService(oldService, newService map[string]interface{}) []FieldChange {
+	var changes []FieldChange
+
+	for _, field := range unionKeys(oldService, newService) {
+		oldValue, inOld := oldService[field]
+		newValue, inNew := newService[field]
+
+		switch field {
+		case "environment":
+			changes = append(changes, diffEntries(field, normalizeEnvironment(oldValue), normalizeEnvironment(newValue))...)
+		case "ports", "volumes":
+			changes = append(changes, diffEntries(field, normalizeList(oldValue), normalizeList(newValue))...)
+		case "networks":
+			changes = append(changes, diffEntries(field, normalizeNetworks(oldValue), normalizeNetworks(newValue))...)
+		default:
+			oldText, newText := formatValue(oldValue), formatValue(newValue)
+			switch {
+			case !inOld:
+				changes = append(changes, FieldChange{Field: field, Kind: ChangeAdded, New: newText})
+			case !inNew:
+				changes = append(changes, FieldChange{Field: field, Kind: ChangeRemoved, Old: oldText})
+			case oldText != newText:
+				changes = append(changes, FieldChange{Field: field, Kind: ChangeModified, Old: oldText, New: newText})
+			}
+		}
+	}
+
+	return changes
+}
+
+// diffEntries compares two sets of keyed entries
+func diffEntries(field string, oldEntries, newEntries map[string]string) []FieldChange {
+	var changes []FieldChange
+	for _, key := range unionKeys(oldEntries, newEntries) {
+		oldValue, inOld := oldEntries[key]
+		newValue, inNew := newEntries[key]
+
+		switch {
+		case !inOld:
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeAdded, Key: key, New: newValue})
+		case !inNew:
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeRemoved, Key: key, Old: oldValue})
+		case oldValue != newValue:
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeModified, Key: key, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
+
+// normalizeEnvironment converts the map and list forms of environment to a map
+func normalizeEnvironment(value interface{}) map[string]string {
+	env := make(map[string]string)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if val == nil {
+				env[key] = ""
+			} else {
+				env[key] = fmt.Sprintf("%v", val)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			key, val, _ := strings.Cut(fmt.Sprintf("%v", item), "=")
+			env[key] = val
+		}
+	}
+	return env
+}
+
+// normalizeList converts a list of short or long syntax entries (ports, volumes) to a set
+func normalizeList(value interface{}) map[string]string {
+	entries := make(map[string]string)
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			text := formatValue(item)
+			entries[text] = text
+		}
+	}
+	return entries
+}
+
+// normalizeNetworks converts the list and map forms of service networks to a map
+func normalizeNetworks(value interface{}) map[string]string {
+	networks := make(map[string]string)
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			networks[fmt.Sprintf("%v", item)] = ""
+		}
+	case map[string]interface{}:
+		for name, config := range v {
+			networks[name] = formatValue(config)
+		}
+	}
+	return networks
+}
+
+// normalizeMapEntries converts a top-level volumes or networks section to a map
+func normalizeMapEntries(section map[string]interface{}) map[string]string {
+	entries := make(map[string]string, len(section))
+	for name, config := range section {
+		entries[name] = formatValue(config)
+	}
+	return entries
+}
+
+// formatValue returns a stable text representation of a compose value
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		// encoding/json sorts map keys, which makes the output stable
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+// unionKeys returns the sorted keys present in either map
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for key := range a {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for key := range b {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffComposeFiles(t *testing.T) {
+	deployed := `
+services:
+  web:
+    image: myapp-web:abc123
+    ports:
+      - "3000:3000"
+    environment:
+      LOG_LEVEL: info
+      DEBUG: "false"
+    networks:
+      - front
+  worker:
+    image: myapp-worker:1
+volumes:
+  data: {}
+`
+	local := `
+services:
+  web:
+    image: myapp-web:def456
+    ports:
+      - "3000:3000"
+      - "9090:9090"
+    environment:
+      - LOG_LEVEL=debug
+      - NEW_VAR=1
+    networks:
+      - front
+      - back
+  cache:
+    image: redis:7
+volumes:
+  data: {}
+  cache: {}
+`
+
+	diff, err := DiffComposeFiles(deployed, local)
+	require.NoError(t, err)
+	assert.True(t, diff.HasChanges())
+
+	require.Len(t, diff.Services, 3)
+	assert.Equal(t, ServiceDiff{Name: "cache", Kind: ChangeAdded}, diff.Services[0])
+	assert.Equal(t, ServiceDiff{Name: "worker", Kind: ChangeRemoved}, diff.Services[2])
+
+	web := diff.Services[1]
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, ChangeModified, web.Kind)
+	assert.Equal(t, []FieldChange{
+		{Field: "environment", Kind: ChangeRemoved, Key: "DEBUG", Old: "false"},
+		{Field: "environment", Kind: ChangeModified, Key: "LOG_LEVEL", Old: "info", New: "debug"},
+		{Field: "environment", Kind: ChangeAdded, Key: "NEW_VAR", New: "1"},
+		{Field: "image", Kind: ChangeModified, Old: "myapp-web:abc123", New: "myapp-web:def456"},
+		{Field: "networks", Kind: ChangeAdded, Key: "back"},
+		{Field: "ports", Kind: ChangeAdded, Key: "9090:9090", New: "9090:9090"},
+	}, web.Changes)
+
+	assert.Equal(t, []FieldChange{{Field: "volumes", Kind: ChangeAdded, Key: "cache", New: "{}"}}, diff.Volumes)
+	assert.Empty(t, diff.Networks)
+}
+
+func TestDiffComposeFiles_NoChanges(t *testing.T) {
+	content := `
+services:
+  web:
+    image: nginx:latest
+    environment:
+      A: "1"
+`
+	// Same content in list form must be considered equal
+	listForm := `
+services:
+  web:
+    image: nginx:latest
+    environment:
+      - A=1
+`
+
+	diff, err := DiffComposeFiles(content, listForm)
+	require.NoError(t, err)
+	assert.False(t, diff.HasChanges())
+}
+
+func TestDiffComposeFiles_NewStack(t *testing.T) {
+	local := `
+services:
+  web:
+    image: nginx:latest
+`
+
+	diff, err := DiffComposeFiles("", local)
+	require.NoError(t, err)
+	require.Len(t, diff.Services, 1)
+	assert.Equal(t, ChangeAdded, diff.Services[0].Kind)
+}
+
+func TestDiffComposeFiles_InvalidYAML(t *testing.T) {
+	_, err := DiffComposeFiles("services: [", "services: {}")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "deployed compose file")
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	oldContent := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	newContent := "a\nb\nc\nD\ne\nf\ng\nh\n"
+
+	diff := UnifiedDiff(oldContent, newContent, "deployed", "local")
+	expected := "--- deployed\n+++ local\n" +
+		"@@ -1,7 +1,7 @@\n" +
+		" a\n b\n c\n-d\n+D\n e\n f\n g\n"
+	assert.Equal(t, expected, diff)
+}
+
+func TestUnifiedDiff_SeparateHunks(t *testing.T) {
+	oldContent := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	newContent := "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n"
+
+	diff := UnifiedDiff(oldContent, newContent, "a", "b")
+	assert.Contains(t, diff, "@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n")
+	assert.Contains(t, diff, "@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n")
+}
+
+func TestUnifiedDiff_Identical(t *testing.T) {
+	assert.Empty(t, UnifiedDiff("a\nb\n", "a\nb\n", "a", "b"))
+}
+
+func TestUnifiedDiff_FromEmpty(t *testing.T) {
+	diff := UnifiedDiff("", "a\nb\n", "deployed", "local")
+	assert.Equal(t, "--- deployed\n+++ local\n@@ -0,0 +1,2 @@\n+a\n+b\n", diff)
+}
@@ -22,6 +22,7 @@ type ServiceBuildInfo struct {
 	ServiceName string
 	Build       *BuildDirective
 	ContextPath string // Resolved absolute path to build context
+	Image       string // image name of the service, empty when the compose file does not set one
 }
 
 // ComposeFile represents a parsed compose file
@@ -75,6 +76,9 @@ func extractBuildInfo(serviceName string, serviceData interface{}) (*ServiceBuil
 	buildInfo := &ServiceBuildInfo{
 		ServiceName: serviceName,
 	}
+	if image, ok := serviceMap["image"].(string); ok {
+		buildInfo.Image = image
+	}
 
 	// Handle different build directive formats
 	switch build := buildData.(type) {
@@ -141,6 +145,39 @@ func extractBuildInfo(serviceName string, serviceData interface{}) (*ServiceBuil
 	return buildInfo, nil
 }
 
+// FindServicesToBuild returns the services with build directives among the given service
+// names, all of them when no name is given
+func (cf *ComposeFile) FindServicesToBuild(names []string) ([]ServiceBuildInfo, error) {
+	if len(names) == 0 {
+		return cf.FindServicesWithBuild()
+	}
+
+	var servicesToBuild []ServiceBuildInfo
+	selected := make(map[string]bool)
+	for _, name := range names {
+		if selected[name] {
+			continue
+		}
+		selected[name] = true
+
+		serviceData, exists := cf.Services[name]
+		if !exists {
+			return nil, fmt.Errorf("service '%s' not found in the compose file", name)
+		}
+
+		buildInfo, err := extractBuildInfo(name, serviceData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract build info for service '%s': %w", name, err)
+		}
+		if buildInfo == nil {
+			return nil, fmt.Errorf("service '%s' has no build directive", name)
+		}
+		servicesToBuild = append(servicesToBuild, *buildInfo)
+	}
+
+	return servicesToBuild, nil
+}
+
 // HasBuildDirectives checks if the compose file has any services with build directives
 func (cf *ComposeFile) HasBuildDirectives() (bool, error) {
 	servicesWithBuild, err := cf.FindServicesWithBuild()
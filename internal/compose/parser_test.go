@@ -116,6 +116,39 @@ services:
 	assert.Empty(t, servicesWithBuild)
 }
 
+func TestComposeFile_FindServicesToBuild(t *testing.T) {
+	composeContent := `
+services:
+  web:
+    build: ./web
+  api:
+    build:
+      context: ./api
+  db:
+    image: postgres:13
+`
+
+	compose, err := ParseComposeFile(composeContent)
+	require.NoError(t, err)
+
+	all, err := compose.FindServicesToBuild(nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	selected, err := compose.FindServicesToBuild([]string{"api", "api"})
+	require.NoError(t, err)
+	require.Len(t, selected, 1)
+	assert.Equal(t, "api", selected[0].ServiceName)
+
+	_, err = compose.FindServicesToBuild([]string{"web", "db"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'db' has no build directive")
+
+	_, err = compose.FindServicesToBuild([]string{"worker"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service 'worker' not found in the compose file")
+}
+
 func TestExtractBuildInfo_StringFormat(t *testing.T) {
 	serviceData := map[string]interface{}{
 		"build": "./src",
@@ -130,6 +163,19 @@ func TestExtractBuildInfo_StringFormat(t *testing.T) {
 	assert.NotNil(t, buildInfo.Build)
 	assert.Equal(t, "./src", buildInfo.Build.Context)
 	assert.Equal(t, "Dockerfile", buildInfo.Build.Dockerfile) // Default
+	assert.Empty(t, buildInfo.Image)
+}
+
+func TestExtractBuildInfo_WithImage(t *testing.T) {
+	serviceData := map[string]interface{}{
+		"build": "./base",
+		"image": "acme/base:1.0",
+	}
+
+	buildInfo, err := extractBuildInfo("base", serviceData)
+	require.NoError(t, err)
+	require.NotNil(t, buildInfo)
+	assert.Equal(t, "acme/base:1.0", buildInfo.Image)
 }
 
 func TestExtractBuildInfo_MapFormat(t *testing.T) {
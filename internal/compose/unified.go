@@ -0,0 +1,154 @@
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unifiedContextLines is the number of unchanged lines shown around changes
+const unifiedContextLines = 3
+
+// diffOp is a line level edit operation
+type diffOp struct {
+	kind byte // ' ' unchanged, '-' removed, '+' added
+	line string
+}
+
+// UnifiedDiff returns a unified text diff between two contents, or an empty string when they
+// are identical. Lines are matched using their longest common subsequence.
+func UnifiedDiff(oldContent, newContent, oldName, newName string) string {
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+
+	ops := diffLines(oldLines, newLines)
+
+	var b strings.Builder
+	for _, hunk := range buildHunks(ops) {
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		b.WriteString(hunk)
+	}
+	return b.String()
+}
+
+// splitLines splits content into lines, ignoring the final newline
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines computes the edit script between a and b from their longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// buildHunks groups the edit script into hunks with context lines
+func buildHunks(ops []diffOp) []string {
+	var hunks []string
+
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		// Extend the hunk while changes are separated by at most 2*context unchanged lines
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			} else if k-last > 2*unifiedContextLines {
+				break
+			}
+		}
+
+		from := max(first-unifiedContextLines, start)
+		to := min(last+unifiedContextLines+1, len(ops))
+		hunks = append(hunks, formatHunk(ops, from, to))
+		start = to
+	}
+
+	return hunks
+}
+
+// formatHunk formats ops[from:to] with its @@ header
+func formatHunk(ops []diffOp, from, to int) string {
+	// Line numbers of the hunk start in the old and new contents
+	oldStart, newStart := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+
+	var body strings.Builder
+	oldCount, newCount := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+		body.WriteByte(op.kind)
+		body.WriteString(op.line)
+		body.WriteByte('\n')
+	}
+
+	// An empty range starts at the line before, as in GNU diff
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n%s", oldStart, oldCount, newStart, newCount, body.String())
+}
@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/deviantony/pctl/internal/credentials"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,18 +19,87 @@ type BuildConfig struct {
 	Platforms       []string          `yaml:"platforms"`         // used for load mode local builds
 	ExtraBuildArgs  map[string]string `yaml:"extra_build_args"`  // optional global overrides
 	ForceBuild      bool              `yaml:"force_build"`       // force rebuild even if unchanged
+	Pull            bool              `yaml:"pull"`              // always pull newer versions of the base images
+	FailFast        bool              `yaml:"fail_fast"`         // cancel the remaining builds on the first failure
 	WarnThresholdMB int               `yaml:"warn_threshold_mb"` // WARN if tar/image stream exceeds this size
+	MaxContextMB    int               `yaml:"max_context_mb"`    // refuse to upload larger build contexts, 0 disables the limit
+}
+
+// TimeoutsConfig represents per-operation timeouts as Go durations (e.g. 90s, 45m, 2h)
+type TimeoutsConfig struct {
+	API   string `yaml:"api,omitempty"`   // regular API calls
+	Build string `yaml:"build,omitempty"` // remote image builds
+	Load  string `yaml:"load,omitempty"`  // image loads in load build mode
+}
+
+// Timeouts holds the parsed timeouts, zero values mean the client defaults
+type Timeouts struct {
+	API   time.Duration
+	Build time.Duration
+	Load  time.Duration
+}
+
+// RetryConfig controls how read-only Portainer API calls are retried on transient failures
+type RetryConfig struct {
+	MaxAttempts    int    `yaml:"max_attempts,omitempty"`    // total attempts, 1 disables retries
+	InitialBackoff string `yaml:"initial_backoff,omitempty"` // delay before the first retry, doubled on every retry
+	MaxBackoff     string `yaml:"max_backoff,omitempty"`     // maximum delay between two attempts
+}
+
+// Retry holds the parsed retry settings, zero values mean the client defaults
+type Retry struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
 }
 
 // Config represents the pctl configuration structure
 type Config struct {
-	PortainerURL  string       `yaml:"portainer_url"`
-	APIToken      string       `yaml:"api_token"`
-	EnvironmentID int          `yaml:"environment_id"`
-	StackName     string       `yaml:"stack_name"`
-	ComposeFile   string       `yaml:"compose_file"`
-	SkipTLSVerify bool         `yaml:"skip_tls_verify"`
-	Build         *BuildConfig `yaml:"build,omitempty"`
+	PortainerURL    string       `yaml:"portainer_url"`
+	APIToken        string       `yaml:"api_token,omitempty"`
+	APITokenFile    string       `yaml:"api_token_file,omitempty"`    // file containing the token, used when api_token is not set
+	APITokenCommand string       `yaml:"api_token_command,omitempty"` // shell command printing the token, used when api_token is not set
+	EnvironmentID   int          `yaml:"environment_id"`
+	StackName       string       `yaml:"stack_name"`
+	ComposeFile     string       `yaml:"compose_file"`
+	SkipTLSVerify   bool         `yaml:"skip_tls_verify"`
+	TLS             *TLSConfig   `yaml:"tls,omitempty"` // CA bundle, client certificate and pinned fingerprint
+	Build           *BuildConfig `yaml:"build,omitempty"`
+
+	// Where Portainer gets the compose file from: the local compose_file, or a git repository
+	Source string     `yaml:"source,omitempty"` // compose (default) | git
+	Git    *GitConfig `yaml:"git,omitempty"`
+
+	// Edge groups the stack is deployed to when environment_id is an Edge environment
+	Edge *EdgeConfig `yaml:"edge,omitempty"`
+
+	// Kind of environment the stack is deployed to, Kubernetes stacks are deployed as manifests
+	Kind       string            `yaml:"kind,omitempty"` // docker (default) | kubernetes
+	Kubernetes *KubernetesConfig `yaml:"kubernetes,omitempty"`
+
+	// Network path to Portainer: an explicit proxy, or an SSH jump host when it is behind a bastion
+	ProxyURL string `yaml:"proxy_url,omitempty"` // http or socks5 proxy, HTTPS_PROXY and HTTP_PROXY are used when unset
+	NoProxy  string `yaml:"no_proxy,omitempty"`  // comma separated hosts, domains and CIDRs reached without the proxy
+	SSHJump  string `yaml:"ssh_jump,omitempty"`  // [user@]host[:port] or ssh config alias of the jump host
+
+	// Timeouts of the Portainer API calls, builds and image loads, and retries of idempotent calls
+	Timeouts *TimeoutsConfig `yaml:"timeouts,omitempty"`
+	Retry    *RetryConfig    `yaml:"retry,omitempty"`
+
+	// Stack environment variables sent to Portainer, env takes precedence over env_file entries
+	Env         map[string]string `yaml:"env,omitempty"`
+	EnvFile     []string          `yaml:"env_file,omitempty"`     // dotenv files, later files take precedence
+	PreserveEnv bool              `yaml:"preserve_env,omitempty"` // keep stack variables not defined by pctl
+
+	// Named targets (e.g. dev, staging, prod) overriding any of the settings above
+	DefaultTarget string               `yaml:"default_target,omitempty"`
+	Targets       map[string]yaml.Node `yaml:"targets,omitempty"`
+
+	// Target is the name of the target the configuration was resolved for, empty when none
+	Target string `yaml:"-"`
+
+	// Session is the pctl login session of portainer_url, used instead of the API token. Nil when not logged in.
+	Session *credentials.Session `yaml:"-"`
 }
 
 const (
@@ -49,8 +120,27 @@ const (
 	DefaultBuildWarnThresholdMB = 50
 )
 
-// Load reads and parses the pctl.yml configuration file
+// Load reads and parses the pctl.yml configuration file, and resolves the credentials
 func Load() (*Config, error) {
+	config, err := LoadWithoutCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.resolveSession(); err != nil {
+		return nil, err
+	}
+
+	if err := config.resolveAPIToken(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// LoadWithoutCredentials reads and parses the pctl.yml configuration file without resolving the
+// API token or the login session, for commands which only need the connection settings
+func LoadWithoutCredentials() (*Config, error) {
 	configPath := ConfigFileName
 
 	// Check if file exists
@@ -63,12 +153,31 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to read configuration file: %w", err)
 	}
 
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file: %w", err)
+	}
+
+	// Targets are interpolated once selected, so that variables used by other targets do not need to be set
+	if err := interpolateNode(&root, "targets"); err != nil {
+		return nil, fmt.Errorf("failed to interpolate configuration file: %w", err)
+	}
+
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := root.Decode(&config); err != nil {
 		return nil, fmt.Errorf("failed to parse configuration file: %w", err)
 	}
 
-	return &config, nil
+	resolved, err := config.resolveTarget(selectedTargetName(&config))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyEnvOverrides(resolved); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
 }
 
 // Save writes the configuration to pctl.yml
@@ -91,8 +200,8 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("portainer_url is required")
 	}
 
-	if c.APIToken == "" {
-		return fmt.Errorf("api_token is required")
+	if c.APIToken == "" && c.Session == nil {
+		return fmt.Errorf("api_token is required (or api_token_file, api_token_command, %sAPI_TOKEN), or run 'pctl login'", EnvOverridePrefix)
 	}
 
 	if c.EnvironmentID == 0 {
@@ -114,12 +223,122 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	switch c.Source {
+	case "", SourceCompose:
+	case SourceGit:
+		if _, err := c.GetGit(); err != nil {
+			return fmt.Errorf("invalid git configuration: %w", err)
+		}
+	default:
+		return fmt.Errorf("source must be '%s' or '%s', got '%s'", SourceCompose, SourceGit, c.Source)
+	}
+
+	if _, err := c.GetEdgeGroups(); err != nil {
+		return fmt.Errorf("invalid edge configuration: %w", err)
+	}
+
+	switch c.Kind {
+	case "", KindDocker:
+	case KindKubernetes:
+		if c.IsGitSource() {
+			return fmt.Errorf("source: git is not supported with kind: kubernetes")
+		}
+		if _, err := c.GetKubernetes(); err != nil {
+			return fmt.Errorf("invalid kubernetes configuration: %w", err)
+		}
+	default:
+		return fmt.Errorf("kind must be '%s' or '%s', got '%s'", KindDocker, KindKubernetes, c.Kind)
+	}
+
+	if _, err := c.GetTLS(); err != nil {
+		return fmt.Errorf("invalid tls configuration: %w", err)
+	}
+
+	if c.ProxyURL != "" && c.SSHJump != "" {
+		return fmt.Errorf("proxy_url and ssh_jump cannot be used together")
+	}
+
+	if _, err := c.GetTimeouts(); err != nil {
+		return fmt.Errorf("invalid timeouts configuration: %w", err)
+	}
+
+	if _, err := c.GetRetry(); err != nil {
+		return fmt.Errorf("invalid retry configuration: %w", err)
+	}
+
+	return nil
+}
+
+// GetTimeouts returns the parsed timeouts, unset timeouts are zero
+func (c *Config) GetTimeouts() (Timeouts, error) {
+	var timeouts Timeouts
+	if c.Timeouts == nil {
+		return timeouts, nil
+	}
+
+	err := parseDurations([]durationField{
+		{"api", c.Timeouts.API, &timeouts.API},
+		{"build", c.Timeouts.Build, &timeouts.Build},
+		{"load", c.Timeouts.Load, &timeouts.Load},
+	})
+	if err != nil {
+		return Timeouts{}, err
+	}
+
+	return timeouts, nil
+}
+
+// GetRetry returns the parsed retry settings, unset values are zero
+func (c *Config) GetRetry() (Retry, error) {
+	var retry Retry
+	if c.Retry == nil {
+		return retry, nil
+	}
+
+	if c.Retry.MaxAttempts < 0 {
+		return Retry{}, fmt.Errorf("max_attempts must be positive, got %d", c.Retry.MaxAttempts)
+	}
+	retry.MaxAttempts = c.Retry.MaxAttempts
+
+	err := parseDurations([]durationField{
+		{"initial_backoff", c.Retry.InitialBackoff, &retry.InitialBackoff},
+		{"max_backoff", c.Retry.MaxBackoff, &retry.MaxBackoff},
+	})
+	if err != nil {
+		return Retry{}, err
+	}
+
+	return retry, nil
+}
+
+// durationField is a duration setting to parse into dest
+type durationField struct {
+	key   string
+	value string
+	dest  *time.Duration
+}
+
+// parseDurations parses the set duration fields, which must be positive
+func parseDurations(fields []durationField) error {
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration '%s' (examples: 90s, 45m, 2h)", f.key, f.value)
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s: duration must be positive, got '%s'", f.key, f.value)
+		}
+		*f.dest = d
+	}
 	return nil
 }
 
 // GetDefaultSkipTLSVerify returns the default value for skip_tls_verify
 func GetDefaultSkipTLSVerify() bool {
-	return true // Default to true for self-hosted environments
+	return false // Self-signed certificates can be pinned with tls.fingerprint instead
 }
 
 // GetDefaultStackName generates a default stack name based on the current directory
@@ -199,5 +418,9 @@ func (bc *BuildConfig) Validate() error {
 		return fmt.Errorf("warn_threshold_mb must be non-negative, got %d", bc.WarnThresholdMB)
 	}
 
+	if bc.MaxContextMB < 0 {
+		return fmt.Errorf("max_context_mb must be non-negative, got %d", bc.MaxContextMB)
+	}
+
 	return nil
 }
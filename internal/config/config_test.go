@@ -4,7 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/deviantony/pctl/internal/credentials"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -126,6 +128,19 @@ func TestConfig_Validate(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestConfig_Validate_Session(t *testing.T) {
+	config := &Config{
+		PortainerURL:  "https://portainer.example.com",
+		EnvironmentID: 1,
+		StackName:     "test-stack",
+		ComposeFile:   "docker-compose.yml",
+		Session:       &credentials.Session{PortainerURL: "https://portainer.example.com", Token: "jwt"},
+	}
+
+	// A login session replaces the API token
+	assert.NoError(t, config.Validate())
+}
+
 func TestConfig_Validate_MissingFields(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -253,6 +268,15 @@ func TestBuildConfig_Validate(t *testing.T) {
 			},
 			expected: "warn_threshold_mb must be non-negative",
 		},
+		{
+			name: "negative max context",
+			config: BuildConfig{
+				Mode:         BuildModeRemoteBuild,
+				Parallel:     BuildParallelAuto,
+				MaxContextMB: -1,
+			},
+			expected: "max_context_mb must be non-negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -376,3 +400,134 @@ func TestConfig_GetBuildConfig_WithPartialBuildConfig(t *testing.T) {
 	assert.False(t, buildConfig.ForceBuild)                                   // Should preserve zero value
 	assert.Equal(t, DefaultBuildWarnThresholdMB, buildConfig.WarnThresholdMB) // Should apply default
 }
+
+func TestConfig_GetTimeouts(t *testing.T) {
+	config := &Config{
+		Timeouts: &TimeoutsConfig{
+			API:   "90s",
+			Build: "1h30m",
+		},
+	}
+
+	timeouts, err := config.GetTimeouts()
+	require.NoError(t, err)
+
+	assert.Equal(t, 90*time.Second, timeouts.API)
+	assert.Equal(t, 90*time.Minute, timeouts.Build)
+	assert.Zero(t, timeouts.Load) // Unset timeouts use the client defaults
+}
+
+func TestConfig_GetTimeouts_NilTimeouts(t *testing.T) {
+	config := &Config{}
+
+	timeouts, err := config.GetTimeouts()
+	require.NoError(t, err)
+	assert.Equal(t, Timeouts{}, timeouts)
+}
+
+func TestConfig_Validate_InvalidTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts *TimeoutsConfig
+		expected string
+	}{
+		{
+			name:     "not a duration",
+			timeouts: &TimeoutsConfig{Build: "10"},
+			expected: "build: invalid duration '10'",
+		},
+		{
+			name:     "negative duration",
+			timeouts: &TimeoutsConfig{Load: "-5m"},
+			expected: "load: duration must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				PortainerURL:  "https://portainer.example.com",
+				APIToken:      "test-token",
+				EnvironmentID: 1,
+				StackName:     "test-stack",
+				ComposeFile:   "docker-compose.yml",
+				Timeouts:      tt.timeouts,
+			}
+
+			err := config.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid timeouts configuration")
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestConfig_GetRetry(t *testing.T) {
+	config := &Config{
+		Retry: &RetryConfig{
+			MaxAttempts:    6,
+			InitialBackoff: "250ms",
+		},
+	}
+
+	retry, err := config.GetRetry()
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, retry.MaxAttempts)
+	assert.Equal(t, 250*time.Millisecond, retry.InitialBackoff)
+	assert.Zero(t, retry.MaxBackoff) // Unset values use the client defaults
+}
+
+func TestConfig_Validate_InvalidRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    *RetryConfig
+		expected string
+	}{
+		{
+			name:     "negative attempts",
+			retry:    &RetryConfig{MaxAttempts: -1},
+			expected: "max_attempts must be positive",
+		},
+		{
+			name:     "invalid backoff",
+			retry:    &RetryConfig{MaxBackoff: "fast"},
+			expected: "max_backoff: invalid duration 'fast'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				PortainerURL:  "https://portainer.example.com",
+				APIToken:      "test-token",
+				EnvironmentID: 1,
+				StackName:     "test-stack",
+				ComposeFile:   "docker-compose.yml",
+				Retry:         tt.retry,
+			}
+
+			err := config.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid retry configuration")
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestConfig_Validate_ProxyAndSSHJump(t *testing.T) {
+	config := &Config{
+		PortainerURL:  "https://portainer.internal",
+		APIToken:      "test-token",
+		EnvironmentID: 1,
+		StackName:     "test-stack",
+		ComposeFile:   "docker-compose.yml",
+		SSHJump:       "admin@bastion.example.com",
+	}
+	require.NoError(t, config.Validate())
+
+	config.ProxyURL = "http://proxy.example.com:3128"
+	err := config.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "proxy_url and ssh_jump cannot be used together")
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EdgeConfig configures the deployment of the stack as an edge stack, used when environment_id
+// is an Edge environment
+type EdgeConfig struct {
+	Groups []string `yaml:"groups"` // names of the edge groups the stack is deployed to
+}
+
+// GetEdgeGroups returns the names of the edge groups the edge stack is deployed to, nil when
+// none are configured
+func (c *Config) GetEdgeGroups() ([]string, error) {
+	if c.Edge == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(c.Edge.Groups))
+	groups := make([]string, 0, len(c.Edge.Groups))
+	for _, group := range c.Edge.Groups {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			return nil, fmt.Errorf("edge.groups cannot contain empty names")
+		}
+		if seen[group] {
+			return nil, fmt.Errorf("edge group '%s' is listed twice", group)
+		}
+		seen[group] = true
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
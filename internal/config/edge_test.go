@@ -0,0 +1,79 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_GetEdgeGroups(t *testing.T) {
+	cfg := &Config{}
+	groups, err := cfg.GetEdgeGroups()
+	require.NoError(t, err)
+	assert.Nil(t, groups)
+
+	cfg.Edge = &EdgeConfig{Groups: []string{"fleet-eu", " fleet-us "}}
+	groups, err = cfg.GetEdgeGroups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fleet-eu", "fleet-us"}, groups)
+}
+
+func TestConfig_GetEdgeGroups_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []string
+		wantErr string
+	}{
+		{name: "empty name", groups: []string{"fleet-eu", " "}, wantErr: "cannot contain empty names"},
+		{name: "duplicate", groups: []string{"fleet-eu", "fleet-eu"}, wantErr: "'fleet-eu' is listed twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Edge: &EdgeConfig{Groups: tt.groups}}
+			_, err := cfg.GetEdgeGroups()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestConfig_Validate_Edge(t *testing.T) {
+	cfg := &Config{
+		PortainerURL:  "https://portainer.example.com",
+		APIToken:      "token",
+		EnvironmentID: 1,
+		StackName:     "myapp",
+		ComposeFile:   "docker-compose.yml",
+		Edge:          &EdgeConfig{Groups: []string{""}},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid edge configuration")
+}
+
+func TestResolveTarget_Edge(t *testing.T) {
+	content := `
+stack_name: myapp
+edge:
+  groups: [fleet-eu]
+targets:
+  canary:
+    edge:
+      groups: [canary]
+`
+	var base Config
+	require.NoError(t, yaml.Unmarshal([]byte(content), &base))
+
+	canary, err := base.resolveTarget("canary")
+	require.NoError(t, err)
+	groups, err := canary.GetEdgeGroups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"canary"}, groups)
+
+	// The top-level settings are untouched
+	assert.Equal(t, []string{"fleet-eu"}, base.Edge.Groups)
+}
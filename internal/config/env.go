@@ -0,0 +1,229 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvOverridePrefix is the prefix of the environment variables overriding configuration keys,
+// e.g. PCTL_API_TOKEN overrides api_token and PCTL_BUILD_MODE overrides build.mode
+const EnvOverridePrefix = "PCTL_"
+
+// envOverrideSkipped lists the variables which do not override their configuration key:
+// targets cannot be set from a variable, PCTL_TARGET selects the target rather than
+// PCTL_DEFAULT_TARGET, and PCTL_ENV is too generic a name to be taken for the stack variables
+var envOverrideSkipped = []string{
+	EnvOverridePrefix + "TARGETS",
+	EnvOverridePrefix + "DEFAULT_TARGET",
+	EnvOverridePrefix + "ENV",
+}
+
+// envVarNamePattern matches valid environment variable names in ${VAR} references
+var envVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// interpolateNode replaces ${VAR} and ${VAR:-default} references in every scalar value of
+// the node. The value of the keys listed in skip is left untouched.
+func interpolateNode(node *yaml.Node, skip ...string) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			if err := interpolateNode(child, skip...); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			if err := interpolateNode(child); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if containsString(skip, node.Content[i].Value) {
+				continue
+			}
+			if err := interpolateNode(node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return nil
+		}
+		value, err := interpolate(node.Value, os.LookupEnv)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = value
+		if node.Style == 0 {
+			// Resolve the type again so that e.g. environment_id: ${ENV_ID} decodes as an int
+			node.Tag = ""
+		}
+	}
+	return nil
+}
+
+// interpolate replaces ${VAR} and ${VAR:-default} references in s. The default is used when
+// the variable is unset or empty. $$ is an escaped $, other uses of $ are kept as-is.
+func interpolate(s string, lookup func(string) (string, bool)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unclosed variable reference in %q", s)
+			}
+			expr := s[i+2 : i+2+end]
+			name, def, hasDefault := strings.Cut(expr, ":-")
+			if !envVarNamePattern.MatchString(name) {
+				return "", fmt.Errorf("invalid variable reference ${%s}", expr)
+			}
+
+			value, ok := lookup(name)
+			switch {
+			case value != "":
+				b.WriteString(value)
+			case hasDefault:
+				b.WriteString(def)
+			case !ok:
+				return "", fmt.Errorf("environment variable '%s' is not set", name)
+			}
+			i += end + 2
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// applyEnvOverrides sets configuration keys from PCTL_* environment variables.
+// Keys of nested sections are prefixed with the section key, e.g. PCTL_BUILD_PARALLEL.
+func applyEnvOverrides(c *Config) error {
+	return applyEnvOverridesTo(reflect.ValueOf(c).Elem(), EnvOverridePrefix)
+}
+
+func applyEnvOverridesTo(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		name := prefix + strings.ToUpper(key)
+		if containsString(envOverrideSkipped, name) {
+			continue
+		}
+		fieldValue := v.Field(i)
+
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			if !hasEnvWithPrefix(name + "_") {
+				continue
+			}
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(field.Type.Elem()))
+			}
+			if err := applyEnvOverridesTo(fieldValue.Elem(), name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(fieldValue, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFromEnv parses an environment variable value into a configuration field.
+// Lists are comma separated and maps use KEY=VALUE pairs separated by commas.
+func setFromEnv(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		list := splitList(value)
+		field.Set(reflect.ValueOf(list))
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		m := make(map[string]string)
+		for _, pair := range splitList(value) {
+			k, v, found := strings.Cut(pair, "=")
+			if !found {
+				return fmt.Errorf("expected KEY=VALUE pairs, got %q", pair)
+			}
+			m[k] = v
+		}
+		field.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, ignoring empty items
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func hasEnvWithPrefix(prefix string) bool {
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{
+		"HOST":  "portainer.example.com",
+		"PORT":  "9443",
+		"EMPTY": "",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError string
+	}{
+		{name: "no reference", input: "plain value", expected: "plain value"},
+		{name: "single reference", input: "${HOST}", expected: "portainer.example.com"},
+		{name: "embedded references", input: "https://${HOST}:${PORT}/", expected: "https://portainer.example.com:9443/"},
+		{name: "default when unset", input: "${MISSING:-fallback}", expected: "fallback"},
+		{name: "default when empty", input: "${EMPTY:-fallback}", expected: "fallback"},
+		{name: "default not used when set", input: "${PORT:-443}", expected: "9443"},
+		{name: "empty default", input: "a${MISSING:-}b", expected: "ab"},
+		{name: "set but empty", input: "a${EMPTY}b", expected: "ab"},
+		{name: "escaped dollar", input: "pa$$word", expected: "pa$word"},
+		{name: "bare dollar kept", input: "$HOME and $", expected: "$HOME and $"},
+		{name: "unset variable", input: "${MISSING}", expectError: "environment variable 'MISSING' is not set"},
+		{name: "unclosed reference", input: "${HOST", expectError: "unclosed variable reference"},
+		{name: "invalid name", input: "${1HOST}", expectError: "invalid variable reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := interpolate(tt.input, lookup)
+			if tt.expectError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestLoad_Interpolation(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	t.Setenv("TEST_PORTAINER_HOST", "portainer.example.com")
+	t.Setenv("TEST_PORTAINER_TOKEN", "ptr_secret")
+	t.Setenv("TEST_ENVIRONMENT_ID", "4")
+
+	configContent := `
+portainer_url: "https://${TEST_PORTAINER_HOST}"
+api_token: "${TEST_PORTAINER_TOKEN}"
+environment_id: ${TEST_ENVIRONMENT_ID}
+stack_name: "${TEST_STACK_NAME:-myapp}"
+compose_file: docker-compose.yml
+build:
+  extra_build_args:
+    TOKEN: "${TEST_PORTAINER_TOKEN}"
+targets:
+  prod:
+    api_token: "${TEST_PROD_TOKEN_NOT_SET}"
+`
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(configContent), 0644))
+
+	config, err := Load()
+	require.NoError(t, err, "variables of targets that are not selected do not need to be set")
+
+	assert.Equal(t, "https://portainer.example.com", config.PortainerURL)
+	assert.Equal(t, "ptr_secret", config.APIToken)
+	assert.Equal(t, 4, config.EnvironmentID)
+	assert.Equal(t, "myapp", config.StackName)
+	assert.Equal(t, "ptr_secret", config.Build.ExtraBuildArgs["TOKEN"])
+
+	// Selecting the target requires its variables
+	t.Setenv(TargetEnvVar, "prod")
+	config, err = Load()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "TEST_PROD_TOKEN_NOT_SET")
+}
+
+func TestLoad_InterpolationUnsetVariable(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	configContent := `
+portainer_url: "https://portainer.example.com"
+api_token: "${TEST_UNSET_TOKEN}"
+environment_id: 1
+`
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(configContent), 0644))
+
+	config, err := Load()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "environment variable 'TEST_UNSET_TOKEN' is not set")
+	assert.Contains(t, err.Error(), "line 3")
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	configContent := `
+portainer_url: "https://portainer.example.com"
+api_token: "file-token"
+environment_id: 1
+stack_name: "myapp"
+compose_file: docker-compose.yml
+skip_tls_verify: true
+`
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(configContent), 0644))
+
+	t.Setenv("PCTL_API_TOKEN", "env-token")
+	t.Setenv("PCTL_ENVIRONMENT_ID", "7")
+	t.Setenv("PCTL_SKIP_TLS_VERIFY", "false")
+	t.Setenv("PCTL_BUILD_MODE", "load")
+	t.Setenv("PCTL_BUILD_PLATFORMS", "linux/amd64, linux/arm64")
+	t.Setenv("PCTL_BUILD_EXTRA_BUILD_ARGS", "A=1,B=2")
+	t.Setenv("PCTL_TIMEOUTS_BUILD", "2h")
+	t.Setenv("PCTL_NO_PROXY", "localhost,.internal")
+
+	config, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "env-token", config.APIToken)
+	assert.Equal(t, 7, config.EnvironmentID)
+	assert.False(t, config.SkipTLSVerify)
+	assert.Equal(t, "myapp", config.StackName)
+	require.NotNil(t, config.Build)
+	assert.Equal(t, BuildModeLoad, config.Build.Mode)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, config.Build.Platforms)
+	assert.Equal(t, map[string]string{"A": "1", "B": "2"}, config.Build.ExtraBuildArgs)
+	require.NotNil(t, config.Timeouts)
+	assert.Equal(t, "2h", config.Timeouts.Build)
+	assert.Equal(t, "localhost,.internal", config.NoProxy)
+}
+
+func TestLoad_EnvOverridesSkippedKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	configContent := `
+portainer_url: "https://portainer.example.com"
+api_token: "token"
+environment_id: 1
+stack_name: "myapp"
+env:
+  LOG_LEVEL: info
+targets:
+  prod:
+    environment_id: 3
+`
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(configContent), 0644))
+
+	// Unrelated variables of CI environments do not break the configuration
+	t.Setenv("PCTL_TARGETS", "dev,prod")
+	t.Setenv("PCTL_ENV", "production")
+	t.Setenv("PCTL_DEFAULT_TARGET", "prod")
+	t.Setenv(TargetEnvVar, "")
+
+	config, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info"}, config.Env)
+	assert.Equal(t, []string{"prod"}, config.TargetNames())
+	assert.Empty(t, config.Target)
+	assert.Equal(t, 1, config.EnvironmentID)
+}
+
+func TestLoad_EnvOverridesInvalidValue(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte("environment_id: 1\n"), 0644))
+	t.Setenv("PCTL_ENVIRONMENT_ID", "prod")
+
+	config, err := Load()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "invalid value for PCTL_ENVIRONMENT_ID")
+}
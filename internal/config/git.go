@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Stack sources
+const (
+	SourceCompose = "compose" // the local compose file is sent to Portainer, the default
+	SourceGit     = "git"     // Portainer deploys the compose file of a git repository
+
+	DefaultGitRef = "refs/heads/main"
+)
+
+// GitConfig describes the repository of a git stack, used with source: git
+type GitConfig struct {
+	URL             string               `yaml:"url"`                        // http(s) URL of the repository
+	Ref             string               `yaml:"ref,omitempty"`              // branch name or full reference, e.g. refs/tags/v1.2.0
+	ComposePath     string               `yaml:"compose_path,omitempty"`     // compose file in the repository, defaults to compose_file
+	AdditionalFiles []string             `yaml:"additional_files,omitempty"` // compose files merged on top of compose_path
+	Username        string               `yaml:"username,omitempty"`
+	Password        string               `yaml:"password,omitempty"`      // password or personal access token, e.g. ${GIT_TOKEN}
+	CredentialID    int                  `yaml:"credential_id,omitempty"` // git credential saved in Portainer, instead of username and password
+	TLSSkipVerify   bool                 `yaml:"tls_skip_verify,omitempty"`
+	AutoUpdate      *GitAutoUpdateConfig `yaml:"auto_update,omitempty"` // let Portainer redeploy the stack when the repository changes
+}
+
+// GitAutoUpdateConfig configures how Portainer keeps a git stack up to date
+type GitAutoUpdateConfig struct {
+	Interval    string `yaml:"interval,omitempty"`     // polling interval as a Go duration, e.g. 5m
+	Webhook     bool   `yaml:"webhook,omitempty"`      // create a webhook triggering a redeploy
+	ForceUpdate bool   `yaml:"force_update,omitempty"` // redeploy even when the repository did not change
+	PullImage   bool   `yaml:"pull_image,omitempty"`   // pull the images on every redeploy
+}
+
+// GitSource holds the validated git settings with the defaults applied
+type GitSource struct {
+	URL             string
+	Ref             string
+	ComposePath     string
+	AdditionalFiles []string
+	Username        string
+	Password        string
+	CredentialID    int
+	TLSSkipVerify   bool
+	AutoUpdate      *GitAutoUpdate // nil when Portainer does not update the stack by itself
+}
+
+// GitAutoUpdate holds the parsed auto update settings
+type GitAutoUpdate struct {
+	Interval    time.Duration // zero when the repository is not polled
+	Webhook     bool
+	ForceUpdate bool
+	PullImage   bool
+}
+
+// IsGitSource reports whether the stack is deployed from a git repository
+func (c *Config) IsGitSource() bool {
+	return c.Source == SourceGit
+}
+
+// GetGit returns the git settings of a git stack. The reference defaults to refs/heads/main
+// and a branch name is expanded to its full reference.
+func (c *Config) GetGit() (*GitSource, error) {
+	if c.Git == nil || c.Git.URL == "" {
+		return nil, fmt.Errorf("git.url is required with source: git")
+	}
+
+	repoURL, err := url.Parse(c.Git.URL)
+	if err != nil || (repoURL.Scheme != "http" && repoURL.Scheme != "https") || repoURL.Host == "" {
+		return nil, fmt.Errorf("git.url must be an http or https URL, got '%s'", c.Git.URL)
+	}
+
+	if c.Git.Password != "" && c.Git.Username == "" {
+		return nil, fmt.Errorf("git.username is required with git.password")
+	}
+	if c.Git.CredentialID != 0 && c.Git.Username != "" {
+		return nil, fmt.Errorf("git.credential_id and git.username cannot be used together")
+	}
+
+	source := &GitSource{
+		URL:             c.Git.URL,
+		Ref:             c.Git.Ref,
+		ComposePath:     c.Git.ComposePath,
+		AdditionalFiles: c.Git.AdditionalFiles,
+		Username:        c.Git.Username,
+		Password:        c.Git.Password,
+		CredentialID:    c.Git.CredentialID,
+		TLSSkipVerify:   c.Git.TLSSkipVerify,
+	}
+
+	switch {
+	case source.Ref == "":
+		source.Ref = DefaultGitRef
+	case !strings.HasPrefix(source.Ref, "refs/"):
+		source.Ref = "refs/heads/" + source.Ref
+	}
+
+	if source.ComposePath == "" {
+		source.ComposePath = c.ComposeFile
+	}
+
+	if auto := c.Git.AutoUpdate; auto != nil {
+		source.AutoUpdate = &GitAutoUpdate{
+			Webhook:     auto.Webhook,
+			ForceUpdate: auto.ForceUpdate,
+			PullImage:   auto.PullImage,
+		}
+		if auto.Interval != "" {
+			interval, err := time.ParseDuration(auto.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid git.auto_update.interval '%s': expected a duration like 5m or 1h", auto.Interval)
+			}
+			if interval < time.Minute {
+				return nil, fmt.Errorf("git.auto_update.interval must be at least 1m, got %s", auto.Interval)
+			}
+			source.AutoUpdate.Interval = interval
+		}
+		if source.AutoUpdate.Interval == 0 && !source.AutoUpdate.Webhook {
+			return nil, fmt.Errorf("git.auto_update requires an interval or webhook: true")
+		}
+	}
+
+	return source, nil
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_GetGit(t *testing.T) {
+	cfg := &Config{
+		ComposeFile: "docker-compose.yml",
+		Source:      SourceGit,
+		Git:         &GitConfig{URL: "https://github.com/acme/myapp.git"},
+	}
+
+	git, err := cfg.GetGit()
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/myapp.git", git.URL)
+	assert.Equal(t, DefaultGitRef, git.Ref)
+	assert.Equal(t, "docker-compose.yml", git.ComposePath)
+	assert.Nil(t, git.AutoUpdate)
+}
+
+func TestConfig_GetGit_Ref(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: "", expected: "refs/heads/main"},
+		{ref: "production", expected: "refs/heads/production"},
+		{ref: "feature/login", expected: "refs/heads/feature/login"},
+		{ref: "refs/tags/v1.2.0", expected: "refs/tags/v1.2.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			cfg := &Config{Source: SourceGit, Git: &GitConfig{URL: "https://github.com/acme/myapp.git", Ref: tt.ref}}
+			git, err := cfg.GetGit()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, git.Ref)
+		})
+	}
+}
+
+func TestConfig_GetGit_AutoUpdate(t *testing.T) {
+	cfg := &Config{Source: SourceGit, Git: &GitConfig{
+		URL:        "https://github.com/acme/myapp.git",
+		AutoUpdate: &GitAutoUpdateConfig{Interval: "10m", Webhook: true, PullImage: true},
+	}}
+
+	git, err := cfg.GetGit()
+	require.NoError(t, err)
+	assert.Equal(t, &GitAutoUpdate{Interval: 10 * time.Minute, Webhook: true, PullImage: true}, git.AutoUpdate)
+}
+
+func TestConfig_GetGit_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		git      *GitConfig
+		expected string
+	}{
+		{name: "missing section", git: nil, expected: "git.url is required"},
+		{name: "missing url", git: &GitConfig{Ref: "main"}, expected: "git.url is required"},
+		{name: "ssh url", git: &GitConfig{URL: "git@github.com:acme/myapp.git"}, expected: "must be an http or https URL"},
+		{name: "password without username", git: &GitConfig{URL: "https://github.com/acme/myapp.git", Password: "secret"}, expected: "git.username is required"},
+		{
+			name:     "credential and username",
+			git:      &GitConfig{URL: "https://github.com/acme/myapp.git", Username: "deployer", CredentialID: 2},
+			expected: "cannot be used together",
+		},
+		{
+			name:     "invalid interval",
+			git:      &GitConfig{URL: "https://github.com/acme/myapp.git", AutoUpdate: &GitAutoUpdateConfig{Interval: "often"}},
+			expected: "invalid git.auto_update.interval",
+		},
+		{
+			name:     "interval too short",
+			git:      &GitConfig{URL: "https://github.com/acme/myapp.git", AutoUpdate: &GitAutoUpdateConfig{Interval: "10s"}},
+			expected: "at least 1m",
+		},
+		{
+			name:     "auto update without trigger",
+			git:      &GitConfig{URL: "https://github.com/acme/myapp.git", AutoUpdate: &GitAutoUpdateConfig{PullImage: true}},
+			expected: "requires an interval or webhook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Source: SourceGit, Git: tt.git}
+			_, err := cfg.GetGit()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestConfig_Validate_Source(t *testing.T) {
+	cfg := &Config{
+		PortainerURL:  "https://portainer.example.com",
+		APIToken:      "test-token",
+		EnvironmentID: 1,
+		StackName:     "test-stack",
+		ComposeFile:   "docker-compose.yml",
+	}
+	assert.NoError(t, cfg.Validate())
+
+	// The git settings are ignored unless the source is git, e.g. when a target deploys the local file
+	cfg.Git = &GitConfig{Ref: "main"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Source = SourceGit
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid git configuration")
+
+	cfg.Git.URL = "https://github.com/acme/myapp.git"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Source = "helm"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "source must be 'compose' or 'git'")
+}
+
+func TestResolveTarget_Git(t *testing.T) {
+	content := `
+stack_name: myapp
+compose_file: docker-compose.yml
+git:
+  url: https://github.com/acme/myapp.git
+  ref: main
+  auto_update:
+    interval: 5m
+targets:
+  dev:
+    stack_name: myapp-dev
+  prod:
+    source: git
+    git:
+      ref: production
+      auto_update:
+        webhook: true
+`
+	var base Config
+	require.NoError(t, yaml.Unmarshal([]byte(content), &base))
+
+	prod, err := base.resolveTarget("prod")
+	require.NoError(t, err)
+	assert.True(t, prod.IsGitSource())
+	git, err := prod.GetGit()
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/myapp.git", git.URL)
+	assert.Equal(t, "refs/heads/production", git.Ref)
+	assert.Equal(t, &GitAutoUpdate{Interval: 5 * time.Minute, Webhook: true}, git.AutoUpdate)
+
+	// The top-level settings are untouched
+	assert.Equal(t, "main", base.Git.Ref)
+	assert.False(t, base.Git.AutoUpdate.Webhook)
+
+	dev, err := base.resolveTarget("dev")
+	require.NoError(t, err)
+	assert.False(t, dev.IsGitSource())
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Target kinds
+const (
+	KindDocker     = "docker"     // Docker standalone or Swarm environment, the default
+	KindKubernetes = "kubernetes" // Kubernetes environment, the stack is deployed as manifests
+
+	DefaultKubernetesNamespace = "default"
+)
+
+// KubernetesConfig configures the deployment of the stack to a Kubernetes environment, used
+// with kind: kubernetes
+type KubernetesConfig struct {
+	Namespace string   `yaml:"namespace,omitempty"` // namespace the stack is deployed to, defaults to default
+	Manifests []string `yaml:"manifests,omitempty"` // manifest files deployed instead of converting compose_file
+}
+
+// Kubernetes holds the validated Kubernetes settings with the defaults applied
+type Kubernetes struct {
+	Namespace string
+	Manifests []string // empty when Portainer converts the compose file to manifests
+}
+
+// namespacePattern matches the RFC 1123 labels Kubernetes accepts as namespace names
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// IsKubernetes reports whether the stack is deployed to a Kubernetes environment
+func (c *Config) IsKubernetes() bool {
+	return c.Kind == KindKubernetes
+}
+
+// GetKubernetes returns the Kubernetes settings of the stack, the namespace defaults to default
+func (c *Config) GetKubernetes() (*Kubernetes, error) {
+	kubernetes := &Kubernetes{Namespace: DefaultKubernetesNamespace}
+	if c.Kubernetes == nil {
+		return kubernetes, nil
+	}
+
+	if namespace := strings.TrimSpace(c.Kubernetes.Namespace); namespace != "" {
+		if len(namespace) > 63 || !namespacePattern.MatchString(namespace) {
+			return nil, fmt.Errorf("kubernetes.namespace must be a lowercase RFC 1123 label, got '%s'", c.Kubernetes.Namespace)
+		}
+		kubernetes.Namespace = namespace
+	}
+
+	for _, manifest := range c.Kubernetes.Manifests {
+		manifest = strings.TrimSpace(manifest)
+		if manifest == "" {
+			return nil, fmt.Errorf("kubernetes.manifests cannot contain empty paths")
+		}
+		kubernetes.Manifests = append(kubernetes.Manifests, manifest)
+	}
+	return kubernetes, nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_GetKubernetes(t *testing.T) {
+	cfg := &Config{Kind: KindKubernetes}
+	assert.True(t, cfg.IsKubernetes())
+
+	kubernetes, err := cfg.GetKubernetes()
+	require.NoError(t, err)
+	assert.Equal(t, &Kubernetes{Namespace: DefaultKubernetesNamespace}, kubernetes)
+
+	cfg.Kubernetes = &KubernetesConfig{Namespace: " shop ", Manifests: []string{"k8s/app.yml", " k8s/ingress.yml"}}
+	kubernetes, err = cfg.GetKubernetes()
+	require.NoError(t, err)
+	assert.Equal(t, "shop", kubernetes.Namespace)
+	assert.Equal(t, []string{"k8s/app.yml", "k8s/ingress.yml"}, kubernetes.Manifests)
+}
+
+func TestConfig_GetKubernetes_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubernetes KubernetesConfig
+		wantErr    string
+	}{
+		{name: "uppercase namespace", kubernetes: KubernetesConfig{Namespace: "Shop"}, wantErr: "RFC 1123 label"},
+		{name: "trailing dash", kubernetes: KubernetesConfig{Namespace: "shop-"}, wantErr: "RFC 1123 label"},
+		{name: "empty manifest", kubernetes: KubernetesConfig{Manifests: []string{"app.yml", ""}}, wantErr: "cannot contain empty paths"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Kind: KindKubernetes, Kubernetes: &tt.kubernetes}
+			_, err := cfg.GetKubernetes()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestConfig_Validate_Kind(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{name: "docker", modify: func(c *Config) { c.Kind = KindDocker }},
+		{name: "kubernetes", modify: func(c *Config) { c.Kind = KindKubernetes }},
+		{name: "unknown kind", modify: func(c *Config) { c.Kind = "nomad" }, wantErr: "kind must be 'docker' or 'kubernetes'"},
+		{
+			name: "git source",
+			modify: func(c *Config) {
+				c.Kind = KindKubernetes
+				c.Source = SourceGit
+				c.Git = &GitConfig{URL: "https://github.com/acme/shop.git"}
+			},
+			wantErr: "source: git is not supported with kind: kubernetes",
+		},
+		{
+			name: "invalid namespace",
+			modify: func(c *Config) {
+				c.Kind = KindKubernetes
+				c.Kubernetes = &KubernetesConfig{Namespace: "my_shop"}
+			},
+			wantErr: "invalid kubernetes configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				PortainerURL:  "https://portainer.example.com",
+				APIToken:      "token",
+				EnvironmentID: 1,
+				StackName:     "myapp",
+				ComposeFile:   "docker-compose.yml",
+			}
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestResolveTarget_Kubernetes(t *testing.T) {
+	content := `
+stack_name: myapp
+environment_id: 1
+kubernetes:
+  namespace: shop
+  manifests: [k8s/app.yml]
+targets:
+  k8s:
+    kind: kubernetes
+    environment_id: 5
+    kubernetes:
+      namespace: shop-staging
+`
+	var base Config
+	require.NoError(t, yaml.Unmarshal([]byte(content), &base))
+
+	k8s, err := base.resolveTarget("k8s")
+	require.NoError(t, err)
+	assert.True(t, k8s.IsKubernetes())
+	assert.Equal(t, 5, k8s.EnvironmentID)
+	kubernetes, err := k8s.GetKubernetes()
+	require.NoError(t, err)
+	assert.Equal(t, "shop-staging", kubernetes.Namespace)
+	assert.Equal(t, []string{"k8s/app.yml"}, kubernetes.Manifests)
+
+	// The top-level settings are untouched
+	assert.False(t, base.IsKubernetes())
+	assert.Equal(t, "shop", base.Kubernetes.Namespace)
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TargetEnvVar selects the target when the --target flag is not given
+const TargetEnvVar = "PCTL_TARGET"
+
+// selectedTarget is the target requested on the command line
+var selectedTarget string
+
+// SetTarget selects the target used by Load, it takes precedence over PCTL_TARGET
+// and the default_target setting
+func SetTarget(name string) {
+	selectedTarget = name
+}
+
+// selectedTargetName returns the target to use: --target flag, then PCTL_TARGET, then default_target
+func selectedTargetName(c *Config) string {
+	if selectedTarget != "" {
+		return selectedTarget
+	}
+	if name := os.Getenv(TargetEnvVar); name != "" {
+		return name
+	}
+	return c.DefaultTarget
+}
+
+// TargetNames returns the sorted names of the configured targets
+func (c *Config) TargetNames() []string {
+	names := make([]string, 0, len(c.Targets))
+	for name := range c.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveTarget returns the configuration with the settings of the named target applied
+// on top of the top-level settings. Settings the target does not set are inherited.
+func (c *Config) resolveTarget(name string) (*Config, error) {
+	if name == "" {
+		return c, nil
+	}
+
+	node, ok := c.Targets[name]
+	if !ok {
+		if len(c.Targets) == 0 {
+			return nil, fmt.Errorf("target '%s' not found: no targets are defined in %s", name, ConfigFileName)
+		}
+		return nil, fmt.Errorf("target '%s' not found in %s (available targets: %s)", name, ConfigFileName, strings.Join(c.TargetNames(), ", "))
+	}
+
+	resolved := *c
+	if c.Build != nil {
+		// Copy the build settings so that the target cannot modify the top-level ones
+		build := *c.Build
+		if c.Build.ExtraBuildArgs != nil {
+			build.ExtraBuildArgs = make(map[string]string, len(c.Build.ExtraBuildArgs))
+			for k, v := range c.Build.ExtraBuildArgs {
+				build.ExtraBuildArgs[k] = v
+			}
+		}
+		resolved.Build = &build
+	}
+	if c.Git != nil {
+		git := *c.Git
+		git.AdditionalFiles = append([]string(nil), c.Git.AdditionalFiles...)
+		if c.Git.AutoUpdate != nil {
+			autoUpdate := *c.Git.AutoUpdate
+			git.AutoUpdate = &autoUpdate
+		}
+		resolved.Git = &git
+	}
+	if c.Edge != nil {
+		edge := *c.Edge
+		edge.Groups = append([]string(nil), c.Edge.Groups...)
+		resolved.Edge = &edge
+	}
+	if c.Kubernetes != nil {
+		kubernetes := *c.Kubernetes
+		kubernetes.Manifests = append([]string(nil), c.Kubernetes.Manifests...)
+		resolved.Kubernetes = &kubernetes
+	}
+	if c.TLS != nil {
+		tls := *c.TLS
+		resolved.TLS = &tls
+	}
+	if c.Timeouts != nil {
+		timeouts := *c.Timeouts
+		resolved.Timeouts = &timeouts
+	}
+	if c.Retry != nil {
+		retry := *c.Retry
+		resolved.Retry = &retry
+	}
+	if c.Env != nil {
+		// The target's env is merged into the map, which must not be the top-level one
+		resolved.Env = make(map[string]string, len(c.Env))
+		for k, v := range c.Env {
+			resolved.Env[k] = v
+		}
+	}
+	resolved.EnvFile = append([]string(nil), c.EnvFile...)
+
+	// Interpolate a copy so that the target can be resolved again with a different environment
+	node = *cloneNode(&node)
+	if err := interpolateNode(&node); err != nil {
+		return nil, fmt.Errorf("failed to interpolate target '%s': %w", name, err)
+	}
+
+	if err := node.Decode(&resolved); err != nil {
+		return nil, fmt.Errorf("failed to parse target '%s': %w", name, err)
+	}
+
+	// Targets cannot be nested
+	resolved.DefaultTarget = c.DefaultTarget
+	resolved.Targets = c.Targets
+	resolved.Target = name
+
+	return &resolved, nil
+}
+
+// cloneNode returns a deep copy of node
+func cloneNode(node *yaml.Node) *yaml.Node {
+	clone := *node
+	clone.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		clone.Content[i] = cloneNode(child)
+	}
+	return &clone
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const targetsConfig = `
+portainer_url: "https://portainer.example.com"
+api_token: "base-token"
+environment_id: 1
+stack_name: "myapp"
+compose_file: "docker-compose.yml"
+skip_tls_verify: true
+build:
+  mode: remote-build
+  extra_build_args:
+    NODE_ENV: development
+default_target: dev
+targets:
+  dev:
+    stack_name: "myapp-dev"
+  prod:
+    portainer_url: "https://prod.example.com"
+    api_token: "prod-token"
+    environment_id: 3
+    compose_file: "docker-compose.prod.yml"
+    skip_tls_verify: false
+    build:
+      mode: load
+      extra_build_args:
+        NODE_ENV: production
+`
+
+// writeTargetsConfig writes the targets configuration in a temporary working directory
+func writeTargetsConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(originalDir) })
+	os.Chdir(tempDir)
+
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(targetsConfig), 0644))
+}
+
+func TestLoad_DefaultTarget(t *testing.T) {
+	writeTargetsConfig(t)
+	t.Setenv(TargetEnvVar, "")
+
+	config, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "dev", config.Target)
+	assert.Equal(t, "myapp-dev", config.StackName)
+	// Settings not set by the target are inherited
+	assert.Equal(t, "https://portainer.example.com", config.PortainerURL)
+	assert.Equal(t, "base-token", config.APIToken)
+	assert.Equal(t, 1, config.EnvironmentID)
+	assert.True(t, config.SkipTLSVerify)
+	assert.Equal(t, "development", config.Build.ExtraBuildArgs["NODE_ENV"])
+}
+
+func TestLoad_TargetFromEnv(t *testing.T) {
+	writeTargetsConfig(t)
+	t.Setenv(TargetEnvVar, "prod")
+
+	config, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "prod", config.Target)
+	assert.Equal(t, "https://prod.example.com", config.PortainerURL)
+	assert.Equal(t, "prod-token", config.APIToken)
+	assert.Equal(t, 3, config.EnvironmentID)
+	assert.Equal(t, "myapp", config.StackName)
+	assert.Equal(t, "docker-compose.prod.yml", config.ComposeFile)
+	assert.False(t, config.SkipTLSVerify, "explicit false in the target must override the top-level value")
+	assert.Equal(t, BuildModeLoad, config.Build.Mode)
+	assert.Equal(t, "production", config.Build.ExtraBuildArgs["NODE_ENV"])
+}
+
+func TestLoad_TargetFlagTakesPrecedence(t *testing.T) {
+	writeTargetsConfig(t)
+	t.Setenv(TargetEnvVar, "dev")
+	SetTarget("prod")
+	t.Cleanup(func() { SetTarget("") })
+
+	config, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "prod", config.Target)
+	assert.Equal(t, 3, config.EnvironmentID)
+}
+
+func TestLoad_TargetDoesNotModifyBase(t *testing.T) {
+	writeTargetsConfig(t)
+	t.Setenv(TargetEnvVar, "")
+
+	base, err := Load()
+	require.NoError(t, err)
+
+	prod, err := base.resolveTarget("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "production", prod.Build.ExtraBuildArgs["NODE_ENV"])
+
+	// The configuration resolved for dev is untouched
+	assert.Equal(t, "development", base.Build.ExtraBuildArgs["NODE_ENV"])
+	assert.Equal(t, BuildModeRemoteBuild, base.Build.Mode)
+}
+
+func TestLoad_TargetsDoNotShareEnv(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(originalDir) })
+	os.Chdir(tempDir)
+	t.Setenv(TargetEnvVar, "")
+
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(`
+portainer_url: "https://portainer.example.com"
+api_token: "token"
+environment_id: 1
+stack_name: "myapp"
+env:
+  LOG_LEVEL: info
+env_file: [".env"]
+targets:
+  staging:
+    env:
+      SENTRY_DSN: "https://sentry.example.com/1"
+    env_file: [".env.staging"]
+  prod:
+    env:
+      LOG_LEVEL: warn
+`), 0644))
+
+	base, err := Load()
+	require.NoError(t, err)
+
+	staging, err := base.resolveTarget("staging")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "SENTRY_DSN": "https://sentry.example.com/1"}, staging.Env)
+	assert.Equal(t, []string{".env.staging"}, staging.EnvFile)
+
+	// The variables of the first target do not leak into the second one
+	prod, err := base.resolveTarget("prod")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "warn"}, prod.Env)
+	assert.Equal(t, []string{".env"}, prod.EnvFile)
+
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info"}, base.Env)
+	assert.Equal(t, []string{".env"}, base.EnvFile)
+}
+
+func TestLoad_UnknownTarget(t *testing.T) {
+	writeTargetsConfig(t)
+	t.Setenv(TargetEnvVar, "staging")
+
+	config, err := Load()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "target 'staging' not found")
+	assert.Contains(t, err.Error(), "available targets: dev, prod")
+}
+
+func TestLoad_TargetWithoutTargets(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	configContent := `
+portainer_url: "https://portainer.example.com"
+api_token: "test-token"
+environment_id: 1
+stack_name: "test-stack"
+compose_file: "docker-compose.yml"
+`
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(configContent), 0644))
+	t.Setenv(TargetEnvVar, "prod")
+
+	config, err := Load()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "no targets are defined")
+}
+
+func TestConfig_TargetNames(t *testing.T) {
+	writeTargetsConfig(t)
+	t.Setenv(TargetEnvVar, "")
+
+	config, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"dev", "prod"}, config.TargetNames())
+}
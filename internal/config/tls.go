@@ -0,0 +1,38 @@
+package config
+
+import "fmt"
+
+// TLSConfig configures how the Portainer server certificate is verified and the client
+// certificate presented for mutual TLS. Paths are relative to the working directory.
+type TLSConfig struct {
+	CAFile      string `yaml:"ca_file,omitempty"`     // PEM bundle trusted in addition to the system certificate authorities
+	CertFile    string `yaml:"cert_file,omitempty"`   // client certificate for mutual TLS
+	KeyFile     string `yaml:"key_file,omitempty"`    // private key of cert_file
+	ServerName  string `yaml:"server_name,omitempty"` // name verified against the server certificate, defaults to the URL host
+	Fingerprint string `yaml:"fingerprint,omitempty"` // pinned SHA-256 fingerprint of the server certificate, see 'pctl init'
+}
+
+// GetTLS returns the TLS settings with ~ expanded in the file paths, unset settings are empty
+func (c *Config) GetTLS() (TLSConfig, error) {
+	if c.TLS == nil {
+		return TLSConfig{}, nil
+	}
+
+	settings := *c.TLS
+	if (settings.CertFile == "") != (settings.KeyFile == "") {
+		return TLSConfig{}, fmt.Errorf("cert_file and key_file must be set together")
+	}
+	if c.SkipTLSVerify && (settings.CAFile != "" || settings.Fingerprint != "") {
+		return TLSConfig{}, fmt.Errorf("skip_tls_verify cannot be combined with ca_file or fingerprint")
+	}
+
+	for _, path := range []*string{&settings.CAFile, &settings.CertFile, &settings.KeyFile} {
+		expanded, err := ExpandHome(*path)
+		if err != nil {
+			return TLSConfig{}, err
+		}
+		*path = expanded
+	}
+
+	return settings, nil
+}
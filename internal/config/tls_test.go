@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_TLS(t *testing.T) {
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	configContent := `
+portainer_url: "https://portainer.internal"
+api_token: "ptr_token"
+environment_id: 1
+stack_name: "myapp"
+compose_file: docker-compose.yml
+tls:
+  ca_file: certs/ca.pem
+  cert_file: ~/.config/pctl/client.crt
+  key_file: ~/.config/pctl/client.key
+  server_name: portainer.corp.example.com
+targets:
+  prod:
+    tls:
+      ca_file: certs/prod-ca.pem
+`
+	require.NoError(t, os.WriteFile(ConfigFileName, []byte(configContent), 0644))
+	t.Setenv("PCTL_TLS_SERVER_NAME", "portainer.example.com")
+
+	config, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+
+	settings, err := config.GetTLS()
+	require.NoError(t, err)
+
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	assert.Equal(t, "certs/ca.pem", settings.CAFile)
+	assert.Equal(t, filepath.Join(home, ".config/pctl/client.crt"), settings.CertFile)
+	assert.Equal(t, filepath.Join(home, ".config/pctl/client.key"), settings.KeyFile)
+	assert.Equal(t, "portainer.example.com", settings.ServerName)
+	assert.Equal(t, "~/.config/pctl/client.crt", config.TLS.CertFile, "the configuration keeps the original path")
+
+	prod, err := config.resolveTarget("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "certs/prod-ca.pem", prod.TLS.CAFile)
+	assert.Equal(t, "~/.config/pctl/client.crt", prod.TLS.CertFile, "unset target keys are inherited")
+	assert.Equal(t, "certs/ca.pem", config.TLS.CAFile, "the target must not modify the top-level settings")
+}
+
+func TestConfig_GetTLS_Unset(t *testing.T) {
+	config := &Config{}
+
+	settings, err := config.GetTLS()
+	require.NoError(t, err)
+	assert.Equal(t, TLSConfig{}, settings)
+}
+
+func TestConfig_Validate_InvalidTLS(t *testing.T) {
+	tests := []struct {
+		name          string
+		skipTLSVerify bool
+		tls           *TLSConfig
+		expected      string
+	}{
+		{
+			name:     "certificate without key",
+			tls:      &TLSConfig{CertFile: "client.crt"},
+			expected: "cert_file and key_file must be set together",
+		},
+		{
+			name:     "key without certificate",
+			tls:      &TLSConfig{KeyFile: "client.key"},
+			expected: "cert_file and key_file must be set together",
+		},
+		{
+			name:          "skip verify with CA file",
+			skipTLSVerify: true,
+			tls:           &TLSConfig{CAFile: "ca.pem"},
+			expected:      "skip_tls_verify cannot be combined",
+		},
+		{
+			name:          "skip verify with fingerprint",
+			skipTLSVerify: true,
+			tls:           &TLSConfig{Fingerprint: "AB:CD"},
+			expected:      "skip_tls_verify cannot be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				PortainerURL:  "https://portainer.example.com",
+				APIToken:      "test-token",
+				EnvironmentID: 1,
+				StackName:     "test-stack",
+				ComposeFile:   "docker-compose.yml",
+				SkipTLSVerify: tt.skipTLSVerify,
+				TLS:           tt.tls,
+			}
+
+			err := config.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid tls configuration")
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
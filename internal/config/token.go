@@ -0,0 +1,131 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/credentials"
+)
+
+// resolveAPIToken reads the API token from api_token_file or api_token_command when
+// api_token is not set and there is no login session. The token is never written back to pctl.yml.
+func (c *Config) resolveAPIToken() error {
+	if c.APITokenFile != "" && c.APITokenCommand != "" {
+		return fmt.Errorf("api_token_file and api_token_command cannot be used together")
+	}
+
+	// The session takes precedence, the token file may not exist for users logging in
+	if c.APIToken != "" || c.Session != nil {
+		return nil
+	}
+
+	token, err := c.readAPIToken()
+	if err != nil {
+		return err
+	}
+	c.APIToken = token
+	return nil
+}
+
+// FallbackAPIToken returns the API token configured next to the login session, used when the
+// session has expired and cannot be renewed. It is empty when no token is configured.
+func (c *Config) FallbackAPIToken() (string, error) {
+	if c.APIToken != "" {
+		return c.APIToken, nil
+	}
+	return c.readAPIToken()
+}
+
+// readAPIToken reads the API token from api_token_file or api_token_command, empty when neither is set
+func (c *Config) readAPIToken() (string, error) {
+	switch {
+	case c.APITokenFile != "":
+		path, err := ExpandHome(c.APITokenFile)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read api_token_file: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("api_token_file '%s' is empty", c.APITokenFile)
+		}
+		return token, nil
+	case c.APITokenCommand != "":
+		return runTokenCommand(c.APITokenCommand)
+	}
+	return "", nil
+}
+
+// resolveSession looks up the pctl login session of the Portainer instance
+func (c *Config) resolveSession() error {
+	session, err := LookupSession(c.PortainerURL)
+	if err != nil {
+		return err
+	}
+	c.Session = session
+	return nil
+}
+
+// LookupSession returns the pctl login session of a Portainer instance, nil when not logged in
+func LookupSession(portainerURL string) (*credentials.Session, error) {
+	dir, err := credentials.DefaultDir()
+	if err != nil {
+		// Without a home directory there cannot be a session
+		return nil, nil
+	}
+
+	store, err := credentials.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	session, _ := store.Get(portainerURL)
+	return session, nil
+}
+
+// runTokenCommand runs the command through the shell and returns its trimmed output.
+// Stderr is left attached to the terminal so that password managers can prompt.
+func runTokenCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("api_token_command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("api_token_command returned an empty token")
+	}
+	return token, nil
+}
+
+// ExpandHome replaces a leading ~ in path with the home directory of the current user
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ResolveAPIToken_File(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("ptr_from_file\n"), 0600))
+
+	config := &Config{APITokenFile: tokenPath}
+	require.NoError(t, config.resolveAPIToken())
+
+	assert.Equal(t, "ptr_from_file", config.APIToken)
+}
+
+func TestConfig_ResolveAPIToken_EmptyFile(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("\n"), 0600))
+
+	config := &Config{APITokenFile: tokenPath}
+	err := config.resolveAPIToken()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is empty")
+}
+
+func TestConfig_ResolveAPIToken_MissingFile(t *testing.T) {
+	config := &Config{APITokenFile: filepath.Join(t.TempDir(), "missing")}
+	err := config.resolveAPIToken()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read api_token_file")
+}
+
+func TestConfig_ResolveAPIToken_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+
+	config := &Config{APITokenCommand: "echo ptr_from_command"}
+	require.NoError(t, config.resolveAPIToken())
+
+	assert.Equal(t, "ptr_from_command", config.APIToken)
+}
+
+func TestConfig_ResolveAPIToken_CommandFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell command")
+	}
+
+	config := &Config{APITokenCommand: "exit 3"}
+	err := config.resolveAPIToken()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "api_token_command failed")
+}
+
+func TestConfig_ResolveAPIToken_TokenTakesPrecedence(t *testing.T) {
+	config := &Config{APIToken: "ptr_direct", APITokenCommand: "exit 1"}
+	require.NoError(t, config.resolveAPIToken())
+
+	assert.Equal(t, "ptr_direct", config.APIToken)
+}
+
+func TestConfig_ResolveAPIToken_BothSources(t *testing.T) {
+	config := &Config{APITokenFile: "token", APITokenCommand: "echo token"}
+	err := config.resolveAPIToken()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be used together")
+}
+
+func TestConfig_ResolveAPIToken_SessionTakesPrecedence(t *testing.T) {
+	config := &Config{
+		APITokenFile: filepath.Join(t.TempDir(), "missing"),
+		Session:      &credentials.Session{PortainerURL: "https://portainer.example.com", Token: "jwt"},
+	}
+	require.NoError(t, config.resolveAPIToken())
+
+	assert.Empty(t, config.APIToken)
+}
+
+func TestConfig_ResolveSession(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	store := &credentials.Store{}
+	store.Set(credentials.Session{PortainerURL: "https://portainer.example.com", Username: "admin", Token: "jwt"})
+	require.NoError(t, store.Save(filepath.Join(dir, "pctl")))
+
+	config := &Config{PortainerURL: "https://portainer.example.com/"}
+	require.NoError(t, config.resolveSession())
+	require.NotNil(t, config.Session)
+	assert.Equal(t, "admin", config.Session.Username)
+	assert.Equal(t, "jwt", config.Session.Token)
+
+	// Sessions of other instances are not used
+	config = &Config{PortainerURL: "https://portainer.prod.example.com"}
+	require.NoError(t, config.resolveSession())
+	assert.Nil(t, config.Session)
+}
+
+func TestExpandHome(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	path, err := ExpandHome("~/.config/pctl/token")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config/pctl/token"), path)
+
+	path, err = ExpandHome("relative/token")
+	require.NoError(t, err)
+	assert.Equal(t, "relative/token", path)
+}
+
+func TestConfig_FallbackAPIToken(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("ptr_from_file\n"), 0600))
+
+	// The token file is not read at load time when there is a session
+	config := &Config{APITokenFile: tokenPath, Session: &credentials.Session{Token: "jwt"}}
+	require.NoError(t, config.resolveAPIToken())
+	assert.Empty(t, config.APIToken)
+
+	token, err := config.FallbackAPIToken()
+	require.NoError(t, err)
+	assert.Equal(t, "ptr_from_file", token)
+
+	config = &Config{APIToken: "ptr_direct", Session: &credentials.Session{Token: "jwt"}}
+	token, err = config.FallbackAPIToken()
+	require.NoError(t, err)
+	assert.Equal(t, "ptr_direct", token)
+
+	config = &Config{Session: &credentials.Session{Token: "jwt"}}
+	token, err = config.FallbackAPIToken()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+}
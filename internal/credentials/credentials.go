@@ -0,0 +1,141 @@
+// Package credentials keeps the Portainer sessions opened with pctl login in a per-user store
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the credential store in the pctl configuration directory of the user
+const FileName = "credentials.json"
+
+// Session is a login to a Portainer instance
+type Session struct {
+	PortainerURL string    `json:"portainer_url"`
+	Username     string    `json:"username"`
+	Token        string    `json:"token"`                // JWT sent as a bearer token
+	ExpiresAt    time.Time `json:"expires_at,omitempty"` // zero when the token has no known expiry
+}
+
+// Expired reports whether the session token has expired
+func (s *Session) Expired() bool {
+	return !s.ExpiresAt.IsZero() && !time.Now().Before(s.ExpiresAt)
+}
+
+// Store holds the sessions of the user, at most one per Portainer instance
+type Store struct {
+	Sessions []Session `json:"sessions"`
+}
+
+// DefaultDir returns the pctl configuration directory of the user: $XDG_CONFIG_HOME/pctl
+// when XDG_CONFIG_HOME is set, ~/.config/pctl otherwise
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pctl"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "pctl"), nil
+}
+
+// Load reads the store from dir, an empty store is returned when it does not exist yet
+func Load(dir string) (*Store, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Store{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	var store Store
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials in %s: %w", filepath.Join(dir, FileName), err)
+	}
+	return &store, nil
+}
+
+// Save writes the store to dir. The tokens grant access to Portainer, so the directory and
+// the file are only accessible to the user, and the file is replaced atomically.
+func (s *Store) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+
+	// CreateTemp creates the file with owner-only permissions
+	tmp, err := os.CreateTemp(dir, FileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, FileName)); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the session of a Portainer instance
+func (s *Store) Get(portainerURL string) (*Session, bool) {
+	if i := s.sessionIndex(portainerURL); i >= 0 {
+		session := s.Sessions[i]
+		return &session, true
+	}
+	return nil, false
+}
+
+// Set stores a session, replacing the previous session of the same Portainer instance
+func (s *Store) Set(session Session) {
+	session.PortainerURL = normalizeURL(session.PortainerURL)
+	if i := s.sessionIndex(session.PortainerURL); i >= 0 {
+		s.Sessions[i] = session
+		return
+	}
+	s.Sessions = append(s.Sessions, session)
+}
+
+// Remove deletes the session of a Portainer instance and reports whether there was one
+func (s *Store) Remove(portainerURL string) bool {
+	i := s.sessionIndex(portainerURL)
+	if i < 0 {
+		return false
+	}
+	s.Sessions = append(s.Sessions[:i], s.Sessions[i+1:]...)
+	return true
+}
+
+func (s *Store) sessionIndex(portainerURL string) int {
+	portainerURL = normalizeURL(portainerURL)
+	for i, session := range s.Sessions {
+		if normalizeURL(session.PortainerURL) == portainerURL {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeURL makes https://portainer.example.com/ and https://portainer.example.com the same instance
+func normalizeURL(portainerURL string) string {
+	return strings.TrimRight(portainerURL, "/")
+}
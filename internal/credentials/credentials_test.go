@@ -0,0 +1,102 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/xdg", "pctl"), dir)
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	dir, err = DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".config", "pctl"), dir)
+}
+
+func TestLoad_Missing(t *testing.T) {
+	store, err := Load(filepath.Join(t.TempDir(), "pctl"))
+	require.NoError(t, err)
+	assert.Empty(t, store.Sessions)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("{not json"), 0600))
+
+	_, err := Load(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse credentials")
+}
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pctl")
+
+	store := &Store{}
+	store.Set(Session{
+		PortainerURL: "https://portainer.example.com",
+		Username:     "admin",
+		Token:        "header.payload.signature",
+		ExpiresAt:    time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, store.Save(dir))
+
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, store, loaded)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(dir, FileName))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		info, err = os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	}
+}
+
+func TestStore_Sessions(t *testing.T) {
+	store := &Store{}
+
+	_, ok := store.Get("https://portainer.example.com")
+	assert.False(t, ok)
+
+	store.Set(Session{PortainerURL: "https://portainer.example.com/", Username: "admin", Token: "first"})
+	store.Set(Session{PortainerURL: "https://portainer.prod.example.com", Username: "admin", Token: "prod"})
+
+	// A trailing slash designates the same instance
+	session, ok := store.Get("https://portainer.example.com")
+	require.True(t, ok)
+	assert.Equal(t, "first", session.Token)
+
+	store.Set(Session{PortainerURL: "https://portainer.example.com", Username: "jane", Token: "second"})
+	assert.Len(t, store.Sessions, 2)
+	session, _ = store.Get("https://portainer.example.com/")
+	assert.Equal(t, "jane", session.Username)
+	assert.Equal(t, "second", session.Token)
+
+	assert.True(t, store.Remove("https://portainer.example.com"))
+	assert.False(t, store.Remove("https://portainer.example.com"))
+	_, ok = store.Get("https://portainer.example.com")
+	assert.False(t, ok)
+	_, ok = store.Get("https://portainer.prod.example.com")
+	assert.True(t, ok)
+}
+
+func TestSession_Expired(t *testing.T) {
+	assert.False(t, (&Session{}).Expired())
+	assert.False(t, (&Session{ExpiresAt: time.Now().Add(time.Hour)}).Expired())
+	assert.True(t, (&Session{ExpiresAt: time.Now().Add(-time.Minute)}).Expired())
+}
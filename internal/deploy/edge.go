@@ -0,0 +1,65 @@
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/compose"
+	"github.com/deviantony/pctl/internal/config"
+)
+
+// PrepareEdgeCompose reads the configured compose file of an edge stack. Images cannot be built
+// for Edge environments, which Portainer only reaches asynchronously, so services with build
+// directives are rejected: their images must be pushed to a registry the devices can pull from.
+func PrepareEdgeCompose(cfg *config.Config) (*PreparedStack, error) {
+	composeContent, err := readRegistryCompose(cfg, "Edge")
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := LoadStackEnv(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack environment variables: %w", err)
+	}
+	if len(env) > 0 {
+		fmt.Println(successStyle.Render(fmt.Sprintf("✓ Loaded %d stack environment variable(s)", len(env))))
+	}
+
+	return &PreparedStack{
+		ComposeContent: composeContent,
+		Env:            env,
+		ImageTags:      map[string]string{},
+	}, nil
+}
+
+// readRegistryCompose reads the configured compose file of a stack deployed to environments
+// pctl cannot build images for, the services with build directives are rejected
+func readRegistryCompose(cfg *config.Config, environmentKind string) (string, error) {
+	fmt.Println(infoStyle.Render("Reading compose file..."))
+	composeContent, err := compose.ReadComposeFile(cfg.ComposeFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read compose file: %w", err)
+	}
+	fmt.Println(successStyle.Render("✓ Compose file loaded"))
+
+	composeFile, err := compose.ParseComposeFile(composeContent)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	servicesWithBuild, err := composeFile.FindServicesWithBuild()
+	if err != nil {
+		return "", fmt.Errorf("failed to find services with build directives: %w", err)
+	}
+	if len(servicesWithBuild) > 0 {
+		names := make([]string, len(servicesWithBuild))
+		for i, service := range servicesWithBuild {
+			names[i] = service.ServiceName
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("images cannot be built for %s environments, push the images of %s to a registry and reference them with image: instead of build:", environmentKind, strings.Join(names, ", "))
+	}
+
+	return composeContent, nil
+}
@@ -0,0 +1,53 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareEdgeCompose(t *testing.T) {
+	composeContent := `services:
+  web:
+    image: registry.example.com/kiosk:1.4
+`
+	composePath := filepath.Join(t.TempDir(), "docker-compose.yml")
+	require.NoError(t, os.WriteFile(composePath, []byte(composeContent), 0644))
+
+	cfg := &config.Config{
+		StackName:   "kiosk",
+		ComposeFile: composePath,
+		Env:         map[string]string{"SITE": "eu"},
+	}
+
+	prepared, err := PrepareEdgeCompose(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, composeContent, prepared.ComposeContent)
+	assert.Equal(t, []portainer.EnvVar{{Name: "SITE", Value: "eu"}}, prepared.Env)
+	assert.False(t, prepared.HasBuild)
+}
+
+func TestPrepareEdgeCompose_BuildDirectives(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "api"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web"), 0755))
+	composePath := filepath.Join(dir, "docker-compose.yml")
+	require.NoError(t, os.WriteFile(composePath, []byte(`services:
+  web:
+    build: ./web
+  api:
+    build: ./api
+  cache:
+    image: redis:7
+`), 0644))
+
+	_, err := PrepareEdgeCompose(&config.Config{StackName: "kiosk", ComposeFile: composePath})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "images cannot be built for Edge environments")
+	assert.Contains(t, err.Error(), "api, web")
+}
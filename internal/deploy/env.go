@@ -0,0 +1,174 @@
+package deploy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+)
+
+// DotEnvFileName is the env file Docker Compose reads next to the compose file
+const DotEnvFileName = ".env"
+
+// LoadStackEnv returns the stack environment variables configured for the stack, sorted by name.
+// Variables are merged in order of precedence: the .env file next to the compose file,
+// the env_file entries in order, then the env map of pctl.yml.
+func LoadStackEnv(cfg *config.Config) ([]portainer.EnvVar, error) {
+	vars := make(map[string]string)
+
+	// The .env file is optional, like with Docker Compose
+	dotEnvPath := filepath.Join(filepath.Dir(cfg.ComposeFile), DotEnvFileName)
+	if _, err := os.Stat(dotEnvPath); err == nil {
+		if err := loadEnvFile(dotEnvPath, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range cfg.EnvFile {
+		if err := loadEnvFile(path, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, value := range cfg.Env {
+		vars[name] = value
+	}
+
+	return toEnvVars(vars), nil
+}
+
+// MergeEnv returns the variables to send to Portainer. Existing stack variables that are
+// not defined in env are kept only when preserve is true.
+func MergeEnv(env, existing []portainer.EnvVar, preserve bool) []portainer.EnvVar {
+	if !preserve || len(existing) == 0 {
+		return env
+	}
+
+	vars := make(map[string]string, len(env)+len(existing))
+	for _, v := range existing {
+		vars[v.Name] = v.Value
+	}
+	for _, v := range env {
+		vars[v.Name] = v.Value
+	}
+	return toEnvVars(vars)
+}
+
+// EnvNames returns the names of the variables, which are safe to display unlike their values
+func EnvNames(env []portainer.EnvVar) []string {
+	names := make([]string, len(env))
+	for i, v := range env {
+		names[i] = v.Name
+	}
+	return names
+}
+
+func toEnvVars(vars map[string]string) []portainer.EnvVar {
+	env := make([]portainer.EnvVar, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, portainer.EnvVar{Name: name, Value: value})
+	}
+	sort.Slice(env, func(i, j int) bool {
+		return env[i].Name < env[j].Name
+	})
+	return env
+}
+
+// loadEnvFile reads a dotenv file into vars, overriding existing entries
+func loadEnvFile(path string, vars map[string]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		name, value, ok, err := parseEnvLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if ok {
+			vars[name] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseEnvLine parses a KEY=VALUE dotenv line. Blank lines and comments are skipped (ok is false).
+// Values can be single quoted (literal), double quoted (supporting \n, \t, \" and \\ escapes)
+// or unquoted, in which case a " #" starts a comment.
+func parseEnvLine(line string) (name, value string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false, nil
+	}
+	line = strings.TrimPrefix(line, "export ")
+
+	name, value, found := strings.Cut(line, "=")
+	name = strings.TrimSpace(name)
+	if !found || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", false, fmt.Errorf("invalid line, expected KEY=VALUE")
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", "", false, fmt.Errorf("unterminated single quoted value for %s", name)
+		}
+		value = value[1 : end+1]
+	case strings.HasPrefix(value, `"`):
+		value, err = unquoteDouble(value)
+		if err != nil {
+			return "", "", false, fmt.Errorf("%w for %s", err, name)
+		}
+	default:
+		if idx := strings.Index(value, " #"); idx >= 0 {
+			value = strings.TrimSpace(value[:idx])
+		}
+	}
+
+	return name, value, true, nil
+}
+
+// unquoteDouble returns the content of a double quoted value, processing escapes
+func unquoteDouble(value string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if i+1 == len(value) {
+				break
+			}
+			i++
+			switch value[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			default:
+				b.WriteByte(value[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated double quoted value")
+}
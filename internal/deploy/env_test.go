@@ -0,0 +1,160 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvLine(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		expectedName  string
+		expectedValue string
+		expectSkip    bool
+		expectError   bool
+	}{
+		{name: "simple", line: "KEY=value", expectedName: "KEY", expectedValue: "value"},
+		{name: "spaces around", line: "  KEY = value  ", expectedName: "KEY", expectedValue: "value"},
+		{name: "empty value", line: "KEY=", expectedName: "KEY", expectedValue: ""},
+		{name: "export prefix", line: "export KEY=value", expectedName: "KEY", expectedValue: "value"},
+		{name: "value with equals", line: "URL=postgres://u:p@db/app?ssl=true", expectedName: "URL", expectedValue: "postgres://u:p@db/app?ssl=true"},
+		{name: "inline comment", line: "KEY=value # comment", expectedName: "KEY", expectedValue: "value"},
+		{name: "hash without space", line: "KEY=val#ue", expectedName: "KEY", expectedValue: "val#ue"},
+		{name: "single quoted", line: `KEY='a $b \n # c'`, expectedName: "KEY", expectedValue: `a $b \n # c`},
+		{name: "double quoted", line: `KEY="line1\nline2 \"quoted\""`, expectedName: "KEY", expectedValue: "line1\nline2 \"quoted\""},
+		{name: "double quoted with comment", line: `KEY="value" # comment`, expectedName: "KEY", expectedValue: "value"},
+		{name: "comment", line: "# KEY=value", expectSkip: true},
+		{name: "blank", line: "   ", expectSkip: true},
+		{name: "missing equals", line: "KEY", expectError: true},
+		{name: "space in name", line: "MY KEY=value", expectError: true},
+		{name: "unterminated single quote", line: "KEY='value", expectError: true},
+		{name: "unterminated double quote", line: `KEY="value`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, value, ok, err := parseEnvLine(tt.line)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expectSkip {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, tt.expectedName, name)
+			assert.Equal(t, tt.expectedValue, value)
+		})
+	}
+}
+
+func TestLoadStackEnv(t *testing.T) {
+	tempDir := t.TempDir()
+	composeDir := filepath.Join(tempDir, "app")
+	require.NoError(t, os.MkdirAll(composeDir, 0755))
+
+	// .env next to the compose file has the lowest precedence
+	require.NoError(t, os.WriteFile(filepath.Join(composeDir, ".env"), []byte("A=dotenv\nB=dotenv\nC=dotenv\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "first.env"), []byte("B=first\nC=first\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "second.env"), []byte("C=second\n"), 0644))
+
+	cfg := &config.Config{
+		ComposeFile: filepath.Join(composeDir, "docker-compose.yml"),
+		EnvFile:     []string{filepath.Join(tempDir, "first.env"), filepath.Join(tempDir, "second.env")},
+		Env:         map[string]string{"D": "config"},
+	}
+
+	env, err := LoadStackEnv(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, []portainer.EnvVar{
+		{Name: "A", Value: "dotenv"},
+		{Name: "B", Value: "first"},
+		{Name: "C", Value: "second"},
+		{Name: "D", Value: "config"},
+	}, env)
+}
+
+func TestLoadStackEnv_EnvOverridesFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "app.env"), []byte("KEY=file\n"), 0644))
+
+	cfg := &config.Config{
+		ComposeFile: filepath.Join(tempDir, "docker-compose.yml"),
+		EnvFile:     []string{filepath.Join(tempDir, "app.env")},
+		Env:         map[string]string{"KEY": "config"},
+	}
+
+	env, err := LoadStackEnv(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, []portainer.EnvVar{{Name: "KEY", Value: "config"}}, env)
+}
+
+func TestLoadStackEnv_Empty(t *testing.T) {
+	cfg := &config.Config{ComposeFile: filepath.Join(t.TempDir(), "docker-compose.yml")}
+
+	env, err := LoadStackEnv(cfg)
+	require.NoError(t, err)
+
+	assert.Empty(t, env)
+}
+
+func TestLoadStackEnv_MissingEnvFile(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		ComposeFile: filepath.Join(tempDir, "docker-compose.yml"),
+		EnvFile:     []string{filepath.Join(tempDir, "missing.env")},
+	}
+
+	env, err := LoadStackEnv(cfg)
+
+	assert.Error(t, err)
+	assert.Nil(t, env)
+	assert.Contains(t, err.Error(), "failed to open env file")
+}
+
+func TestLoadStackEnv_InvalidLine(t *testing.T) {
+	tempDir := t.TempDir()
+	envPath := filepath.Join(tempDir, "app.env")
+	require.NoError(t, os.WriteFile(envPath, []byte("OK=1\nnot a variable\n"), 0644))
+
+	cfg := &config.Config{
+		ComposeFile: filepath.Join(tempDir, "docker-compose.yml"),
+		EnvFile:     []string{envPath},
+	}
+
+	_, err := LoadStackEnv(cfg)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "app.env:2")
+}
+
+func TestMergeEnv(t *testing.T) {
+	env := []portainer.EnvVar{{Name: "A", Value: "new"}, {Name: "C", Value: "new"}}
+	existing := []portainer.EnvVar{{Name: "A", Value: "old"}, {Name: "B", Value: "ui-only"}}
+
+	// Without preserve, only the configured variables are sent
+	assert.Equal(t, env, MergeEnv(env, existing, false))
+
+	// With preserve, unknown existing variables are kept and configured ones win
+	assert.Equal(t, []portainer.EnvVar{
+		{Name: "A", Value: "new"},
+		{Name: "B", Value: "ui-only"},
+		{Name: "C", Value: "new"},
+	}, MergeEnv(env, existing, true))
+}
+
+func TestEnvNames(t *testing.T) {
+	env := []portainer.EnvVar{{Name: "A", Value: "secret"}, {Name: "B", Value: "secret"}}
+	assert.Equal(t, []string{"A", "B"}, EnvNames(env))
+}
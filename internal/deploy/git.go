@@ -0,0 +1,44 @@
+package deploy
+
+import (
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+)
+
+// GitRepository returns the repository Portainer deploys a git stack from
+func GitRepository(git *config.GitSource) portainer.GitRepository {
+	return portainer.GitRepository{
+		URL:             git.URL,
+		ReferenceName:   git.Ref,
+		ComposeFile:     git.ComposePath,
+		AdditionalFiles: git.AdditionalFiles,
+		Username:        git.Username,
+		Password:        git.Password,
+		CredentialID:    git.CredentialID,
+		TLSSkipVerify:   git.TLSSkipVerify,
+	}
+}
+
+// GitAutoUpdate returns the auto update settings of a git stack, nil when Portainer should not
+// redeploy it by itself. The webhook of the deployed stack is kept so that its URL does not change.
+func GitAutoUpdate(git *config.GitSource, existingStack *portainer.Stack) *portainer.AutoUpdateSettings {
+	if git.AutoUpdate == nil {
+		return nil
+	}
+
+	settings := &portainer.AutoUpdateSettings{
+		ForceUpdate:    git.AutoUpdate.ForceUpdate,
+		ForcePullImage: git.AutoUpdate.PullImage,
+	}
+	if git.AutoUpdate.Interval > 0 {
+		settings.Interval = git.AutoUpdate.Interval.String()
+	}
+	if git.AutoUpdate.Webhook {
+		if existingStack != nil && existingStack.AutoUpdate != nil && existingStack.AutoUpdate.Webhook != "" {
+			settings.Webhook = existingStack.AutoUpdate.Webhook
+		} else {
+			settings.Webhook = portainer.NewWebhookID()
+		}
+	}
+	return settings
+}
@@ -0,0 +1,53 @@
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitRepository(t *testing.T) {
+	repo := GitRepository(&config.GitSource{
+		URL:             "https://github.com/acme/myapp.git",
+		Ref:             "refs/heads/main",
+		ComposePath:     "docker-compose.yml",
+		AdditionalFiles: []string{"docker-compose.prod.yml"},
+		CredentialID:    2,
+	})
+
+	assert.Equal(t, portainer.GitRepository{
+		URL:             "https://github.com/acme/myapp.git",
+		ReferenceName:   "refs/heads/main",
+		ComposeFile:     "docker-compose.yml",
+		AdditionalFiles: []string{"docker-compose.prod.yml"},
+		CredentialID:    2,
+	}, repo)
+}
+
+func TestGitAutoUpdate(t *testing.T) {
+	assert.Nil(t, GitAutoUpdate(&config.GitSource{}, nil))
+
+	git := &config.GitSource{AutoUpdate: &config.GitAutoUpdate{Interval: 5 * time.Minute, PullImage: true}}
+	assert.Equal(t, &portainer.AutoUpdateSettings{Interval: "5m0s", ForcePullImage: true}, GitAutoUpdate(git, nil))
+}
+
+func TestGitAutoUpdate_Webhook(t *testing.T) {
+	git := &config.GitSource{AutoUpdate: &config.GitAutoUpdate{Webhook: true}}
+
+	// A new stack gets a new webhook
+	settings := GitAutoUpdate(git, nil)
+	require.NotNil(t, settings)
+	assert.NotEmpty(t, settings.Webhook)
+	assert.Empty(t, settings.Interval)
+
+	// The webhook of a deployed stack is kept so that its URL does not change
+	existing := &portainer.Stack{AutoUpdate: &portainer.AutoUpdateSettings{Webhook: "existing-id"}}
+	assert.Equal(t, "existing-id", GitAutoUpdate(git, existing).Webhook)
+
+	existing = &portainer.Stack{GitConfig: &portainer.GitConfig{URL: "https://github.com/acme/myapp.git"}}
+	assert.NotEmpty(t, GitAutoUpdate(git, existing).Webhook)
+}
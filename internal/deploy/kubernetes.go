@@ -0,0 +1,67 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/config"
+)
+
+// PreparedManifests holds the content of a Kubernetes stack ready to be sent to Portainer
+type PreparedManifests struct {
+	Content       string
+	ComposeFormat bool // Content is the compose file, converted to manifests by Portainer
+}
+
+// PrepareKubernetes reads the manifests of a Kubernetes stack: the configured manifest files
+// joined into a single multi-document file, or the compose file when there are none. Images
+// cannot be built through the Kubernetes proxy, services with build directives are rejected.
+func PrepareKubernetes(cfg *config.Config) (*PreparedManifests, error) {
+	kubernetes, err := cfg.GetKubernetes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(kubernetes.Manifests) == 0 {
+		composeContent, err := readRegistryCompose(cfg, "Kubernetes")
+		if err != nil {
+			return nil, err
+		}
+		return &PreparedManifests{Content: composeContent, ComposeFormat: true}, nil
+	}
+
+	fmt.Println(infoStyle.Render("Reading Kubernetes manifests..."))
+	manifests := make([]string, len(kubernetes.Manifests))
+	for i, path := range kubernetes.Manifests {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest file: %w", err)
+		}
+		manifests[i] = string(data)
+	}
+
+	content := JoinManifests(manifests)
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("the manifest files %s are empty", strings.Join(kubernetes.Manifests, ", "))
+	}
+	fmt.Println(successStyle.Render(fmt.Sprintf("✓ Loaded %d manifest file(s)", len(manifests))))
+
+	return &PreparedManifests{Content: content}, nil
+}
+
+// JoinManifests concatenates Kubernetes manifest files into a single multi-document YAML file
+func JoinManifests(manifests []string) string {
+	documents := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		manifest = strings.TrimSpace(manifest)
+		manifest = strings.TrimSpace(strings.TrimPrefix(manifest, "---"))
+		if manifest != "" {
+			documents = append(documents, manifest)
+		}
+	}
+	if len(documents) == 0 {
+		return ""
+	}
+	return strings.Join(documents, "\n---\n") + "\n"
+}
@@ -0,0 +1,66 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareKubernetes_Compose(t *testing.T) {
+	composeContent := `services:
+  web:
+    image: registry.example.com/shop:2.1
+`
+	composePath := filepath.Join(t.TempDir(), "docker-compose.yml")
+	require.NoError(t, os.WriteFile(composePath, []byte(composeContent), 0644))
+
+	prepared, err := PrepareKubernetes(&config.Config{StackName: "shop", ComposeFile: composePath, Kind: config.KindKubernetes})
+	require.NoError(t, err)
+	assert.Equal(t, composeContent, prepared.Content)
+	assert.True(t, prepared.ComposeFormat)
+}
+
+func TestPrepareKubernetes_ComposeBuildDirectives(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web"), 0755))
+	composePath := filepath.Join(dir, "docker-compose.yml")
+	require.NoError(t, os.WriteFile(composePath, []byte("services:\n  web:\n    build: ./web\n"), 0644))
+
+	_, err := PrepareKubernetes(&config.Config{StackName: "shop", ComposeFile: composePath, Kind: config.KindKubernetes})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "images cannot be built for Kubernetes environments")
+}
+
+func TestPrepareKubernetes_Manifests(t *testing.T) {
+	dir := t.TempDir()
+	deployment := filepath.Join(dir, "deployment.yml")
+	service := filepath.Join(dir, "service.yml")
+	require.NoError(t, os.WriteFile(deployment, []byte("apiVersion: apps/v1\nkind: Deployment\n"), 0644))
+	require.NoError(t, os.WriteFile(service, []byte("---\napiVersion: v1\nkind: Service\n"), 0644))
+
+	cfg := &config.Config{
+		StackName:   "shop",
+		ComposeFile: filepath.Join(dir, "missing-compose.yml"),
+		Kind:        config.KindKubernetes,
+		Kubernetes:  &config.KubernetesConfig{Manifests: []string{deployment, service}},
+	}
+
+	prepared, err := PrepareKubernetes(cfg)
+	require.NoError(t, err)
+	assert.False(t, prepared.ComposeFormat)
+	assert.Equal(t, "apiVersion: apps/v1\nkind: Deployment\n---\napiVersion: v1\nkind: Service\n", prepared.Content)
+
+	cfg.Kubernetes.Manifests = []string{filepath.Join(dir, "missing.yml")}
+	_, err = PrepareKubernetes(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read manifest file")
+}
+
+func TestJoinManifests(t *testing.T) {
+	assert.Equal(t, "", JoinManifests([]string{"", "---\n"}))
+	assert.Equal(t, "a: 1\n---\nb: 2\n", JoinManifests([]string{"a: 1\n", "\n", "---\nb: 2"}))
+}
@@ -0,0 +1,145 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deviantony/pctl/internal/build"
+	"github.com/deviantony/pctl/internal/compose"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	infoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+)
+
+// Options controls how the compose file is prepared for deployment
+type Options struct {
+	ForceRebuild bool // force rebuild of images (sets build.ForceBuild, which includes no-cache)
+	DryRun       bool // resolve image tags from the build contexts without building
+}
+
+// PreparedStack holds the compose content ready to be sent to Portainer
+type PreparedStack struct {
+	ComposeContent string
+	Env            []portainer.EnvVar // stack environment variables from pctl.yml and env files
+	HasBuild       bool               // true when images were built for this deployment
+	ImageTags      map[string]string  // service name -> built image tag
+}
+
+// PrepareCompose reads the configured compose file, builds the images of services with
+// build directives and returns the compose content to deploy
+func PrepareCompose(ctx context.Context, client *portainer.Client, cfg *config.Config, opts Options) (*PreparedStack, error) {
+	// Read compose file
+	fmt.Println(infoStyle.Render("Reading compose file..."))
+	composeContent, err := compose.ReadComposeFile(cfg.ComposeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+	fmt.Println(successStyle.Render("✓ Compose file loaded"))
+
+	// Load stack environment variables before building so that errors are reported early
+	env, err := LoadStackEnv(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack environment variables: %w", err)
+	}
+	if len(env) > 0 {
+		fmt.Println(successStyle.Render(fmt.Sprintf("✓ Loaded %d stack environment variable(s)", len(env))))
+	}
+
+	// Parse compose file to check for build directives
+	composeFile, err := compose.ParseComposeFile(composeContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+
+	// Check if there are build directives
+	hasBuild, err := composeFile.HasBuildDirectives()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for build directives: %w", err)
+	}
+
+	if !hasBuild {
+		fmt.Println(infoStyle.Render("No build directives found, using compose file as-is"))
+		return &PreparedStack{
+			ComposeContent: composeContent,
+			Env:            env,
+			ImageTags:      map[string]string{},
+		}, nil
+	}
+
+	// Get build configuration
+	buildConfig := cfg.GetBuildConfig()
+
+	// Apply CLI override if requested
+	if opts.ForceRebuild {
+		buildConfig.ForceBuild = true
+		fmt.Println(infoStyle.Render("Force rebuild enabled: force_build=true (no-cache)"))
+	}
+
+	// Validate build configuration
+	if err := buildConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid build configuration: %w", err)
+	}
+
+	fmt.Println(infoStyle.Render("Build directives detected, processing builds..."))
+
+	// Find services with build directives
+	servicesWithBuild, err := composeFile.FindServicesWithBuild()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find services with build directives: %w", err)
+	}
+
+	// Validate build contexts
+	if err := composeFile.ValidateBuildContexts(); err != nil {
+		return nil, fmt.Errorf("build context validation failed: %w", err)
+	}
+
+	// Create build orchestrator
+	logger := build.NewStyledBuildLogger("BUILD")
+	orchestrator := build.NewBuildOrchestrator(client, buildConfig, cfg.EnvironmentID, cfg.StackName, logger)
+
+	var imageTags map[string]string
+	if opts.DryRun {
+		// Tags are content hashes, so they match what a build would produce
+		fmt.Println(infoStyle.Render("Dry run: skipping builds, resolving image tags from build contexts"))
+		imageTags, err = orchestrator.ResolveTags(servicesWithBuild)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve image tags: %w", err)
+		}
+	} else {
+		// Build services
+		imageTags, err = orchestrator.BuildServices(ctx, servicesWithBuild)
+		if err != nil {
+			return nil, fmt.Errorf("build failed: %w", err)
+		}
+	}
+
+	// Transform compose file
+	transformer, err := compose.TransformComposeFile(composeContent, imageTags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transform compose file: %w", err)
+	}
+
+	// Validate transformation
+	if err := transformer.ValidateTransformation(); err != nil {
+		return nil, fmt.Errorf("compose transformation validation failed: %w", err)
+	}
+
+	if opts.DryRun {
+		fmt.Println(successStyle.Render("✓ Compose file transformed"))
+	} else {
+		fmt.Println(successStyle.Render("✓ Build completed and compose file transformed"))
+	}
+
+	return &PreparedStack{
+		ComposeContent: transformer.TransformedContent,
+		Env:            env,
+		HasBuild:       !opts.DryRun,
+		ImageTags:      imageTags,
+	}, nil
+}
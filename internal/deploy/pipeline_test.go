@@ -0,0 +1,56 @@
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareCompose_NoBuild(t *testing.T) {
+	tempDir := t.TempDir()
+	composeContent := `version: '3.8'
+services:
+  web:
+    image: nginx:alpine
+`
+	composePath := filepath.Join(tempDir, "docker-compose.yml")
+	require.NoError(t, os.WriteFile(composePath, []byte(composeContent), 0644))
+
+	cfg := &config.Config{
+		EnvironmentID: 1,
+		StackName:     "test-stack",
+		ComposeFile:   composePath,
+	}
+
+	// No build directives means the client is never used
+	client := portainer.NewClient("https://portainer.example.com", "test-token")
+
+	prepared, err := PrepareCompose(context.Background(), client, cfg, Options{})
+
+	require.NoError(t, err)
+	assert.False(t, prepared.HasBuild)
+	assert.Equal(t, composeContent, prepared.ComposeContent)
+	assert.Empty(t, prepared.ImageTags)
+}
+
+func TestPrepareCompose_MissingComposeFile(t *testing.T) {
+	cfg := &config.Config{
+		EnvironmentID: 1,
+		StackName:     "test-stack",
+		ComposeFile:   filepath.Join(t.TempDir(), "missing.yml"),
+	}
+
+	client := portainer.NewClient("https://portainer.example.com", "test-token")
+
+	prepared, err := PrepareCompose(context.Background(), client, cfg, Options{})
+
+	assert.Error(t, err)
+	assert.Nil(t, prepared)
+	assert.Contains(t, err.Error(), "failed to read compose file")
+}
@@ -0,0 +1,202 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deviantony/pctl/internal/compose"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	addedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	removedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	modifiedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	headerStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Bold(true)
+)
+
+// Plan describes what deploying a prepared stack would change in Portainer
+type Plan struct {
+	Stack           *portainer.Stack // nil when the stack would be created
+	DeployedContent string
+	LocalContent    string
+	Compose         *compose.ComposeDiff
+	Env             []compose.FieldChange // stack environment variable changes, values are not included
+}
+
+// HasChanges reports whether deploying would change the stack
+func (p *Plan) HasChanges() bool {
+	return p.Stack == nil || p.Compose.HasChanges() || len(p.Env) > 0
+}
+
+// BuildPlan compares the deployed stack with the prepared one. existingStack is nil when
+// the stack does not exist yet.
+func BuildPlan(ctx context.Context, client *portainer.Client, cfg *config.Config, existingStack *portainer.Stack, prepared *PreparedStack) (*Plan, error) {
+	plan := &Plan{
+		Stack:        existingStack,
+		LocalContent: prepared.ComposeContent,
+	}
+
+	var existingEnv []portainer.EnvVar
+	if existingStack != nil {
+		content, err := client.GetStackFile(ctx, existingStack.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployed stack file: %w", err)
+		}
+		plan.DeployedContent = content
+		existingEnv = existingStack.Env
+	}
+
+	diff, err := compose.DiffComposeFiles(plan.DeployedContent, plan.LocalContent)
+	if err != nil {
+		return nil, err
+	}
+	plan.Compose = diff
+
+	env := MergeEnv(prepared.Env, existingEnv, cfg.PreserveEnv)
+	plan.Env = diffEnv(existingEnv, env)
+
+	return plan, nil
+}
+
+// diffEnv compares stack environment variables by name, values may be secrets
+func diffEnv(existing, env []portainer.EnvVar) []compose.FieldChange {
+	oldValues := make(map[string]string, len(existing))
+	for _, v := range existing {
+		oldValues[v.Name] = v.Value
+	}
+	newValues := make(map[string]string, len(env))
+	for _, v := range env {
+		newValues[v.Name] = v.Value
+	}
+
+	var changes []compose.FieldChange
+	for _, v := range existing {
+		if _, ok := newValues[v.Name]; !ok {
+			changes = append(changes, compose.FieldChange{Field: "env", Kind: compose.ChangeRemoved, Key: v.Name})
+		}
+	}
+	for _, v := range env {
+		oldValue, ok := oldValues[v.Name]
+		switch {
+		case !ok:
+			changes = append(changes, compose.FieldChange{Field: "env", Kind: compose.ChangeAdded, Key: v.Name})
+		case oldValue != v.Value:
+			changes = append(changes, compose.FieldChange{Field: "env", Kind: compose.ChangeModified, Key: v.Name})
+		}
+	}
+	return changes
+}
+
+// Print displays the semantic diff followed by the unified diff of the compose files
+func (p *Plan) Print() {
+	fmt.Println(headerStyle.Render("Deployment plan"))
+	if p.Stack == nil {
+		fmt.Println("  Stack does not exist, it will be created")
+	} else {
+		fmt.Printf("  Stack %s (ID: %d) will be updated\n", p.Stack.Name, p.Stack.ID)
+	}
+	fmt.Println()
+
+	if !p.HasChanges() {
+		fmt.Println(addedStyle.Render("✓ No changes, the deployed stack matches the local compose file"))
+		return
+	}
+
+	if len(p.Compose.Services) > 0 {
+		fmt.Println(headerStyle.Render("Services:"))
+		for _, service := range p.Compose.Services {
+			fmt.Println("  " + changeStyle(service.Kind).Render(changeSymbol(service.Kind)+" "+service.Name))
+			for _, change := range service.Changes {
+				fmt.Println("      " + formatChange(change))
+			}
+		}
+		fmt.Println()
+	}
+
+	printChanges("Volumes:", p.Compose.Volumes)
+	printChanges("Networks:", p.Compose.Networks)
+	printChanges("Stack environment variables:", p.Env)
+
+	if text := compose.UnifiedDiff(p.DeployedContent, p.LocalContent, "deployed", "local"); text != "" {
+		fmt.Println(headerStyle.Render("Compose file diff:"))
+		for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				fmt.Println(line)
+			case strings.HasPrefix(line, "+"):
+				fmt.Println(addedStyle.Render(line))
+			case strings.HasPrefix(line, "-"):
+				fmt.Println(removedStyle.Render(line))
+			case strings.HasPrefix(line, "@@"):
+				fmt.Println(headerStyle.Render(line))
+			default:
+				fmt.Println(line)
+			}
+		}
+		fmt.Println()
+	}
+}
+
+func printChanges(title string, changes []compose.FieldChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Println(headerStyle.Render(title))
+	for _, change := range changes {
+		fmt.Println("  " + formatChange(change))
+	}
+	fmt.Println()
+}
+
+// formatChange renders a change as "~ field[key]: old -> new"
+func formatChange(change compose.FieldChange) string {
+	symbol := changeSymbol(change.Kind)
+	style := changeStyle(change.Kind)
+
+	// Set entries (ports, volumes, network names, stack env names) carry no value of their own
+	isSetEntry := func(value string) bool { return value == "" || value == change.Key }
+	if change.Key != "" && (change.Field == "env" || isSetEntry(change.Old) && isSetEntry(change.New)) {
+		return style.Render(fmt.Sprintf("%s %s: %s", symbol, change.Field, change.Key))
+	}
+
+	name := change.Field
+	if change.Key != "" {
+		name = fmt.Sprintf("%s[%s]", change.Field, change.Key)
+	}
+
+	switch change.Kind {
+	case compose.ChangeAdded:
+		return style.Render(fmt.Sprintf("%s %s: %s", symbol, name, change.New))
+	case compose.ChangeRemoved:
+		return style.Render(fmt.Sprintf("%s %s: %s", symbol, name, change.Old))
+	default:
+		return style.Render(fmt.Sprintf("%s %s: %s -> %s", symbol, name, change.Old, change.New))
+	}
+}
+
+func changeSymbol(kind compose.ChangeKind) string {
+	switch kind {
+	case compose.ChangeAdded:
+		return "+"
+	case compose.ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func changeStyle(kind compose.ChangeKind) lipgloss.Style {
+	switch kind {
+	case compose.ChangeAdded:
+		return addedStyle
+	case compose.ChangeRemoved:
+		return removedStyle
+	default:
+		return modifiedStyle
+	}
+}
@@ -0,0 +1,112 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deviantony/pctl/internal/compose"
+	"github.com/deviantony/pctl/internal/config"
+	"github.com/deviantony/pctl/internal/portainer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPlan_ExistingStack(t *testing.T) {
+	deployed := `services:
+  web:
+    image: nginx:1.25
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/stacks/7/file", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(portainer.StackFile{StackFileContent: deployed})
+	}))
+	defer server.Close()
+
+	client := portainer.NewClient(server.URL, "test-token")
+	stack := &portainer.Stack{
+		ID:   7,
+		Name: "test-stack",
+		Env: []portainer.EnvVar{
+			{Name: "KEPT", Value: "same"},
+			{Name: "OLD", Value: "x"},
+			{Name: "TOKEN", Value: "before"},
+		},
+	}
+	prepared := &PreparedStack{
+		ComposeContent: "services:\n  web:\n    image: nginx:1.27\n",
+		Env: []portainer.EnvVar{
+			{Name: "KEPT", Value: "same"},
+			{Name: "NEW", Value: "y"},
+			{Name: "TOKEN", Value: "after"},
+		},
+	}
+
+	plan, err := BuildPlan(context.Background(), client, &config.Config{}, stack, prepared)
+	require.NoError(t, err)
+
+	assert.True(t, plan.HasChanges())
+	assert.Equal(t, deployed, plan.DeployedContent)
+	require.Len(t, plan.Compose.Services, 1)
+	assert.Equal(t, []compose.FieldChange{
+		{Field: "image", Kind: compose.ChangeModified, Old: "nginx:1.25", New: "nginx:1.27"},
+	}, plan.Compose.Services[0].Changes)
+
+	// Values are never part of the env changes
+	assert.Equal(t, []compose.FieldChange{
+		{Field: "env", Kind: compose.ChangeRemoved, Key: "OLD"},
+		{Field: "env", Kind: compose.ChangeAdded, Key: "NEW"},
+		{Field: "env", Kind: compose.ChangeModified, Key: "TOKEN"},
+	}, plan.Env)
+}
+
+func TestBuildPlan_PreserveEnv(t *testing.T) {
+	content := "services:\n  web:\n    image: nginx:1.27\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(portainer.StackFile{StackFileContent: content})
+	}))
+	defer server.Close()
+
+	client := portainer.NewClient(server.URL, "test-token")
+	stack := &portainer.Stack{ID: 1, Env: []portainer.EnvVar{{Name: "MANUAL", Value: "1"}}}
+	prepared := &PreparedStack{ComposeContent: content}
+
+	plan, err := BuildPlan(context.Background(), client, &config.Config{PreserveEnv: true}, stack, prepared)
+	require.NoError(t, err)
+
+	assert.Empty(t, plan.Env)
+	assert.False(t, plan.HasChanges())
+}
+
+func TestBuildPlan_NewStack(t *testing.T) {
+	// The deployed stack file is not fetched when the stack does not exist
+	client := portainer.NewClient("https://portainer.example.com", "test-token")
+	prepared := &PreparedStack{ComposeContent: "services:\n  web:\n    image: nginx\n"}
+
+	plan, err := BuildPlan(context.Background(), client, &config.Config{}, nil, prepared)
+	require.NoError(t, err)
+
+	assert.True(t, plan.HasChanges())
+	assert.Empty(t, plan.DeployedContent)
+	require.Len(t, plan.Compose.Services, 1)
+	assert.Equal(t, compose.ChangeAdded, plan.Compose.Services[0].Kind)
+}
+
+func TestBuildPlan_StackFileError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	// Without retries, the server error is returned at once
+	client := portainer.NewClientWithOptions(server.URL, "test-token", portainer.ClientOptions{Retry: portainer.RetryPolicy{MaxAttempts: 1}})
+	prepared := &PreparedStack{ComposeContent: "services: {}\n"}
+
+	_, err := BuildPlan(context.Background(), client, &config.Config{}, &portainer.Stack{ID: 1}, prepared)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get deployed stack file")
+}
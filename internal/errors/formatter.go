@@ -1,9 +1,18 @@
 package errors
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/deviantony/pctl/internal/portainer"
 )
 
 var (
@@ -12,57 +21,178 @@ var (
 	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
 )
 
-// FormatError converts technical errors to user-friendly messages
+// FormatError converts technical errors to user-friendly messages.
+// Errors are classified by type, never by their message text.
 func FormatError(err error) string {
-	errStr := err.Error()
-
-	if containsAny(errStr, []string{"context deadline exceeded", "timeout"}) {
-		return fmt.Sprintf("%s\n\n%s\n%s\n%s\n%s\n%s",
-			warningStyle.Render("Network connection timeout"),
-			infoStyle.Render("This usually means:"),
-			infoStyle.Render("• Your internet connection is unstable"),
-			infoStyle.Render("• The Portainer server is slow to respond"),
-			infoStyle.Render("• The server might be temporarily unavailable"),
-			infoStyle.Render("\nPlease check your connection and try again."))
+	if errors.Is(err, context.Canceled) {
+		return warningStyle.Render("Operation cancelled")
 	}
 
-	if containsAny(errStr, []string{"connection refused"}) {
-		return fmt.Sprintf("%s\n\n%s\n%s\n%s\n%s\n%s",
-			warningStyle.Render("Connection refused"),
-			infoStyle.Render("This usually means:"),
-			infoStyle.Render("• The Portainer URL is incorrect"),
-			infoStyle.Render("• The Portainer server is not running"),
-			infoStyle.Render("• There's a firewall blocking the connection"),
-			infoStyle.Render("\nPlease verify your Portainer URL and try again."))
+	var apiErr *portainer.APIError
+	if errors.As(err, &apiErr) {
+		return formatAPIError(apiErr)
 	}
 
-	if containsAny(errStr, []string{"certificate", "TLS"}) {
-		return fmt.Sprintf("%s\n\n%s\n%s\n%s\n%s\n%s",
-			warningStyle.Render("SSL/TLS certificate error"),
-			infoStyle.Render("This usually means:"),
-			infoStyle.Render("• The SSL certificate is invalid or expired"),
-			infoStyle.Render("• You're using a self-signed certificate"),
-			infoStyle.Render("• There's a certificate authority issue"),
-			infoStyle.Render("\nYou can try again or contact your administrator."))
+	switch {
+	case isTimeout(err):
+		return guidance("Network connection timeout",
+			[]string{
+				"Your internet connection is unstable",
+				"The Portainer server is slow to respond",
+				"The server might be temporarily unavailable",
+			},
+			"Please check your connection and try again.")
+	case isConnectionRefused(err):
+		return guidance("Connection refused",
+			[]string{
+				"The Portainer URL is incorrect",
+				"The Portainer server is not running",
+				"There's a firewall blocking the connection",
+			},
+			"Please verify your Portainer URL and try again.")
+	case isFingerprintMismatch(err):
+		return guidance("Server certificate does not match the pinned fingerprint",
+			[]string{
+				"The Portainer certificate was renewed",
+				"The Portainer URL points to another server",
+				"Something is intercepting the connection",
+			},
+			"Verify the new certificate with your administrator, then update tls.fingerprint in pctl.yml.")
+	case isCertificateError(err):
+		return guidance("SSL/TLS certificate error",
+			[]string{
+				"The SSL certificate is invalid or expired",
+				"You're using a self-signed certificate",
+				"There's a certificate authority issue",
+			},
+			"Set tls.ca_file to your private certificate authority, pin the certificate\n"+
+				"with tls.fingerprint (see 'pctl init'), or contact your administrator.")
 	}
 
 	// Generic error message
 	return fmt.Sprintf("%s\n\n%s\n%s",
 		warningStyle.Render("Operation failed"),
 		infoStyle.Render("Error details:"),
-		errorStyle.Render(errStr))
+		errorStyle.Render(err.Error()))
 }
 
-// containsAny checks if the string contains any of the substrings
-func containsAny(s string, substrings []string) bool {
-	for _, substr := range substrings {
-		if len(s) >= len(substr) {
-			for i := 0; i <= len(s)-len(substr); i++ {
-				if s[i:i+len(substr)] == substr {
-					return true
-				}
-			}
-		}
+// formatAPIError explains an error response returned by the Portainer API
+func formatAPIError(err *portainer.APIError) string {
+	var summary string
+
+	switch {
+	case err.StatusCode == http.StatusUnauthorized:
+		summary = guidance("Authentication failed",
+			[]string{
+				"The API token has expired or was revoked",
+				"The API token is incorrect",
+				"The 'pctl login' session has expired or was revoked",
+				"The token belongs to another Portainer instance",
+			},
+			"Create a new access token in Portainer (My account > Access tokens) and update\n"+
+				"api_token, api_token_file, api_token_command or PCTL_API_TOKEN, or run 'pctl login' again.")
+	case err.StatusCode == http.StatusForbidden:
+		summary = guidance("Permission denied",
+			[]string{
+				"Your Portainer role does not allow this operation",
+				"Your user or team has no access to this environment",
+				"The stack is restricted to another user or team",
+			},
+			"Ask your Portainer administrator for a role that can manage stacks\n"+
+				"on this environment (e.g. Environment administrator or Operator).")
+	case err.StatusCode == http.StatusNotFound:
+		summary = guidance("Resource not found",
+			[]string{
+				"The environment_id in pctl.yml is incorrect",
+				"The stack or container was removed outside of pctl",
+				"The Portainer version does not support this operation",
+			},
+			"Please check your configuration and try again.")
+	case err.StatusCode == http.StatusConflict:
+		summary = guidance("Conflicting resource",
+			[]string{
+				"A stack with the same name already exists",
+				"The resource is still in use",
+				"Another operation on the stack is in progress",
+			},
+			"Please check the current state with 'pctl ps' and try again.")
+	case err.StatusCode >= http.StatusInternalServerError:
+		summary = guidance("Portainer server error",
+			[]string{
+				"Portainer or the Docker engine could not process the request",
+				"The compose file was rejected by the Docker engine",
+				"The environment agent is unreachable",
+			},
+			"Please check the details below and the Portainer logs.")
+	default:
+		summary = warningStyle.Render("Portainer request failed")
+	}
+
+	details := []string{errorStyle.Render(err.Error())}
+	if err.Method != "" {
+		details = append(details, infoStyle.Render(fmt.Sprintf("%s %s (status %d)", err.Method, err.Path, err.StatusCode)))
+	}
+
+	return fmt.Sprintf("%s\n\n%s\n%s",
+		summary,
+		infoStyle.Render("Error details:"),
+		strings.Join(details, "\n"))
+}
+
+// guidance renders a title followed by the usual causes and a suggestion
+func guidance(title string, causes []string, advice string) string {
+	lines := []string{
+		warningStyle.Render(title),
+		"",
+		infoStyle.Render("This usually means:"),
+	}
+	for _, cause := range causes {
+		lines = append(lines, infoStyle.Render("• "+cause))
+	}
+	lines = append(lines, infoStyle.Render("\n"+advice))
+
+	return strings.Join(lines, "\n")
+}
+
+// isTimeout reports whether err is a deadline or network timeout
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isConnectionRefused reports whether err comes from failing to reach the server
+func isConnectionRefused(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
 	}
-	return false
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isFingerprintMismatch reports whether err comes from a server certificate that is not the pinned one
+func isFingerprintMismatch(err error) bool {
+	var mismatchErr *portainer.FingerprintMismatchError
+	return errors.As(err, &mismatchErr)
+}
+
+// isCertificateError reports whether err comes from the TLS handshake or certificate verification
+func isCertificateError(err error) bool {
+	var (
+		verificationErr  *tls.CertificateVerificationError
+		recordHeaderErr  tls.RecordHeaderError
+		unknownAuthority x509.UnknownAuthorityError
+		hostnameErr      x509.HostnameError
+		invalidErr       x509.CertificateInvalidError
+	)
+
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &recordHeaderErr) ||
+		errors.As(err, &unknownAuthority) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
 }
@@ -1,12 +1,29 @@
 package errors
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
 	"testing"
 
+	"github.com/deviantony/pctl/internal/portainer"
 	"github.com/stretchr/testify/assert"
 )
 
+// timeoutError is a net.Error that reports a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
 func TestFormatError_Timeout(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -15,17 +32,17 @@ func TestFormatError_Timeout(t *testing.T) {
 	}{
 		{
 			name:     "context deadline exceeded",
-			err:      errors.New("context deadline exceeded"),
+			err:      context.DeadlineExceeded,
 			expected: "Network connection timeout",
 		},
 		{
-			name:     "timeout error",
-			err:      errors.New("request timeout"),
+			name:     "wrapped deadline exceeded",
+			err:      fmt.Errorf("failed to make request: %w", context.DeadlineExceeded),
 			expected: "Network connection timeout",
 		},
 		{
-			name:     "timeout in error message",
-			err:      errors.New("operation failed: timeout occurred"),
+			name:     "net error timeout",
+			err:      &url.Error{Op: "Get", URL: "https://portainer.example.com", Err: timeoutError{}},
 			expected: "Network connection timeout",
 		},
 	}
@@ -51,12 +68,16 @@ func TestFormatError_ConnectionRefused(t *testing.T) {
 	}{
 		{
 			name:     "connection refused",
-			err:      errors.New("connection refused"),
+			err:      syscall.ECONNREFUSED,
 			expected: "Connection refused",
 		},
 		{
-			name:     "connection refused with details",
-			err:      errors.New("dial tcp: connection refused"),
+			name: "dial error",
+			err: fmt.Errorf("failed to make request: %w", &url.Error{
+				Op:  "Get",
+				URL: "https://portainer.example.com",
+				Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			}),
 			expected: "Connection refused",
 		},
 	}
@@ -81,18 +102,23 @@ func TestFormatError_Certificate(t *testing.T) {
 		expected string
 	}{
 		{
-			name:     "certificate error",
-			err:      errors.New("certificate verify failed"),
+			name:     "unknown authority",
+			err:      &url.Error{Op: "Get", URL: "https://portainer.example.com", Err: x509.UnknownAuthorityError{}},
+			expected: "SSL/TLS certificate error",
+		},
+		{
+			name:     "hostname mismatch",
+			err:      fmt.Errorf("failed to make request: %w", x509.HostnameError{Certificate: &x509.Certificate{}, Host: "portainer.example.com"}),
 			expected: "SSL/TLS certificate error",
 		},
 		{
-			name:     "TLS error",
-			err:      errors.New("TLS handshake failed"),
+			name:     "certificate verification",
+			err:      &tls.CertificateVerificationError{Err: x509.CertificateInvalidError{Reason: x509.Expired}},
 			expected: "SSL/TLS certificate error",
 		},
 		{
-			name:     "certificate with details",
-			err:      errors.New("x509: certificate signed by unknown authority"),
+			name:     "plain HTTP server",
+			err:      tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"},
 			expected: "SSL/TLS certificate error",
 		},
 	}
@@ -105,11 +131,19 @@ func TestFormatError_Certificate(t *testing.T) {
 			assert.Contains(t, result, "• The SSL certificate is invalid or expired")
 			assert.Contains(t, result, "• You're using a self-signed certificate")
 			assert.Contains(t, result, "• There's a certificate authority issue")
-			assert.Contains(t, result, "You can try again or contact your administrator.")
+			assert.Contains(t, result, "tls.ca_file")
 		})
 	}
 }
 
+func TestFormatError_Cancelled(t *testing.T) {
+	err := fmt.Errorf("failed to make request: %w", context.Canceled)
+
+	result := FormatError(err)
+	assert.Contains(t, result, "Operation cancelled")
+	assert.NotContains(t, result, "Operation failed")
+}
+
 func TestFormatError_Generic(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -143,162 +177,99 @@ func TestFormatError_Generic(t *testing.T) {
 	}
 }
 
-func TestContainsAny(t *testing.T) {
+func TestFormatError_FingerprintMismatch(t *testing.T) {
+	err := &url.Error{Op: "Get", URL: "https://portainer.example.com", Err: &portainer.FingerprintMismatchError{Expected: "AA:BB", Actual: "CC:DD"}}
+
+	result := FormatError(err)
+	assert.Contains(t, result, "Server certificate does not match the pinned fingerprint")
+	assert.Contains(t, result, "tls.fingerprint")
+	assert.NotContains(t, result, "SSL/TLS certificate error")
+}
+
+// Test that FormatError classifies errors by type rather than message text
+func TestFormatError_MessageTextIgnored(t *testing.T) {
 	tests := []struct {
-		name       string
-		s          string
-		substrings []string
-		expected   bool
+		name string
+		err  error
 	}{
-		{
-			name:       "contains first substring",
-			s:          "hello world",
-			substrings: []string{"hello", "goodbye"},
-			expected:   true,
-		},
-		{
-			name:       "contains second substring",
-			s:          "goodbye world",
-			substrings: []string{"hello", "goodbye"},
-			expected:   true,
-		},
-		{
-			name:       "contains substring in middle",
-			s:          "the quick brown fox",
-			substrings: []string{"brown", "red"},
-			expected:   true,
-		},
-		{
-			name:       "contains substring at end",
-			s:          "hello world",
-			substrings: []string{"world", "universe"},
-			expected:   true,
-		},
-		{
-			name:       "no match",
-			s:          "hello world",
-			substrings: []string{"goodbye", "farewell"},
-			expected:   false,
-		},
-		{
-			name:       "partial match not counted",
-			s:          "hello",
-			substrings: []string{"helloworld", "goodbye"},
-			expected:   false,
-		},
-		{
-			name:       "case sensitive match",
-			s:          "Hello World",
-			substrings: []string{"hello", "world"},
-			expected:   false,
-		},
-		{
-			name:       "exact match",
-			s:          "exact",
-			substrings: []string{"exact", "match"},
-			expected:   true,
-		},
+		{name: "TLS in container name", err: errors.New("container my-TLS-proxy exited with code 1")},
+		{name: "certificate in message", err: errors.New("failed to mount certificate volume")},
+		{name: "timeout in message", err: errors.New("healthcheck timeout option is invalid")},
+		{name: "connection refused in logs", err: errors.New("app: connection refused by upstream")},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := containsAny(tt.s, tt.substrings)
-			assert.Equal(t, tt.expected, result)
+			result := FormatError(tt.err)
+			assert.Contains(t, result, "Operation failed")
+			assert.Contains(t, result, tt.err.Error())
+			assert.NotContains(t, result, "SSL/TLS certificate error")
+			assert.NotContains(t, result, "Network connection timeout")
+			assert.NotContains(t, result, "Connection refused")
 		})
 	}
 }
 
-func TestContainsAny_EmptyString(t *testing.T) {
+// Test that the more specific classification wins when errors are combined
+func TestFormatError_ErrorPriority(t *testing.T) {
+	// A dial that timed out is reported as a timeout
+	dialTimeout := &net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}
+	result := FormatError(dialTimeout)
+	assert.Contains(t, result, "Network connection timeout")
+	assert.NotContains(t, result, "Connection refused")
+
+	// An API error whose message mentions a certificate is still an API error
+	apiErr := &portainer.APIError{StatusCode: http.StatusBadRequest, Message: "invalid certificate"}
+	result = FormatError(apiErr)
+	assert.Contains(t, result, "Portainer request failed")
+	assert.NotContains(t, result, "SSL/TLS certificate error")
+}
+
+func TestFormatError_APIError(t *testing.T) {
 	tests := []struct {
-		name       string
-		s          string
-		substrings []string
-		expected   bool
+		name     string
+		err      *portainer.APIError
+		expected []string
 	}{
 		{
-			name:       "empty string with non-empty substrings",
-			s:          "",
-			substrings: []string{"hello", "world"},
-			expected:   false,
+			name:     "unauthorized",
+			err:      &portainer.APIError{StatusCode: http.StatusUnauthorized, Method: http.MethodGet, Path: "/api/stacks", Message: "Invalid JWT token"},
+			expected: []string{"Authentication failed", "The API token has expired or was revoked", "PCTL_API_TOKEN", "GET /api/stacks (status 401)"},
 		},
 		{
-			name:       "empty string with empty substring",
-			s:          "",
-			substrings: []string{""},
-			expected:   true,
+			name:     "forbidden",
+			err:      &portainer.APIError{StatusCode: http.StatusForbidden, Method: http.MethodPut, Path: "/api/stacks/1", Message: "Access denied to resource"},
+			expected: []string{"Permission denied", "Your Portainer role does not allow this operation", "Portainer administrator", "PUT /api/stacks/1 (status 403)"},
 		},
 		{
-			name:       "empty string with empty substrings",
-			s:          "",
-			substrings: []string{},
-			expected:   false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := containsAny(tt.s, tt.substrings)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestContainsAny_NoMatch(t *testing.T) {
-	tests := []struct {
-		name       string
-		s          string
-		substrings []string
-		expected   bool
-	}{
-		{
-			name:       "no matching substrings",
-			s:          "hello world",
-			substrings: []string{"goodbye", "farewell", "adios"},
-			expected:   false,
+			name:     "not found",
+			err:      &portainer.APIError{StatusCode: http.StatusNotFound, Method: http.MethodGet, Path: "/api/endpoints/99", Message: "Unable to find an environment"},
+			expected: []string{"Resource not found", "environment_id", "Unable to find an environment"},
 		},
 		{
-			name:       "longer substrings than string",
-			s:          "hi",
-			substrings: []string{"hello", "world", "goodbye"},
-			expected:   false,
+			name:     "conflict",
+			err:      &portainer.APIError{StatusCode: http.StatusConflict, Message: "A stack with the normalized name 'app' already exists"},
+			expected: []string{"Conflicting resource", "already exists"},
 		},
 		{
-			name:       "empty substrings slice",
-			s:          "hello world",
-			substrings: []string{},
-			expected:   false,
+			name:     "server error",
+			err:      &portainer.APIError{StatusCode: http.StatusInternalServerError, Details: "compose up failed"},
+			expected: []string{"Portainer server error", "API request failed with status 500: compose up failed"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := containsAny(tt.s, tt.substrings)
-			assert.Equal(t, tt.expected, result)
+			result := FormatError(fmt.Errorf("failed to update stack: %w", tt.err))
+			for _, expected := range tt.expected {
+				assert.Contains(t, result, expected)
+			}
+			assert.Contains(t, result, "Error details:")
+			assert.NotContains(t, result, "Operation failed")
 		})
 	}
 }
 
-// Test that FormatError handles different error types correctly
-func TestFormatError_ErrorPriority(t *testing.T) {
-	// Test that timeout errors take priority over other patterns
-	timeoutErr := errors.New("context deadline exceeded: connection refused")
-	result := FormatError(timeoutErr)
-	assert.Contains(t, result, "Network connection timeout")
-	assert.NotContains(t, result, "Connection refused")
-
-	// Test that connection refused takes priority over certificate
-	connErr := errors.New("connection refused: certificate error")
-	result = FormatError(connErr)
-	assert.Contains(t, result, "Connection refused")
-	assert.NotContains(t, result, "SSL/TLS certificate error")
-
-	// Test that certificate takes priority over generic
-	certErr := errors.New("certificate error: something else")
-	result = FormatError(certErr)
-	assert.Contains(t, result, "SSL/TLS certificate error")
-	assert.NotContains(t, result, "Operation failed")
-}
-
 // Test that the formatting includes proper styling markers
 func TestFormatError_Styling(t *testing.T) {
 	result := FormatError(errors.New("test error"))
@@ -0,0 +1,88 @@
+package portainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// authRequest is the body of a login with a username and password
+type authRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// authResponse is the Portainer response to a successful login
+type authResponse struct {
+	JWT string `json:"jwt"`
+}
+
+// Authenticate logs in with a username and password and returns the JWT of the session.
+// The JWT is used as a bearer token instead of an API key, see ClientOptions.JWT.
+func (c *Client) Authenticate(ctx context.Context, username, password string) (string, error) {
+	jsonData, err := json.Marshal(authRequest{Username: username, Password: password})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, "POST", "/api/auth", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", c.handleErrorResponse(resp)
+	}
+
+	var auth authResponse
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if auth.JWT == "" {
+		return "", fmt.Errorf("failed to decode response: no token returned")
+	}
+
+	return auth.JWT, nil
+}
+
+// TokenExpiry returns the expiry time of a JWT from its exp claim. The signature is not
+// verified, the expiry is only used to ask for a new login before the server rejects the token.
+func TokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("invalid token: expected 3 parts, got %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid token payload: %w", err)
+	}
+
+	var claims struct {
+		ExpiresAt *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("invalid token payload: %w", err)
+	}
+	if claims.ExpiresAt == nil {
+		return time.Time{}, fmt.Errorf("token has no expiry")
+	}
+
+	exp, err := claims.ExpiresAt.Float64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid token expiry: %w", err)
+	}
+	return time.Unix(int64(exp), 0), nil
+}
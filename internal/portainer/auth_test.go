@@ -0,0 +1,84 @@
+package portainer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testJWT returns an unsigned JWT with the given claims
+func testJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func TestClient_Authenticate(t *testing.T) {
+	token := testJWT(`{"id":1,"username":"admin","exp":1735718400}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/auth", r.URL.Path)
+		assert.Empty(t, r.Header.Get("X-API-Key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+
+		var body authRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.Username != "admin" || body.Password != "secret" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message": "Invalid credentials", "details": "Unauthorized"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jwt": %q}`, token)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "")
+
+	jwt, err := client.Authenticate(context.Background(), "admin", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, token, jwt)
+
+	_, err = client.Authenticate(context.Background(), "admin", "wrong")
+	require.Error(t, err)
+	assert.True(t, IsInvalidCredentials(err))
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The session replaces the API key
+		assert.Equal(t, "Bearer session-token", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("X-API-Key"))
+		json.NewEncoder(w).Encode([]Environment{{ID: 1, Name: "local"}})
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, "ptr_token", ClientOptions{JWT: "session-token"})
+	_, err := client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expiry, err := TokenExpiry(testJWT(`{"id":1,"exp":1735718400}`))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC), expiry.UTC())
+
+	_, err = TokenExpiry(testJWT(`{"id":1}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no expiry")
+
+	_, err = TokenExpiry("ptr_not_a_jwt")
+	require.Error(t, err)
+
+	_, err = TokenExpiry("header.!!!.signature")
+	require.Error(t, err)
+}
@@ -10,49 +10,109 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
+// Default timeouts of the client operations
+const (
+	DefaultAPITimeout   = 30 * time.Second
+	DefaultBuildTimeout = 30 * time.Minute
+	DefaultLoadTimeout  = 30 * time.Minute
+)
+
 // Client handles communication with the Portainer API
 type Client struct {
-	baseURL       string
-	apiToken      string
-	skipTLSVerify bool
-	httpClient    *http.Client
+	baseURL      string
+	apiToken     string
+	jwt          string
+	tlsConfig    *tls.Config
+	proxy        ProxyFunc
+	dialContext  DialFunc
+	buildTimeout time.Duration
+	loadTimeout  time.Duration
+	retry        RetryPolicy
+	onRetry      func(RetryEvent)
+	httpClient   *http.Client
+}
+
+// ClientOptions configures a Client. Zero timeouts and retry settings use the defaults.
+type ClientOptions struct {
+	JWT           string // session token from Authenticate, sent as a bearer token instead of the API key
+	SkipTLSVerify bool
+	TLSConfig     *tls.Config   // used instead of SkipTLSVerify when set, see NewTLSConfig
+	Proxy         ProxyFunc     // proxy selection, the environment proxy when nil, see NewProxyFunc
+	DialContext   DialFunc      // dials the server, e.g. through an SSH jump host, see SSHJumpDialer
+	APITimeout    time.Duration // timeout of regular API calls
+	BuildTimeout  time.Duration // timeout of image builds, which stream their output
+	LoadTimeout   time.Duration // timeout of image loads, which stream their output
+	Retry         RetryPolicy
+	OnRetry       func(RetryEvent) // called before an idempotent request is retried
 }
 
 // NewClient creates a new Portainer API client
 func NewClient(baseURL, apiToken string) *Client {
-	return NewClientWithTLS(baseURL, apiToken, true) // Default to skip TLS verify
+	return NewClientWithTLS(baseURL, apiToken, false)
 }
 
 // NewClientWithTLS creates a new Portainer API client with TLS verification control
 func NewClientWithTLS(baseURL, apiToken string, skipTLSVerify bool) *Client {
+	return NewClientWithOptions(baseURL, apiToken, ClientOptions{SkipTLSVerify: skipTLSVerify})
+}
+
+// NewClientWithOptions creates a new Portainer API client
+func NewClientWithOptions(baseURL, apiToken string, opts ClientOptions) *Client {
+	tlsConfig := opts.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: opts.SkipTLSVerify,
+		}
+	}
+
+	proxy := opts.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
 	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: skipTLSVerify,
-		},
+		Proxy:           proxy,
+		DialContext:     opts.DialContext,
+		TLSClientConfig: tlsConfig,
 	}
 
 	return &Client{
-		baseURL:       baseURL,
-		apiToken:      apiToken,
-		skipTLSVerify: skipTLSVerify,
+		baseURL:      baseURL,
+		apiToken:     apiToken,
+		jwt:          opts.JWT,
+		tlsConfig:    tlsConfig,
+		proxy:        proxy,
+		dialContext:  opts.DialContext,
+		buildTimeout: orDefault(opts.BuildTimeout, DefaultBuildTimeout),
+		loadTimeout:  orDefault(opts.LoadTimeout, DefaultLoadTimeout),
+		retry:        opts.Retry.withDefaults(),
+		onRetry:      opts.OnRetry,
 		httpClient: &http.Client{
-			Timeout:   30 * time.Second,
+			Timeout:   orDefault(opts.APITimeout, DefaultAPITimeout),
 			Transport: transport,
 		},
 	}
 }
 
+func orDefault(timeout, defaultTimeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return defaultTimeout
+	}
+	return timeout
+}
+
 // GetEnvironments retrieves all available environments from Portainer
-func (c *Client) GetEnvironments() ([]Environment, error) {
-	req, err := c.newRequest("GET", "/api/endpoints", nil)
+func (c *Client) GetEnvironments(ctx context.Context) ([]Environment, error) {
+	req, err := c.newRequest(ctx, "GET", "/api/endpoints", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -70,15 +130,21 @@ func (c *Client) GetEnvironments() ([]Environment, error) {
 	return environments, nil
 }
 
-// GetStack retrieves a stack by name and environment ID
-func (c *Client) GetStack(name string, environmentID int) (*Stack, error) {
-	// Get all stacks and filter by name and environment
-	req, err := c.newRequest("GET", "/api/stacks", nil)
+// GetStack retrieves a stack by name and environment ID, nil when it does not exist
+func (c *Client) GetStack(ctx context.Context, name string, environmentID int) (*Stack, error) {
+	// Portainer filters the stacks by environment, the name is matched below
+	filters, err := json.Marshal(StackFilters{EnvironmentID: environmentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/stacks?filters=%s", url.QueryEscape(string(filters)))
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -93,7 +159,8 @@ func (c *Client) GetStack(name string, environmentID int) (*Stack, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Find stack with matching name and environment ID
+	// Find stack with matching name and environment ID, also checking the environment
+	// in case the Portainer version ignores the filters
 	for _, stack := range stacks {
 		if stack.Name == name && stack.EnvironmentID == environmentID {
 			return &stack, nil
@@ -103,29 +170,45 @@ func (c *Client) GetStack(name string, environmentID int) (*Stack, error) {
 	return nil, nil // Stack not found
 }
 
-// CreateStack creates a new stack in Portainer
-func (c *Client) CreateStack(name, composeContent string, environmentID int) (*Stack, error) {
-	// Create JSON request body
-	reqBody := map[string]string{
+// CreateStack creates a new stack in Portainer on a standalone Docker environment
+func (c *Client) CreateStack(ctx context.Context, name, composeContent string, env []EnvVar, environmentID int) (*Stack, error) {
+	reqBody := map[string]interface{}{
 		"name":             name,
 		"stackFileContent": composeContent,
+		"env":              nonNilEnv(env),
 	}
+	return c.createStack(ctx, "standalone", reqBody, environmentID)
+}
+
+// CreateSwarmStack creates a new stack in Portainer on a Docker Swarm environment, swarmID
+// is the ID of the cluster (see GetSwarmID)
+func (c *Client) CreateSwarmStack(ctx context.Context, name, swarmID, composeContent string, env []EnvVar, environmentID int) (*Stack, error) {
+	reqBody := map[string]interface{}{
+		"name":             name,
+		"swarmID":          swarmID,
+		"stackFileContent": composeContent,
+		"env":              nonNilEnv(env),
+	}
+	return c.createStack(ctx, "swarm", reqBody, environmentID)
+}
 
+// createStack creates a stack from the compose file content of reqBody, kind is the
+// stack type of the creation endpoint (standalone or swarm)
+func (c *Client) createStack(ctx context.Context, kind string, reqBody map[string]interface{}, environmentID int) (*Stack, error) {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Use the correct endpoint for Docker Compose stack creation
-	endpoint := fmt.Sprintf("/api/stacks/create/standalone/string?endpointId=%d", environmentID)
-	req, err := c.newRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+	endpoint := fmt.Sprintf("/api/stacks/create/%s/string?endpointId=%d", kind, environmentID)
+	req, err := c.newRequest(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -155,12 +238,14 @@ func (c *Client) CreateStack(name, composeContent string, environmentID int) (*S
 }
 
 // UpdateStack updates an existing stack in Portainer
-func (c *Client) UpdateStack(stackID int, composeContent string, pullImages bool, environmentID int) error {
+// The stack environment variables are replaced by env.
+func (c *Client) UpdateStack(ctx context.Context, stackID int, composeContent string, env []EnvVar, pullImages bool, environmentID int) error {
 	// Create JSON request body for stack update
 	reqBody := map[string]interface{}{
 		"prune":            true,
 		"pullImage":        pullImages,
 		"stackFileContent": composeContent,
+		"env":              nonNilEnv(env),
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -170,14 +255,14 @@ func (c *Client) UpdateStack(stackID int, composeContent string, pullImages bool
 
 	// Use the correct endpoint for stack updates with endpointId parameter
 	endpoint := fmt.Sprintf("/api/stacks/%d?endpointId=%d", stackID, environmentID)
-	req, err := c.newRequest("PUT", endpoint, bytes.NewBuffer(jsonData))
+	req, err := c.newRequest(ctx, "PUT", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to make request: %w", err)
 	}
@@ -190,15 +275,49 @@ func (c *Client) UpdateStack(stackID int, composeContent string, pullImages bool
 	return nil
 }
 
+// GetStackFile retrieves the compose file content of a deployed stack
+func (c *Client) GetStackFile(ctx context.Context, stackID int) (string, error) {
+	endpoint := fmt.Sprintf("/api/stacks/%d/file", stackID)
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", c.handleErrorResponse(resp)
+	}
+
+	var file StackFile
+	if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return file.StackFileContent, nil
+}
+
+// nonNilEnv makes sure env is sent as an empty list rather than null
+func nonNilEnv(env []EnvVar) []EnvVar {
+	if env == nil {
+		return []EnvVar{}
+	}
+	return env
+}
+
 // GetStackDetails retrieves detailed stack information by ID
-func (c *Client) GetStackDetails(stackID int) (*StackDetails, error) {
+func (c *Client) GetStackDetails(ctx context.Context, stackID int) (*StackDetails, error) {
 	endpoint := fmt.Sprintf("/api/stacks/%d", stackID)
-	req, err := c.newRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -217,11 +336,11 @@ func (c *Client) GetStackDetails(stackID int) (*StackDetails, error) {
 }
 
 // GetStackContainers retrieves containers for a specific stack via Docker proxy
-func (c *Client) GetStackContainers(environmentID int, stackName string) ([]Container, error) {
+func (c *Client) GetStackContainers(ctx context.Context, environmentID int, stackName string) ([]Container, error) {
 	// Create filters for Docker Compose project label
 	// Docker API expects filters in the format: {"label": ["com.docker.compose.project=stackname"]}
 	filters := map[string][]string{
-		"label": {fmt.Sprintf("com.docker.compose.project=%s", stackName)},
+		"label": {fmt.Sprintf("%s=%s", ComposeProjectLabel, stackName)},
 	}
 
 	filtersJSON, err := json.Marshal(filters)
@@ -234,12 +353,12 @@ func (c *Client) GetStackContainers(environmentID int, stackName string) ([]Cont
 
 	// Use Docker proxy endpoint to list containers
 	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/containers/json?filters=%s", environmentID, encodedFilters)
-	req, err := c.newRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -257,60 +376,126 @@ func (c *Client) GetStackContainers(environmentID int, stackName string) ([]Cont
 	return containers, nil
 }
 
-// GetContainerLogs retrieves logs for a specific container via Docker proxy
-func (c *Client) GetContainerLogs(environmentID int, containerID string, tail int) (string, error) {
-	// Build query parameters
+// InspectContainer retrieves the details of a container via Docker proxy
+func (c *Client) InspectContainer(ctx context.Context, environmentID int, containerID string) (*ContainerInspect, error) {
+	var container ContainerInspect
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/endpoints/%d/docker/containers/%s/json", environmentID, containerID), &container); err != nil {
+		return nil, err
+	}
+	return &container, nil
+}
+
+// GetContainerLogs retrieves logs for a specific container via Docker proxy.
+// tty is the Tty setting of the container, whose logs are multiplexed without it.
+func (c *Client) GetContainerLogs(ctx context.Context, environmentID int, containerID string, tty bool, tail int) ([]LogEntry, error) {
+	return c.getLogs(ctx, fmt.Sprintf("/api/endpoints/%d/docker/containers/%s/logs", environmentID, containerID), dockerLogParams(tail, false), !tty)
+}
+
+// StreamContainerLogs follows the logs of a container via Docker proxy.
+// onEntry is called for every log line until the stream ends or ctx is cancelled.
+func (c *Client) StreamContainerLogs(ctx context.Context, environmentID int, containerID string, tty bool, tail int, onEntry func(LogEntry)) error {
+	return c.streamLogs(ctx, fmt.Sprintf("/api/endpoints/%d/docker/containers/%s/logs", environmentID, containerID), dockerLogParams(tail, true), !tty, onEntry)
+}
+
+// dockerLogParams returns the query of a Docker logs endpoint returning the last tail lines,
+// all lines when tail is 0
+func dockerLogParams(tail int, follow bool) url.Values {
 	params := url.Values{}
 	if tail > 0 {
 		params.Set("tail", fmt.Sprintf("%d", tail))
 	}
+	if follow {
+		params.Set("follow", "true")
+	}
 	params.Set("stdout", "true")
 	params.Set("stderr", "true")
 	params.Set("timestamps", "true")
+	return params
+}
 
-	// Use Docker proxy endpoint to get container logs
-	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/containers/%s/logs?%s",
-		environmentID, containerID, params.Encode())
-
-	req, err := c.newRequest("GET", endpoint, nil)
+// getLogs retrieves the lines of a logs endpoint, multiplexed tells whether stdout and stderr
+// are sent as Docker frames
+func (c *Client) getLogs(ctx context.Context, path string, params url.Values, multiplexed bool) ([]LogEntry, error) {
+	req, err := c.newRequest(ctx, "GET", path+"?"+params.Encode(), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", c.handleErrorResponse(resp)
+		return nil, c.handleErrorResponse(resp)
 	}
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body)
+	// Demultiplex the stdout/stderr frames into log entries, other streams are plain text
+	var entries []LogEntry
+	err = demuxLogStream(resp.Body, multiplexed, func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return string(body), nil
+	return entries, nil
+}
+
+// streamLogs follows a logs endpoint until the stream ends or ctx is cancelled
+func (c *Client) streamLogs(ctx context.Context, path string, params url.Values, multiplexed bool, onEntry func(LogEntry)) error {
+	req, err := c.newRequest(ctx, "GET", path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// A followed log stream stays open indefinitely, so the regular client timeout
+	// cannot be used. The context handles cancellation instead.
+	streamClient := &http.Client{
+		Transport: c.httpClient.Transport,
+	}
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	if err := demuxLogStream(resp.Body, multiplexed, onEntry); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read log stream: %w", err)
+	}
+
+	return nil
 }
 
 // BuildOptions represents options for building an image
 type BuildOptions struct {
 	Tag        string
+	ExtraTags  []string          // optional - additional names of the built image
 	Dockerfile string            // relative to context
 	BuildArgs  map[string]string // optional
 	Target     string            // optional
 	NoCache    bool              // optional - set to true to disable build cache
+	Pull       bool              // optional - set to true to pull newer versions of the base images
 }
 
 // BuildImage builds an image using the Docker Build API via Portainer proxy
-func (c *Client) BuildImage(environmentID int, ctxTar io.Reader, opts BuildOptions, onLine func(string)) error {
+func (c *Client) BuildImage(ctx context.Context, environmentID int, ctxTar io.Reader, opts BuildOptions, onLine func(string)) error {
 	q := url.Values{}
 	if opts.Tag != "" {
 		q.Set("t", opts.Tag)
 	}
+	for _, tag := range opts.ExtraTags {
+		q.Add("t", tag)
+	}
 	if opts.Dockerfile != "" {
 		q.Set("dockerfile", opts.Dockerfile)
 	}
@@ -324,75 +509,86 @@ func (c *Client) BuildImage(environmentID int, ctxTar io.Reader, opts BuildOptio
 	if opts.NoCache {
 		q.Set("nocache", "true")
 	}
+	if opts.Pull {
+		q.Set("pull", "true")
+	}
 
 	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/build?%s", environmentID, q.Encode())
 
-	req, err := c.newRequest("POST", endpoint, ctxTar)
+	// Docker builds can take a long time, especially with large contexts or slow networks,
+	// so they use their own timeout on top of ctx
+	buildCtx, cancel := context.WithTimeout(ctx, c.buildTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(buildCtx, "POST", endpoint, ctxTar)
 	if err != nil {
 		return fmt.Errorf("build request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-tar")
 
-	// Use a context with a longer timeout for build operations (5 minutes)
-	// Docker builds can take a long time, especially with large contexts or slow networks
-	buildCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-	req = req.WithContext(buildCtx)
-
-	// Create a temporary HTTP client with no timeout for the build request
-	// The context timeout will handle the timeout instead
-	buildClient := &http.Client{
-		Transport: c.httpClient.Transport,
-		// No timeout - let the context handle it
+	// The engine answers 200 before building, build errors are reported in the stream
+	var buildErr string
+	err = c.streamLines(req, func(line string) {
+		if message := streamErrorMessage(line); message != "" {
+			buildErr = message
+		}
+		if onLine != nil {
+			onLine(line)
+		}
+	})
+	if err != nil && ctx.Err() == nil && buildCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("build timed out after %s: %w", c.buildTimeout, err)
 	}
-
-	resp, err := buildClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("build call: %w", err)
+	if err == nil && buildErr != "" {
+		return fmt.Errorf("build error: %s", buildErr)
 	}
-	defer resp.Body.Close()
+	return err
+}
 
-	if resp.StatusCode/100 != 2 {
-		return c.handleErrorResponse(resp)
+// streamErrorMessage returns the error of a JSON message of a Docker progress stream, empty
+// when the line is not an error
+func streamErrorMessage(line string) string {
+	var message struct {
+		Error string `json:"error"`
 	}
-
-	// Stream JSON lines from the build
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if onLine != nil {
-			onLine(line)
-		}
+	if err := json.Unmarshal([]byte(line), &message); err != nil {
+		return ""
 	}
-	return scanner.Err()
+	return message.Error
 }
 
 // LoadImage loads an image tar into the Docker engine via Portainer proxy
-func (c *Client) LoadImage(environmentID int, imageTar io.Reader, onProgress func(string)) error {
+func (c *Client) LoadImage(ctx context.Context, environmentID int, imageTar io.Reader, onProgress func(string)) error {
 	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/images/load", environmentID)
 
-	req, err := c.newRequest("POST", endpoint, imageTar)
+	// Image loads can take a long time, especially with large images or slow networks,
+	// so they use their own timeout on top of ctx
+	loadCtx, cancel := context.WithTimeout(ctx, c.loadTimeout)
+	defer cancel()
+
+	req, err := c.newRequest(loadCtx, "POST", endpoint, imageTar)
 	if err != nil {
 		return fmt.Errorf("load request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/x-tar")
 
-	// Use a context with a longer timeout for load operations (5 minutes)
-	// Image loads can take a long time, especially with large images or slow networks
-	loadCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancel()
-	req = req.WithContext(loadCtx)
+	err = c.streamLines(req, onProgress)
+	if err != nil && ctx.Err() == nil && loadCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("image load timed out after %s: %w", c.loadTimeout, err)
+	}
+	return err
+}
 
-	// Create a temporary HTTP client with no timeout for the load request
-	// The context timeout will handle the timeout instead
-	loadClient := &http.Client{
+// streamLines sends a long-running request and calls onLine for every line of the response.
+// The regular client timeout does not apply, the request context handles it instead.
+func (c *Client) streamLines(req *http.Request, onLine func(string)) error {
+	streamClient := &http.Client{
 		Transport: c.httpClient.Transport,
-		// No timeout - let the context handle it
 	}
 
-	resp, err := loadClient.Do(req)
+	resp, err := streamClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("load call: %w", err)
+		return fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -400,26 +596,24 @@ func (c *Client) LoadImage(environmentID int, imageTar io.Reader, onProgress fun
 		return c.handleErrorResponse(resp)
 	}
 
-	// Stream response for progress updates
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
-		line := scanner.Text()
-		if onProgress != nil {
-			onProgress(line)
+		if onLine != nil {
+			onLine(scanner.Text())
 		}
 	}
 	return scanner.Err()
 }
 
 // GetDockerInfo retrieves Docker engine information via Portainer proxy
-func (c *Client) GetDockerInfo(environmentID int) (map[string]interface{}, error) {
+func (c *Client) GetDockerInfo(ctx context.Context, environmentID int) (map[string]interface{}, error) {
 	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/info", environmentID)
-	req, err := c.newRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -438,14 +632,14 @@ func (c *Client) GetDockerInfo(environmentID int) (map[string]interface{}, error
 }
 
 // DeleteStack deletes a stack from Portainer
-func (c *Client) DeleteStack(stackID int, environmentID int) error {
+func (c *Client) DeleteStack(ctx context.Context, stackID int, environmentID int) error {
 	endpoint := fmt.Sprintf("/api/stacks/%d?endpointId=%d", stackID, environmentID)
-	req, err := c.newRequest("DELETE", endpoint, nil)
+	req, err := c.newRequest(ctx, "DELETE", endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to make request: %w", err)
 	}
@@ -458,15 +652,210 @@ func (c *Client) DeleteStack(stackID int, environmentID int) error {
 	return nil
 }
 
+// StartStack starts a stopped stack
+func (c *Client) StartStack(ctx context.Context, stackID int, environmentID int) error {
+	return c.stackAction(ctx, stackID, environmentID, "start")
+}
+
+// StopStack stops a running stack, its definition is kept in Portainer
+func (c *Client) StopStack(ctx context.Context, stackID int, environmentID int) error {
+	return c.stackAction(ctx, stackID, environmentID, "stop")
+}
+
+// stackAction calls a stack lifecycle endpoint (start or stop)
+func (c *Client) stackAction(ctx context.Context, stackID int, environmentID int, action string) error {
+	endpoint := fmt.Sprintf("/api/stacks/%d/%s?endpointId=%d", stackID, action, environmentID)
+	req, err := c.newRequest(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// RestartContainer restarts a container via Docker proxy
+func (c *Client) RestartContainer(ctx context.Context, environmentID int, containerID string) error {
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/containers/%s/restart", environmentID, url.PathEscape(containerID))
+	req, err := c.newRequest(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// GetStackVolumes retrieves the volumes created for a specific stack via Docker proxy
+func (c *Client) GetStackVolumes(ctx context.Context, environmentID int, stackName string) ([]Volume, error) {
+	// Docker Compose labels the named volumes it creates with the project name
+	filters := map[string][]string{
+		"label": {fmt.Sprintf("%s=%s", ComposeProjectLabel, stackName)},
+	}
+
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/volumes?filters=%s", environmentID, url.QueryEscape(string(filtersJSON)))
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var volumeList struct {
+		Volumes []Volume `json:"Volumes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&volumeList); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return volumeList.Volumes, nil
+}
+
+// RemoveVolume removes a volume from the Docker engine via Portainer proxy
+func (c *Client) RemoveVolume(ctx context.Context, environmentID int, volumeName string) error {
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/volumes/%s", environmentID, url.PathEscape(volumeName))
+	req, err := c.newRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// GetImages retrieves all images from the Docker engine via Portainer proxy
+func (c *Client) GetImages(ctx context.Context, environmentID int) ([]Image, error) {
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/images/json", environmentID)
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var images []Image
+	if err := json.NewDecoder(resp.Body).Decode(&images); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return images, nil
+}
+
+// RemoveImage removes an image reference from the Docker engine via Portainer proxy.
+// The image itself is deleted once its last tag is removed.
+func (c *Client) RemoveImage(ctx context.Context, environmentID int, imageRef string) error {
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/images/%s", environmentID, imageRef)
+	req, err := c.newRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// TagImage adds a name to an image of the remote Docker engine, target is a repository with
+// an optional tag which defaults to latest
+func (c *Client) TagImage(ctx context.Context, environmentID int, source, target string) error {
+	repo, tag := splitImageTag(target)
+	q := url.Values{}
+	q.Set("repo", repo)
+	q.Set("tag", tag)
+
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/images/%s/tag?%s", environmentID, source, q.Encode())
+	req, err := c.newRequest(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// splitImageTag splits an image reference into its repository and its tag, latest by default
+func splitImageTag(ref string) (repo, tag string) {
+	slash := strings.LastIndex(ref, "/")
+	if colon := strings.LastIndex(ref, ":"); colon > slash {
+		return ref[:colon], ref[colon+1:]
+	}
+	return ref, "latest"
+}
+
 // ImageExists checks if an image exists on the remote Docker engine
-func (c *Client) ImageExists(environmentID int, imageTag string) (bool, error) {
+func (c *Client) ImageExists(ctx context.Context, environmentID int, imageTag string) (bool, error) {
 	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/images/%s/json", environmentID, imageTag)
-	req, err := c.newRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return false, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -481,8 +870,8 @@ func (c *Client) ImageExists(environmentID int, imageTag string) (bool, error) {
 	}
 }
 
-// newRequest creates a new HTTP request with proper headers
-func (c *Client) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+// newRequest creates a new HTTP request bound to ctx with proper headers
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
 	// Ensure baseURL ends with /
 	baseURL := c.baseURL
 	if baseURL[len(baseURL)-1:] != "/" {
@@ -495,39 +884,69 @@ func (c *Client) newRequest(method, path string, body io.Reader) (*http.Request,
 	}
 
 	fullURL := baseURL + path
-	req, err := http.NewRequest(method, fullURL, body)
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("X-API-Key", c.apiToken)
+	c.setAuthHeader(req.Header)
 	req.Header.Set("Accept", "application/json")
 
 	return req, nil
 }
 
-// handleErrorResponse processes error responses from the API
-func (c *Client) handleErrorResponse(resp *http.Response) error {
-	body, err := io.ReadAll(resp.Body)
+// getJSON sends a GET request and decodes the JSON response into out
+func (c *Client) getJSON(ctx context.Context, path string, out any) error {
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
 	if err != nil {
-		return fmt.Errorf("API request failed with status %d: failed to read error response", resp.StatusCode)
+		return fmt.Errorf("failed to make request: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// If response body is empty, return a simple error
-	if len(body) == 0 {
-		return fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
 	}
 
-	var apiErr APIError
-	if err := json.Unmarshal(body, &apiErr); err != nil {
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// setAuthHeader authenticates a request with the session JWT when there is one, the API key otherwise
+func (c *Client) setAuthHeader(header http.Header) {
+	switch {
+	case c.jwt != "":
+		header.Set("Authorization", "Bearer "+c.jwt)
+	case c.apiToken != "":
+		header.Set("X-API-Key", c.apiToken)
+	}
+}
+
+// handleErrorResponse converts an error response from the API into an *APIError
+func (c *Client) handleErrorResponse(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.Path = resp.Request.URL.Path
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || len(body) == 0 {
+		return apiErr
 	}
 
-	if apiErr.Message != "" {
-		return fmt.Errorf("API error: %s", apiErr.Message)
+	if err := json.Unmarshal(body, apiErr); err != nil {
+		apiErr.Details = string(body)
 	}
 
-	return fmt.Errorf("API request failed with status %d", resp.StatusCode)
+	return apiErr
 }
 
 // ValidateURL checks if the provided URL is valid
@@ -1,11 +1,14 @@
 package portainer
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,7 +19,7 @@ func TestNewClient(t *testing.T) {
 
 	assert.Equal(t, "https://portainer.example.com", client.baseURL)
 	assert.Equal(t, "test-token", client.apiToken)
-	assert.True(t, client.skipTLSVerify)
+	assert.False(t, client.tlsConfig.InsecureSkipVerify, "TLS verification must be enabled by default")
 	assert.NotNil(t, client.httpClient)
 }
 
@@ -44,16 +47,28 @@ func TestNewClientWithTLS(t *testing.T) {
 
 			assert.Equal(t, "https://portainer.example.com", client.baseURL)
 			assert.Equal(t, "test-token", client.apiToken)
-			assert.Equal(t, tt.expectedSkip, client.skipTLSVerify)
+			assert.Equal(t, tt.expectedSkip, client.tlsConfig.InsecureSkipVerify)
 			assert.NotNil(t, client.httpClient)
 		})
 	}
 }
 
+func TestNewClientWithOptions(t *testing.T) {
+	client := NewClientWithOptions("https://portainer.example.com", "test-token", ClientOptions{
+		APITimeout:   10 * time.Second,
+		BuildTimeout: 2 * time.Hour,
+	})
+
+	assert.False(t, client.tlsConfig.InsecureSkipVerify)
+	assert.Equal(t, 10*time.Second, client.httpClient.Timeout)
+	assert.Equal(t, 2*time.Hour, client.buildTimeout)
+	assert.Equal(t, DefaultLoadTimeout, client.loadTimeout)
+}
+
 func TestClient_newRequest(t *testing.T) {
 	client := NewClient("https://portainer.example.com", "test-token")
 
-	req, err := client.newRequest("GET", "/api/endpoints", nil)
+	req, err := client.newRequest(context.Background(), "GET", "/api/endpoints", nil)
 	require.NoError(t, err)
 
 	assert.Equal(t, "GET", req.Method)
@@ -97,7 +112,7 @@ func TestClient_newRequest_URLFormatting(t *testing.T) {
 				httpClient: &http.Client{},
 			}
 
-			req, err := client.newRequest("GET", tt.path, nil)
+			req, err := client.newRequest(context.Background(), "GET", tt.path, nil)
 			require.NoError(t, err)
 			assert.Equal(t, tt.expected, req.URL.String())
 		})
@@ -115,13 +130,13 @@ func TestClient_handleErrorResponse(t *testing.T) {
 			name:          "API error with message",
 			statusCode:    400,
 			responseBody:  `{"message": "Invalid request", "details": "Missing required field"}`,
-			expectedError: "API error: Invalid request",
+			expectedError: "API error: Invalid request: Missing required field",
 		},
 		{
 			name:          "API error without message",
 			statusCode:    500,
 			responseBody:  `{"details": "Internal server error"}`,
-			expectedError: "API request failed with status 500",
+			expectedError: "API request failed with status 500: Internal server error",
 		},
 		{
 			name:          "empty response body",
@@ -141,23 +156,25 @@ func TestClient_handleErrorResponse(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			client := NewClient("https://portainer.example.com", "test-token")
 
+			req, err := http.NewRequest("GET", "https://portainer.example.com/api/stacks?filters=x", nil)
+			require.NoError(t, err)
+
 			// Create a mock response
 			resp := &http.Response{
 				StatusCode: tt.statusCode,
-				Body:       http.NoBody,
+				Body:       io.NopCloser(strings.NewReader(tt.responseBody)),
+				Request:    req,
 			}
 
-			// Mock the response body
-			if tt.responseBody != "" {
-				resp.Body = http.NoBody
-				// We can't easily mock the response body in this test
-				// This is more of a unit test for the error handling logic
-			}
+			err = client.handleErrorResponse(resp)
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
 
-			err := client.handleErrorResponse(resp)
-			assert.Error(t, err)
-			// Note: The actual error message will depend on the implementation
-			// This test verifies that an error is returned
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.statusCode, apiErr.StatusCode)
+			assert.Equal(t, "GET", apiErr.Method)
+			assert.Equal(t, "/api/stacks", apiErr.Path)
 		})
 	}
 }
@@ -249,7 +266,7 @@ func TestClient_GetEnvironments(t *testing.T) {
 	defer server.Close()
 
 	client := NewClient(server.URL, "test-token")
-	environments, err := client.GetEnvironments()
+	environments, err := client.GetEnvironments(context.Background())
 
 	require.NoError(t, err)
 	require.Len(t, environments, 2)
@@ -266,7 +283,9 @@ func TestClient_GetStack(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, "GET", r.Method)
 		assert.Equal(t, "/api/stacks", r.URL.Path)
+		assert.Equal(t, `{"EndpointID":1}`, r.URL.Query().Get("filters"))
 
+		// Older Portainer versions ignore the filters, the environment is checked by the client
 		stacks := []Stack{
 			{ID: 1, Name: "myapp", EnvironmentID: 1, Status: 1},
 			{ID: 2, Name: "myapp", EnvironmentID: 2, Status: 1},
@@ -281,7 +300,7 @@ func TestClient_GetStack(t *testing.T) {
 	client := NewClient(server.URL, "test-token")
 
 	// Test finding existing stack
-	stack, err := client.GetStack("myapp", 1)
+	stack, err := client.GetStack(context.Background(), "myapp", 1)
 	require.NoError(t, err)
 	require.NotNil(t, stack)
 	assert.Equal(t, 1, stack.ID)
@@ -289,7 +308,7 @@ func TestClient_GetStack(t *testing.T) {
 	assert.Equal(t, 1, stack.EnvironmentID)
 
 	// Test finding non-existing stack
-	stack, err = client.GetStack("nonexistent", 1)
+	stack, err = client.GetStack(context.Background(), "nonexistent", 1)
 	require.NoError(t, err)
 	assert.Nil(t, stack)
 }
@@ -302,10 +321,15 @@ func TestClient_CreateStack(t *testing.T) {
 		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
 
 		// Verify request body
-		var reqBody map[string]string
-		json.NewDecoder(r.Body).Decode(&reqBody)
-		assert.Equal(t, "myapp", reqBody["name"])
-		assert.Equal(t, "version: '3.8'\nservices:\n  web:\n    image: nginx", reqBody["stackFileContent"])
+		var reqBody struct {
+			Name             string   `json:"name"`
+			StackFileContent string   `json:"stackFileContent"`
+			Env              []EnvVar `json:"env"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reqBody))
+		assert.Equal(t, "myapp", reqBody.Name)
+		assert.Equal(t, "version: '3.8'\nservices:\n  web:\n    image: nginx", reqBody.StackFileContent)
+		assert.Equal(t, []EnvVar{{Name: "DB_PASSWORD", Value: "secret"}}, reqBody.Env)
 
 		stack := Stack{
 			ID:            1,
@@ -323,7 +347,7 @@ func TestClient_CreateStack(t *testing.T) {
 	client := NewClient(server.URL, "test-token")
 
 	composeContent := "version: '3.8'\nservices:\n  web:\n    image: nginx"
-	stack, err := client.CreateStack("myapp", composeContent, 1)
+	stack, err := client.CreateStack(context.Background(), "myapp", composeContent, []EnvVar{{Name: "DB_PASSWORD", Value: "secret"}}, 1)
 
 	require.NoError(t, err)
 	require.NotNil(t, stack)
@@ -345,6 +369,7 @@ func TestClient_UpdateStack(t *testing.T) {
 		assert.Equal(t, true, reqBody["prune"])
 		assert.Equal(t, true, reqBody["pullImage"])
 		assert.Equal(t, "version: '3.8'\nservices:\n  web:\n    image: nginx:latest", reqBody["stackFileContent"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "APP_ENV", "value": "production"}}, reqBody["env"])
 
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -353,11 +378,67 @@ func TestClient_UpdateStack(t *testing.T) {
 	client := NewClient(server.URL, "test-token")
 
 	composeContent := "version: '3.8'\nservices:\n  web:\n    image: nginx:latest"
-	err := client.UpdateStack(1, composeContent, true, 1)
+	err := client.UpdateStack(context.Background(), 1, composeContent, []EnvVar{{Name: "APP_ENV", Value: "production"}}, true, 1)
 
 	require.NoError(t, err)
 }
 
+func TestClient_UpdateStack_EmptyEnv(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Env must be sent as an empty list, not null
+		var reqBody map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		assert.Equal(t, []interface{}{}, reqBody["env"])
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.UpdateStack(context.Background(), 1, "services: {}", nil, false, 1)
+
+	require.NoError(t, err)
+}
+
+func TestClient_GetStackFile(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/stacks/5/file", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StackFile{StackFileContent: "services:\n  web:\n    image: nginx\n"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	content, err := client.GetStackFile(context.Background(), 5)
+
+	require.NoError(t, err)
+	assert.Equal(t, "services:\n  web:\n    image: nginx\n", content)
+}
+
+func TestClient_GetStackFile_NotFound(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIError{Message: "Unable to find a stack with the specified identifier inside the database"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	content, err := client.GetStackFile(context.Background(), 5)
+
+	assert.Error(t, err)
+	assert.Empty(t, content)
+	assert.Contains(t, err.Error(), "Unable to find a stack")
+}
+
 func TestClient_GetStackDetails(t *testing.T) {
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -384,7 +465,7 @@ func TestClient_GetStackDetails(t *testing.T) {
 
 	client := NewClient(server.URL, "test-token")
 
-	stackDetails, err := client.GetStackDetails(1)
+	stackDetails, err := client.GetStackDetails(context.Background(), 1)
 
 	require.NoError(t, err)
 	require.NotNil(t, stackDetails)
@@ -398,6 +479,9 @@ func TestClient_GetStackDetails(t *testing.T) {
 	assert.Equal(t, "admin", stackDetails.UpdatedBy)
 	assert.Equal(t, "/opt/portainer/stacks/1", stackDetails.ProjectPath)
 	assert.Equal(t, "docker-compose.yml", stackDetails.EntryPoint)
+
+	stack := stackDetails.Stack()
+	assert.Equal(t, Stack{ID: 1, Name: "myapp", StackFile: "docker-compose.yml", EnvironmentID: 1, Status: 1}, *stack)
 }
 
 func TestClient_GetStackContainers(t *testing.T) {
@@ -431,7 +515,7 @@ func TestClient_GetStackContainers(t *testing.T) {
 
 	client := NewClient(server.URL, "test-token")
 
-	containers, err := client.GetStackContainers(1, "myapp")
+	containers, err := client.GetStackContainers(context.Background(), 1, "myapp")
 
 	require.NoError(t, err)
 	require.Len(t, containers, 2)
@@ -442,6 +526,21 @@ func TestClient_GetStackContainers(t *testing.T) {
 	assert.Equal(t, "running", containers[0].State)
 }
 
+func TestClient_InspectContainer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/1/docker/containers/abc123/json", r.URL.Path)
+		w.Write([]byte(`{"Id": "abc123", "Config": {"Tty": true, "Image": "nginx:latest"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	container, err := client.InspectContainer(context.Background(), 1, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", container.ID)
+	assert.True(t, container.Config.Tty)
+}
+
 func TestClient_GetContainerLogs(t *testing.T) {
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -458,11 +557,88 @@ func TestClient_GetContainerLogs(t *testing.T) {
 
 	client := NewClient(server.URL, "test-token")
 
-	logs, err := client.GetContainerLogs(1, "abc123", 100)
+	entries, err := client.GetContainerLogs(context.Background(), 1, "abc123", true, 100)
 
 	require.NoError(t, err)
-	assert.Contains(t, logs, "Starting application")
-	assert.Contains(t, logs, "Application started")
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Starting application", entries[0].Message)
+	assert.Equal(t, "Application started", entries[1].Message)
+	assert.Equal(t, LogStreamTTY, entries[0].Stream)
+	assert.False(t, entries[0].Timestamp.IsZero())
+}
+
+func TestClient_GetContainerLogs_Multiplexed(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(logFrame(1, "2023-01-01T12:00:00Z Starting application\n"))
+		w.Write(logFrame(2, "2023-01-01T12:00:01Z Connection refused\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	entries, err := client.GetContainerLogs(context.Background(), 1, "abc123", false, 100)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, LogStreamStdout, entries[0].Stream)
+	assert.Equal(t, "Starting application", entries[0].Message)
+	assert.Equal(t, LogStreamStderr, entries[1].Stream)
+	assert.Equal(t, "Connection refused", entries[1].Message)
+}
+
+func TestClient_StreamContainerLogs(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/api/endpoints/1/docker/containers/abc123/logs"))
+		assert.Contains(t, r.URL.RawQuery, "follow=true")
+		assert.Contains(t, r.URL.RawQuery, "tail=10")
+
+		w.Write(logFrame(1, "2023-01-01T12:00:00Z Starting application\n"))
+		w.Write(logFrame(2, "2023-01-01T12:00:01Z Something went wrong\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	var entries []LogEntry
+	err := client.StreamContainerLogs(context.Background(), 1, "abc123", false, 10, func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Starting application", entries[0].Message)
+	assert.Equal(t, LogStreamStdout, entries[0].Stream)
+	assert.Equal(t, "Something went wrong", entries[1].Message)
+	assert.Equal(t, LogStreamStderr, entries[1].Stream)
+}
+
+func TestClient_StreamContainerLogs_ShortTTYOutput(t *testing.T) {
+	// A TTY container which logged less than a frame header, and keeps the stream open
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var entries []LogEntry
+	err := client.StreamContainerLogs(ctx, 1, "abc123", true, 10, func(entry LogEntry) {
+		entries = append(entries, entry)
+		cancel()
+	})
+
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "the line must be shown without waiting for more output")
+	assert.Equal(t, "ok", entries[0].Message)
+	assert.Equal(t, LogStreamTTY, entries[0].Stream)
 }
 
 func TestClient_GetDockerInfo(t *testing.T) {
@@ -489,7 +665,7 @@ func TestClient_GetDockerInfo(t *testing.T) {
 
 	client := NewClient(server.URL, "test-token")
 
-	info, err := client.GetDockerInfo(1)
+	info, err := client.GetDockerInfo(context.Background(), 1)
 
 	require.NoError(t, err)
 	require.NotNil(t, info)
@@ -539,9 +715,10 @@ func TestClient_ImageExists(t *testing.T) {
 			}))
 			defer server.Close()
 
-			client := NewClient(server.URL, "test-token")
+			// Without retries, the server error is returned at once
+			client := NewClientWithOptions(server.URL, "test-token", ClientOptions{Retry: RetryPolicy{MaxAttempts: 1}})
 
-			exists, err := client.ImageExists(1, "nginx:latest")
+			exists, err := client.ImageExists(context.Background(), 1, "nginx:latest")
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -593,7 +770,7 @@ func TestClient_BuildImage(t *testing.T) {
 	// Create a mock tar reader
 	tarReader := strings.NewReader("mock tar content")
 
-	err := client.BuildImage(1, tarReader, opts, onLine)
+	err := client.BuildImage(context.Background(), 1, tarReader, opts, onLine)
 
 	require.NoError(t, err)
 	assert.Len(t, buildLines, 7) // Should have 7 lines of build output
@@ -601,6 +778,134 @@ func TestClient_BuildImage(t *testing.T) {
 	assert.Contains(t, buildLines[6], "sha256:ghi789jkl012")
 }
 
+func TestClient_BuildImage_NoCacheAndPull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("nocache"))
+		assert.Equal(t, "true", r.URL.Query().Get("pull"))
+		w.Write([]byte(`{"aux": {"ID": "sha256:abc123"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	err := client.BuildImage(context.Background(), 1, strings.NewReader("mock tar content"), BuildOptions{Tag: "myapp:latest", NoCache: true, Pull: true}, func(string) {})
+	require.NoError(t, err)
+}
+
+func TestClient_BuildImage_ExtraTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"pctl-myapp-base:abc123", "acme/base:1.0"}, r.URL.Query()["t"])
+		w.Write([]byte(`{"aux": {"ID": "sha256:abc123"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	opts := BuildOptions{Tag: "pctl-myapp-base:abc123", ExtraTags: []string{"acme/base:1.0"}}
+	err := client.BuildImage(context.Background(), 1, strings.NewReader("mock tar content"), opts, func(string) {})
+	require.NoError(t, err)
+}
+
+func TestClient_TagImage(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		repo   string
+		tag    string
+	}{
+		{name: "with tag", target: "acme/base:1.0", repo: "acme/base", tag: "1.0"},
+		{name: "default tag", target: "acme/base", repo: "acme/base", tag: "latest"},
+		{name: "registry port", target: "registry.local:5000/acme/base", repo: "registry.local:5000/acme/base", tag: "latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, "/api/endpoints/1/docker/images/pctl-myapp-base:abc123/tag", r.URL.Path)
+				assert.Equal(t, tt.repo, r.URL.Query().Get("repo"))
+				assert.Equal(t, tt.tag, r.URL.Query().Get("tag"))
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "test-token")
+			err := client.TagImage(context.Background(), 1, "pctl-myapp-base:abc123", tt.target)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_BuildImage_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"stream": "Step 1/2 : FROM alpine"}
+{"errorDetail": {"message": "COPY failed: file not found"}, "error": "COPY failed: file not found"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	var lines []string
+	err := client.BuildImage(context.Background(), 1, strings.NewReader("mock tar content"), BuildOptions{Tag: "myapp:latest"}, func(line string) {
+		lines = append(lines, line)
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "COPY failed: file not found")
+	assert.Len(t, lines, 2)
+}
+
+func TestClient_BuildImage_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"stream": "Step 1/2 : FROM nginx:latest"}` + "\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{BuildTimeout: 100 * time.Millisecond})
+
+	var buildLines []string
+	err := client.BuildImage(context.Background(), 1, strings.NewReader("mock tar content"), BuildOptions{Tag: "myapp:latest"}, func(line string) {
+		buildLines = append(buildLines, line)
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "build timed out after 100ms")
+	assert.Len(t, buildLines, 1)
+}
+
+func TestClient_BuildImage_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"stream": "Step 1/2 : FROM nginx:latest"}` + "\n"))
+		w.(http.Flusher).Flush()
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.BuildImage(ctx, 1, strings.NewReader("mock tar content"), BuildOptions{Tag: "myapp:latest"}, nil)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotContains(t, err.Error(), "timed out")
+}
+
+func TestClient_GetStack_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should not be sent with a cancelled context")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewClient(server.URL, "test-token")
+	_, err := client.GetStack(ctx, "test-stack", 1)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestClient_LoadImage(t *testing.T) {
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -626,10 +931,172 @@ func TestClient_LoadImage(t *testing.T) {
 	// Create a mock tar reader
 	tarReader := strings.NewReader("mock tar content")
 
-	err := client.LoadImage(1, tarReader, onProgress)
+	err := client.LoadImage(context.Background(), 1, tarReader, onProgress)
 
 	require.NoError(t, err)
 	assert.Len(t, progressLines, 2)
 	assert.Contains(t, progressLines[0], "Loaded image: myapp:latest")
 	assert.Contains(t, progressLines[1], "Loaded image: myapp:staging")
 }
+
+func TestClient_GetStackVolumes(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/volumes", r.URL.Path)
+		assert.Contains(t, r.URL.Query().Get("filters"), "com.docker.compose.project=myapp")
+
+		response := map[string]interface{}{
+			"Volumes": []Volume{
+				{Name: "myapp_data", Driver: "local", Labels: map[string]string{"com.docker.compose.project": "myapp"}},
+			},
+			"Warnings": []string{},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	volumes, err := client.GetStackVolumes(context.Background(), 1, "myapp")
+
+	require.NoError(t, err)
+	require.Len(t, volumes, 1)
+	assert.Equal(t, "myapp_data", volumes[0].Name)
+	assert.Equal(t, "local", volumes[0].Driver)
+}
+
+func TestClient_RemoveVolume(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/volumes/myapp_data", r.URL.Path)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.RemoveVolume(context.Background(), 1, "myapp_data")
+
+	require.NoError(t, err)
+}
+
+func TestClient_StartStack(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/stacks/5/start", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("endpointId"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Stack{ID: 5, Name: "myapp", Status: StackStatusActive})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.StartStack(context.Background(), 5, 1)
+
+	require.NoError(t, err)
+}
+
+func TestClient_StopStack(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/stacks/5/stop", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("endpointId"))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Stack{ID: 5, Name: "myapp", Status: StackStatusInactive})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.StopStack(context.Background(), 5, 1)
+
+	require.NoError(t, err)
+}
+
+func TestClient_StopStack_Error(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIError{Message: "Stack is already inactive"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.StopStack(context.Background(), 5, 1)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Stack is already inactive")
+}
+
+func TestClient_RestartContainer(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/containers/abc123/restart", r.URL.Path)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.RestartContainer(context.Background(), 1, "abc123")
+
+	require.NoError(t, err)
+}
+
+func TestClient_GetImages(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/images/json", r.URL.Path)
+
+		images := []Image{
+			{ID: "sha256:abc", RepoTags: []string{"pctl-myapp-web:0123456789ab"}, Size: 1024},
+			{ID: "sha256:def", RepoTags: []string{"nginx:latest"}, Size: 2048},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(images)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	images, err := client.GetImages(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, []string{"pctl-myapp-web:0123456789ab"}, images[0].RepoTags)
+	assert.Equal(t, int64(2048), images[1].Size)
+}
+
+func TestClient_RemoveImage(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/images/pctl-myapp-web:0123456789ab", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"Untagged": "pctl-myapp-web:0123456789ab"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.RemoveImage(context.Background(), 1, "pctl-myapp-web:0123456789ab")
+
+	require.NoError(t, err)
+}
@@ -0,0 +1,175 @@
+package portainer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// createEdgeStackRequest is the payload creating an edge stack from a compose file
+type createEdgeStackRequest struct {
+	Name             string   `json:"name"`
+	StackFileContent string   `json:"stackFileContent"`
+	EdgeGroups       []int    `json:"edgeGroups"`
+	DeploymentType   int      `json:"deploymentType"`
+	Registries       []int    `json:"registries"`
+	EnvVars          []EnvVar `json:"envVars"`
+}
+
+// updateEdgeStackRequest is the payload updating the compose file and groups of an edge stack
+type updateEdgeStackRequest struct {
+	StackFileContent string   `json:"stackFileContent"`
+	EdgeGroups       []int    `json:"edgeGroups"`
+	DeploymentType   int      `json:"deploymentType"`
+	UpdateVersion    bool     `json:"updateVersion"`
+	EnvVars          []EnvVar `json:"envVars"`
+}
+
+// edgeDeploymentCompose is the deployment type of edge stacks defined by a compose file
+const edgeDeploymentCompose = 0
+
+// GetEnvironment retrieves an environment by ID
+func (c *Client) GetEnvironment(ctx context.Context, environmentID int) (*Environment, error) {
+	var environment Environment
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/endpoints/%d", environmentID), &environment); err != nil {
+		return nil, err
+	}
+	return &environment, nil
+}
+
+// GetEnvironmentsByID retrieves the given environments, which is cheaper than listing every
+// environment of a large edge fleet
+func (c *Client) GetEnvironmentsByID(ctx context.Context, environmentIDs []int) ([]Environment, error) {
+	if len(environmentIDs) == 0 {
+		return nil, nil
+	}
+
+	params := url.Values{}
+	for _, id := range environmentIDs {
+		params.Add("endpointIds", fmt.Sprintf("%d", id))
+	}
+
+	var environments []Environment
+	if err := c.getJSON(ctx, "/api/endpoints?"+params.Encode(), &environments); err != nil {
+		return nil, err
+	}
+	return environments, nil
+}
+
+// GetEdgeGroups retrieves all edge groups
+func (c *Client) GetEdgeGroups(ctx context.Context) ([]EdgeGroup, error) {
+	var groups []EdgeGroup
+	if err := c.getJSON(ctx, "/api/edge_groups", &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetEdgeStack retrieves an edge stack by name, nil when it does not exist
+func (c *Client) GetEdgeStack(ctx context.Context, name string) (*EdgeStack, error) {
+	var stacks []EdgeStack
+	if err := c.getJSON(ctx, "/api/edge_stacks", &stacks); err != nil {
+		return nil, err
+	}
+
+	for _, stack := range stacks {
+		if stack.Name == name {
+			return &stack, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetEdgeStackByID retrieves an edge stack with its deployment status on every environment
+func (c *Client) GetEdgeStackByID(ctx context.Context, stackID int) (*EdgeStack, error) {
+	var stack EdgeStack
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/edge_stacks/%d", stackID), &stack); err != nil {
+		return nil, err
+	}
+	return &stack, nil
+}
+
+// CreateEdgeStack creates an edge stack deployed to the environments of the given edge groups.
+// The Edge agents pull the stack asynchronously, see GetEdgeStackByID for the deployment status.
+func (c *Client) CreateEdgeStack(ctx context.Context, name, composeContent string, edgeGroupIDs []int, env []EnvVar) (*EdgeStack, error) {
+	payload := createEdgeStackRequest{
+		Name:             name,
+		StackFileContent: composeContent,
+		EdgeGroups:       edgeGroupIDs,
+		DeploymentType:   edgeDeploymentCompose,
+		Registries:       []int{},
+		EnvVars:          nonNilEnv(env),
+	}
+
+	resp, err := c.sendJSON(ctx, "POST", "/api/edge_stacks/create/string", payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var stack EdgeStack
+	if err := json.Unmarshal(body, &stack); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w, body: %s", err, string(body))
+	}
+	if stack.ID == 0 || stack.Name == "" {
+		return nil, fmt.Errorf("invalid edge stack response: ID=%d, Name=%s, body: %s", stack.ID, stack.Name, string(body))
+	}
+
+	return &stack, nil
+}
+
+// UpdateEdgeStack replaces the compose file, edge groups and environment variables of an edge
+// stack. A new version is created, so that the Edge agents redeploy it.
+func (c *Client) UpdateEdgeStack(ctx context.Context, stackID int, composeContent string, edgeGroupIDs []int, env []EnvVar) error {
+	payload := updateEdgeStackRequest{
+		StackFileContent: composeContent,
+		EdgeGroups:       edgeGroupIDs,
+		DeploymentType:   edgeDeploymentCompose,
+		UpdateVersion:    true,
+		EnvVars:          nonNilEnv(env),
+	}
+
+	resp, err := c.sendJSON(ctx, "PUT", fmt.Sprintf("/api/edge_stacks/%d", stackID), payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// DeleteEdgeStack deletes an edge stack, the Edge agents remove it from their environments
+func (c *Client) DeleteEdgeStack(ctx context.Context, stackID int) error {
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/api/edge_stacks/%d", stackID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package portainer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/12", r.URL.Path)
+		w.Write([]byte(`{"Id": 12, "Name": "kiosk-042", "Type": 4}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	environment, err := client.GetEnvironment(context.Background(), 12)
+	require.NoError(t, err)
+	assert.Equal(t, "kiosk-042", environment.Name)
+	assert.True(t, environment.IsEdge())
+}
+
+func TestEnvironment_IsEdge(t *testing.T) {
+	tests := []struct {
+		envType int
+		edge    bool
+	}{
+		{EnvironmentTypeDocker, false},
+		{EnvironmentTypeAgentOnDocker, false},
+		{EnvironmentTypeEdgeAgentOnDocker, true},
+		{EnvironmentTypeAgentOnKubernetes, false},
+		{EnvironmentTypeEdgeAgentOnKubernetes, true},
+	}
+
+	for _, tt := range tests {
+		environment := &Environment{Type: tt.envType}
+		assert.Equal(t, tt.edge, environment.IsEdge(), "type %d", tt.envType)
+	}
+}
+
+func TestClient_GetEnvironmentsByID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints", r.URL.Path)
+		assert.Equal(t, []string{"3", "5"}, r.URL.Query()["endpointIds"])
+		w.Write([]byte(`[{"Id": 3, "Name": "kiosk-003", "Type": 4}, {"Id": 5, "Name": "kiosk-005", "Type": 4}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	environments, err := client.GetEnvironmentsByID(context.Background(), []int{3, 5})
+	require.NoError(t, err)
+	require.Len(t, environments, 2)
+	assert.Equal(t, "kiosk-005", environments[1].Name)
+
+	// No request without IDs, Portainer would list every environment
+	environments, err = client.GetEnvironmentsByID(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, environments)
+}
+
+func TestClient_GetEdgeGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/edge_groups", r.URL.Path)
+		w.Write([]byte(`[{"Id": 1, "Name": "fleet-eu", "Dynamic": false, "Endpoints": [3, 5]}, {"Id": 2, "Name": "canary", "Dynamic": true}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	groups, err := client.GetEdgeGroups(context.Background())
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, EdgeGroup{ID: 1, Name: "fleet-eu", Endpoints: []int{3, 5}}, groups[0])
+	assert.True(t, groups[1].Dynamic)
+}
+
+func TestClient_GetEdgeStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/edge_stacks", r.URL.Path)
+		w.Write([]byte(`[
+			{"Id": 1, "Name": "other"},
+			{"Id": 2, "Name": "myapp", "EdgeGroups": [1], "StackFileVersion": 3, "Status": {
+				"3": {"EndpointID": 3, "Status": [{"Type": 1, "Time": 1714550000}, {"Type": 7, "Time": 1714550060}]},
+				"5": {"EndpointID": 5, "Status": [{"Type": 2, "Error": "image not found", "Time": 1714550030}]}
+			}}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	stack, err := client.GetEdgeStack(context.Background(), "myapp")
+	require.NoError(t, err)
+	require.NotNil(t, stack)
+	assert.Equal(t, 2, stack.ID)
+	assert.Equal(t, 3, stack.Version)
+	require.Len(t, stack.Status, 2)
+	assert.Equal(t, EdgeStatusRunning, stack.Status[3].Current().Type)
+	assert.Equal(t, EdgeStackStatusDetail{Type: EdgeStatusError, Error: "image not found", Time: 1714550030}, stack.Status[5].Current())
+
+	stack, err = client.GetEdgeStack(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.Nil(t, stack)
+}
+
+func TestEdgeStackStatus_Current_Pending(t *testing.T) {
+	assert.Equal(t, EdgeStatusPending, EdgeStackStatus{EnvironmentID: 3}.Current().Type)
+}
+
+func TestClient_CreateEdgeStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/edge_stacks/create/string", r.URL.Path)
+
+		var body createEdgeStackRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "myapp", body.Name)
+		assert.Equal(t, "services:\n  web:\n    image: nginx\n", body.StackFileContent)
+		assert.Equal(t, []int{1, 2}, body.EdgeGroups)
+		assert.Equal(t, 0, body.DeploymentType)
+		assert.Equal(t, []EnvVar{{Name: "SITE", Value: "eu"}}, body.EnvVars)
+
+		json.NewEncoder(w).Encode(EdgeStack{ID: 4, Name: "myapp", EdgeGroups: body.EdgeGroups})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	stack, err := client.CreateEdgeStack(context.Background(), "myapp", "services:\n  web:\n    image: nginx\n", []int{1, 2}, []EnvVar{{Name: "SITE", Value: "eu"}})
+	require.NoError(t, err)
+	assert.Equal(t, 4, stack.ID)
+}
+
+func TestClient_UpdateEdgeStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "/api/edge_stacks/4", r.URL.Path)
+
+		var body updateEdgeStackRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []int{1}, body.EdgeGroups)
+		assert.True(t, body.UpdateVersion)
+		assert.Equal(t, []EnvVar{}, body.EnvVars)
+
+		w.Write([]byte(`{"Id": 4, "Name": "myapp"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.UpdateEdgeStack(context.Background(), 4, "services: {}\n", []int{1}, nil)
+	require.NoError(t, err)
+}
+
+func TestClient_DeleteEdgeStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/api/edge_stacks/4", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	require.NoError(t, client.DeleteEdgeStack(context.Background(), 4))
+}
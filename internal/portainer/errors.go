@@ -0,0 +1,61 @@
+package portainer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError represents an error response from the Portainer API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Method     string `json:"-"`
+	Path       string `json:"-"` // request path, without the query string
+	Message    string `json:"message"`
+	Details    string `json:"details"` // Portainer details, or the raw body when it is not JSON
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	switch {
+	case e.Message != "" && e.Details != "" && e.Details != e.Message:
+		return fmt.Sprintf("API error: %s: %s", e.Message, e.Details)
+	case e.Message != "":
+		return fmt.Sprintf("API error: %s", e.Message)
+	case e.Details != "":
+		return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Details)
+	default:
+		return fmt.Sprintf("API request failed with status %d", e.StatusCode)
+	}
+}
+
+// IsNotFound reports whether err is a Portainer API 404 response
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is a Portainer API 401 response, usually an invalid or expired token
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is a Portainer API 403 response, usually a missing RBAC role
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsConflict reports whether err is a Portainer API 409 response
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsInvalidCredentials reports whether err is the Portainer API 422 response to a login with
+// a wrong username or password
+func IsInvalidCredentials(err error) bool {
+	return hasStatus(err, http.StatusUnprocessableEntity)
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
@@ -0,0 +1,106 @@
+package portainer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *APIError
+		expected string
+	}{
+		{
+			name:     "message and details",
+			err:      &APIError{StatusCode: 404, Message: "Unable to find a stack", Details: "Object not found inside the database"},
+			expected: "API error: Unable to find a stack: Object not found inside the database",
+		},
+		{
+			name:     "details repeating the message",
+			err:      &APIError{StatusCode: 400, Message: "Invalid request", Details: "Invalid request"},
+			expected: "API error: Invalid request",
+		},
+		{
+			name:     "details only",
+			err:      &APIError{StatusCode: 502, Details: "Bad Gateway"},
+			expected: "API request failed with status 502: Bad Gateway",
+		},
+		{
+			name:     "status only",
+			err:      &APIError{StatusCode: 500},
+			expected: "API request failed with status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.err.Error())
+		})
+	}
+}
+
+func TestAPIError_StatusChecks(t *testing.T) {
+	tests := []struct {
+		status       int
+		notFound     bool
+		unauthorized bool
+		forbidden    bool
+		conflict     bool
+	}{
+		{status: http.StatusNotFound, notFound: true},
+		{status: http.StatusUnauthorized, unauthorized: true},
+		{status: http.StatusForbidden, forbidden: true},
+		{status: http.StatusConflict, conflict: true},
+		{status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			// Checks must see through wrapping
+			err := fmt.Errorf("failed to update stack: %w", &APIError{StatusCode: tt.status})
+
+			assert.Equal(t, tt.notFound, IsNotFound(err))
+			assert.Equal(t, tt.unauthorized, IsUnauthorized(err))
+			assert.Equal(t, tt.forbidden, IsForbidden(err))
+			assert.Equal(t, tt.conflict, IsConflict(err))
+		})
+	}
+
+	assert.False(t, IsNotFound(errors.New("not found")))
+	assert.False(t, IsNotFound(nil))
+}
+
+func TestClient_APIErrorFromResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Access denied to resource",
+			"details": "Permission denied to access environment",
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	err := client.StopStack(context.Background(), 3, 1)
+
+	require.Error(t, err)
+	assert.True(t, IsForbidden(err))
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "POST", apiErr.Method)
+	assert.Equal(t, "/api/stacks/3/stop", apiErr.Path)
+	assert.Equal(t, "Access denied to resource", apiErr.Message)
+	assert.Equal(t, "Permission denied to access environment", apiErr.Details)
+}
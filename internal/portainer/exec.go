@@ -0,0 +1,259 @@
+package portainer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// CreateExec creates an exec instance in a container via Docker proxy and returns its ID
+func (c *Client) CreateExec(ctx context.Context, environmentID int, containerID string, config ExecConfig) (string, error) {
+	jsonData, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/containers/%s/exec", environmentID, url.PathEscape(containerID))
+	req, err := c.newRequest(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", c.handleErrorResponse(resp)
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// InspectExec retrieves the state of an exec instance, including its exit code
+func (c *Client) InspectExec(ctx context.Context, environmentID int, execID string) (*ExecInspect, error) {
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/exec/%s/json", environmentID, url.PathEscape(execID))
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var inspect ExecInspect
+	if err := json.NewDecoder(resp.Body).Decode(&inspect); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &inspect, nil
+}
+
+// ResizeExec resizes the TTY of a running exec instance
+func (c *Client) ResizeExec(ctx context.Context, environmentID int, execID string, height, width int) error {
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/exec/%s/resize?h=%d&w=%d", environmentID, url.PathEscape(execID), height, width)
+	req, err := c.newRequest(ctx, "POST", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// AttachExec starts an exec instance through the Portainer websocket exec endpoint
+// and returns a connection carrying its input and output
+func (c *Client) AttachExec(ctx context.Context, environmentID int, execID string) (*ExecConn, error) {
+	wsURL, err := c.websocketURL("/api/websocket/exec", url.Values{
+		"endpointId": {fmt.Sprintf("%d", environmentID)},
+		"id":         {execID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build websocket URL: %w", err)
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            websocketProxy(c.proxy),
+		NetDialContext:   c.dialContext,
+		HandshakeTimeout: c.httpClient.Timeout,
+		TLSClientConfig:  c.tlsConfig.Clone(),
+	}
+
+	header := http.Header{}
+	c.setAuthHeader(header)
+
+	conn, resp, err := dialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return nil, c.handleErrorResponse(resp)
+		}
+		return nil, fmt.Errorf("failed to connect to exec websocket: %w", err)
+	}
+
+	return &ExecConn{conn: conn}, nil
+}
+
+// websocketURL converts the Portainer URL to a ws:// or wss:// URL for the given path
+func (c *Client) websocketURL(path string, params url.Values) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(c.baseURL, "/") + path)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("unsupported URL scheme: %s", u.Scheme)
+	}
+
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+// ExecConn is an attached exec session. Reading returns the process output and
+// writing sends input to the process. The connection ends when the process exits.
+type ExecConn struct {
+	conn    *websocket.Conn
+	reader  io.Reader
+	writeMu sync.Mutex
+}
+
+// Read reads process output, returning io.EOF once the session is closed
+func (e *ExecConn) Read(p []byte) (int, error) {
+	for {
+		if e.reader == nil {
+			_, r, err := e.conn.NextReader()
+			if err != nil {
+				if isExecClosed(err) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			e.reader = r
+		}
+
+		n, err := e.reader.Read(p)
+		if err == io.EOF {
+			e.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Write sends input to the process
+func (e *ExecConn) Write(p []byte) (int, error) {
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	if err := e.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the session
+func (e *ExecConn) Close() error {
+	e.writeMu.Lock()
+	_ = e.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	e.writeMu.Unlock()
+	return e.conn.Close()
+}
+
+// isExecClosed reports whether err means the exec session ended
+func isExecClosed(err error) bool {
+	var closeErr *websocket.CloseError
+	return errors.As(err, &closeErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// isFrameHeader reports whether b looks like a Docker stdcopy frame header
+func isFrameHeader(b []byte) bool {
+	if len(b) < logFrameHeaderSize {
+		return false
+	}
+	// Stream type is stdin (0), stdout (1) or stderr (2), followed by three zero bytes
+	return b[0] <= frameStderr && b[1] == 0 && b[2] == 0 && b[3] == 0
+}
+
+// CopyExecOutput copies the output of an exec session started without a TTY to stdout
+// and stderr. Docker multiplexes both streams in that case, using the same framing as
+// the logs endpoint. Output that is not multiplexed is copied to stdout as-is.
+func CopyExecOutput(stdout, stderr io.Writer, r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(logFrameHeaderSize)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if !isFrameHeader(header) {
+		_, err := io.Copy(stdout, br)
+		return err
+	}
+
+	hdr := make([]byte, logFrameHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, hdr); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+
+		out := stdout
+		if hdr[0] == frameStderr {
+			out = stderr
+		}
+
+		size := int64(binary.BigEndian.Uint32(hdr[4:]))
+		if _, err := io.CopyN(out, br, size); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
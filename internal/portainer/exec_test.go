@@ -0,0 +1,209 @@
+package portainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CreateExec(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/containers/abc123/exec", r.URL.Path)
+		assert.Equal(t, "test-token", r.Header.Get("X-API-Key"))
+
+		var config ExecConfig
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&config))
+		assert.Equal(t, []string{"/bin/sh"}, config.Cmd)
+		assert.True(t, config.Tty)
+		assert.True(t, config.AttachStdin)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": "exec123"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	execID, err := client.CreateExec(context.Background(), 1, "abc123", ExecConfig{
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          true,
+		Cmd:          []string{"/bin/sh"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "exec123", execID)
+}
+
+func TestClient_CreateExec_ContainerNotRunning(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(APIError{Message: "container abc123 is not running"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	_, err := client.CreateExec(context.Background(), 1, "abc123", ExecConfig{Cmd: []string{"ls"}})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not running")
+}
+
+func TestClient_InspectExec(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/exec/exec123/json", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ExecInspect{ID: "exec123", Running: false, ExitCode: 3})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	inspect, err := client.InspectExec(context.Background(), 1, "exec123")
+
+	require.NoError(t, err)
+	assert.False(t, inspect.Running)
+	assert.Equal(t, 3, inspect.ExitCode)
+}
+
+func TestClient_ResizeExec(t *testing.T) {
+	// Create a test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/endpoints/1/docker/exec/exec123/resize", r.URL.Path)
+		assert.Equal(t, "40", r.URL.Query().Get("h"))
+		assert.Equal(t, "120", r.URL.Query().Get("w"))
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	err := client.ResizeExec(context.Background(), 1, "exec123", 40, 120)
+
+	require.NoError(t, err)
+}
+
+func TestClient_AttachExec(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	// Create a test server echoing input back in upper case, then closing the session
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/websocket/exec", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("endpointId"))
+		assert.Equal(t, "exec123", r.URL.Query().Get("id"))
+		assert.Equal(t, "test-token", r.Header.Get("X-API-Key"))
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, bytes.ToUpper(msg)))
+		require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte(" done")))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	conn, err := client.AttachExec(context.Background(), 1, "exec123")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	output, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO done", string(output))
+}
+
+func TestClient_AttachExec_Unauthorized(t *testing.T) {
+	// Create a test server rejecting the websocket handshake
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(APIError{Message: "Unauthorized"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "bad-token")
+
+	conn, err := client.AttachExec(context.Background(), 1, "exec123")
+
+	assert.Error(t, err)
+	assert.Nil(t, conn)
+	assert.Contains(t, err.Error(), "Unauthorized")
+}
+
+func TestClient_WebsocketURL(t *testing.T) {
+	tests := []struct {
+		baseURL  string
+		expected string
+	}{
+		{baseURL: "https://portainer.example.com", expected: "wss://portainer.example.com/api/websocket/exec?id=x"},
+		{baseURL: "http://localhost:9000/", expected: "ws://localhost:9000/api/websocket/exec?id=x"},
+		{baseURL: "https://example.com/portainer", expected: "wss://example.com/portainer/api/websocket/exec?id=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			client := NewClient(tt.baseURL, "test-token")
+			wsURL, err := client.websocketURL("/api/websocket/exec", map[string][]string{"id": {"x"}})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, wsURL)
+		})
+	}
+}
+
+func TestIsFrameHeader(t *testing.T) {
+	assert.True(t, isFrameHeader([]byte{1, 0, 0, 0, 0, 0, 0, 5}))
+	assert.True(t, isFrameHeader([]byte{2, 0, 0, 0, 0, 0, 1, 0}))
+	assert.False(t, isFrameHeader([]byte("2023-01-")))
+	assert.False(t, isFrameHeader([]byte{1, 0, 0}))
+	assert.False(t, isFrameHeader([]byte{3, 0, 0, 0, 0, 0, 0, 5}))
+}
+
+func TestCopyExecOutput_Multiplexed(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(logFrame(1, "out line\n"))
+	stream.Write(logFrame(2, "err line\n"))
+	stream.Write(logFrame(1, "more out"))
+
+	var stdout, stderr bytes.Buffer
+	err := CopyExecOutput(&stdout, &stderr, &stream)
+
+	require.NoError(t, err)
+	assert.Equal(t, "out line\nmore out", stdout.String())
+	assert.Equal(t, "err line\n", stderr.String())
+}
+
+func TestCopyExecOutput_Raw(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := CopyExecOutput(&stdout, &stderr, bytes.NewBufferString("plain output\n"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "plain output\n", stdout.String())
+	assert.Empty(t, stderr.String())
+}
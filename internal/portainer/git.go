@@ -0,0 +1,218 @@
+package portainer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GitRepository describes the repository Portainer deploys a git stack from
+type GitRepository struct {
+	URL             string
+	ReferenceName   string // full reference, e.g. refs/heads/main
+	ComposeFile     string // path of the compose file in the repository
+	AdditionalFiles []string
+	Username        string
+	Password        string
+	CredentialID    int // git credential saved in Portainer, used instead of Username and Password
+	TLSSkipVerify   bool
+}
+
+// authenticated reports whether the repository is accessed with credentials
+func (r GitRepository) authenticated() bool {
+	return r.Username != "" || r.Password != "" || r.CredentialID != 0
+}
+
+// createGitStackRequest is the payload creating a stack from a git repository
+type createGitStackRequest struct {
+	Name                      string              `json:"name"`
+	SwarmID                   string              `json:"swarmID,omitempty"`
+	RepositoryURL             string              `json:"repositoryURL"`
+	RepositoryReferenceName   string              `json:"repositoryReferenceName"`
+	ComposeFile               string              `json:"composeFile"`
+	AdditionalFiles           []string            `json:"additionalFiles"`
+	RepositoryAuthentication  bool                `json:"repositoryAuthentication"`
+	RepositoryUsername        string              `json:"repositoryUsername"`
+	RepositoryPassword        string              `json:"repositoryPassword"`
+	RepositoryGitCredentialID int                 `json:"repositoryGitCredentialID"`
+	TLSSkipVerify             bool                `json:"tlsskipVerify"`
+	AutoUpdate                *AutoUpdateSettings `json:"autoUpdate"`
+	Env                       []EnvVar            `json:"env"`
+}
+
+// updateGitStackRequest is the payload updating the git settings of a stack
+type updateGitStackRequest struct {
+	RepositoryReferenceName   string              `json:"repositoryReferenceName"`
+	RepositoryAuthentication  bool                `json:"repositoryAuthentication"`
+	RepositoryUsername        string              `json:"repositoryUsername"`
+	RepositoryPassword        string              `json:"repositoryPassword"`
+	RepositoryGitCredentialID int                 `json:"repositoryGitCredentialID"`
+	TLSSkipVerify             bool                `json:"tlsskipVerify"`
+	AutoUpdate                *AutoUpdateSettings `json:"autoUpdate"`
+	Env                       []EnvVar            `json:"env"`
+	Prune                     bool                `json:"prune"`
+}
+
+// redeployGitStackRequest is the payload pulling the repository of a stack and redeploying it
+type redeployGitStackRequest struct {
+	RepositoryReferenceName   string   `json:"repositoryReferenceName"`
+	RepositoryAuthentication  bool     `json:"repositoryAuthentication"`
+	RepositoryUsername        string   `json:"repositoryUsername"`
+	RepositoryPassword        string   `json:"repositoryPassword"`
+	RepositoryGitCredentialID int      `json:"repositoryGitCredentialID"`
+	Env                       []EnvVar `json:"env"`
+	Prune                     bool     `json:"prune"`
+	PullImage                 bool     `json:"pullImage"`
+}
+
+// CreateGitStack creates a stack Portainer deploys from a git repository. swarmID is the
+// cluster ID on a Docker Swarm environment and empty on a standalone one, autoUpdate is nil
+// when Portainer should not redeploy the stack by itself.
+func (c *Client) CreateGitStack(ctx context.Context, name, swarmID string, repo GitRepository, autoUpdate *AutoUpdateSettings, env []EnvVar, environmentID int) (*Stack, error) {
+	payload := createGitStackRequest{
+		Name:                      name,
+		SwarmID:                   swarmID,
+		RepositoryURL:             repo.URL,
+		RepositoryReferenceName:   repo.ReferenceName,
+		ComposeFile:               repo.ComposeFile,
+		AdditionalFiles:           repo.AdditionalFiles,
+		RepositoryAuthentication:  repo.authenticated(),
+		RepositoryUsername:        repo.Username,
+		RepositoryPassword:        repo.Password,
+		RepositoryGitCredentialID: repo.CredentialID,
+		TLSSkipVerify:             repo.TLSSkipVerify,
+		AutoUpdate:                autoUpdate,
+		Env:                       nonNilEnv(env),
+	}
+	if payload.AdditionalFiles == nil {
+		payload.AdditionalFiles = []string{}
+	}
+
+	kind := "standalone"
+	if swarmID != "" {
+		kind = "swarm"
+	}
+
+	endpoint := fmt.Sprintf("/api/stacks/create/%s/repository?endpointId=%d", kind, environmentID)
+	resp, err := c.sendJSON(ctx, "POST", endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var stack Stack
+	if err := json.Unmarshal(body, &stack); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w, body: %s", err, string(body))
+	}
+	if stack.ID == 0 || stack.Name == "" {
+		return nil, fmt.Errorf("invalid stack response: ID=%d, Name=%s, body: %s", stack.ID, stack.Name, string(body))
+	}
+
+	return &stack, nil
+}
+
+// UpdateGitStack updates the reference, credentials, auto update settings and environment
+// variables of a git stack. The stack is not redeployed, see RedeployGitStack.
+func (c *Client) UpdateGitStack(ctx context.Context, stackID int, repo GitRepository, autoUpdate *AutoUpdateSettings, env []EnvVar, environmentID int) error {
+	payload := updateGitStackRequest{
+		RepositoryReferenceName:   repo.ReferenceName,
+		RepositoryAuthentication:  repo.authenticated(),
+		RepositoryUsername:        repo.Username,
+		RepositoryPassword:        repo.Password,
+		RepositoryGitCredentialID: repo.CredentialID,
+		TLSSkipVerify:             repo.TLSSkipVerify,
+		AutoUpdate:                autoUpdate,
+		Env:                       nonNilEnv(env),
+		Prune:                     true,
+	}
+
+	endpoint := fmt.Sprintf("/api/stacks/%d/git?endpointId=%d", stackID, environmentID)
+	resp, err := c.sendJSON(ctx, "POST", endpoint, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// RedeployGitStack pulls the repository of a git stack and redeploys it.
+// The stack environment variables are replaced by env.
+func (c *Client) RedeployGitStack(ctx context.Context, stackID int, repo GitRepository, env []EnvVar, pullImages bool, environmentID int) error {
+	payload := redeployGitStackRequest{
+		RepositoryReferenceName:   repo.ReferenceName,
+		RepositoryAuthentication:  repo.authenticated(),
+		RepositoryUsername:        repo.Username,
+		RepositoryPassword:        repo.Password,
+		RepositoryGitCredentialID: repo.CredentialID,
+		Env:                       nonNilEnv(env),
+		Prune:                     true,
+		PullImage:                 pullImages,
+	}
+
+	endpoint := fmt.Sprintf("/api/stacks/%d/git/redeploy?endpointId=%d", stackID, environmentID)
+	resp, err := c.sendJSON(ctx, "PUT", endpoint, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// WebhookURL returns the URL triggering the redeploy of a git stack with a webhook
+func (c *Client) WebhookURL(webhookID string) string {
+	return strings.TrimRight(c.baseURL, "/") + "/api/stacks/webhooks/" + webhookID
+}
+
+// NewWebhookID returns a random UUID identifying the webhook of a git stack.
+// Portainer expects the client to generate it.
+func NewWebhookID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// sendJSON sends payload as the JSON body of a request
+func (c *Client) sendJSON(ctx context.Context, method, path string, payload any) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, method, path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,121 @@
+package portainer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CreateGitStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/stacks/create/standalone/repository", r.URL.Path)
+		assert.Equal(t, "3", r.URL.Query().Get("endpointId"))
+
+		var body createGitStackRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "myapp", body.Name)
+		assert.Equal(t, "https://github.com/acme/myapp.git", body.RepositoryURL)
+		assert.Equal(t, "refs/heads/production", body.RepositoryReferenceName)
+		assert.Equal(t, "deploy/docker-compose.yml", body.ComposeFile)
+		assert.Equal(t, []string{}, body.AdditionalFiles)
+		assert.True(t, body.RepositoryAuthentication)
+		assert.Equal(t, "deployer", body.RepositoryUsername)
+		assert.Equal(t, "ghp_secret", body.RepositoryPassword)
+		assert.Equal(t, &AutoUpdateSettings{Interval: "5m0s", Webhook: "0b5e7d4c-1111-4222-8333-444455556666"}, body.AutoUpdate)
+		assert.Equal(t, []EnvVar{{Name: "APP_ENV", Value: "production"}}, body.Env)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Stack{
+			ID:            7,
+			Name:          "myapp",
+			EnvironmentID: 3,
+			GitConfig:     &GitConfig{URL: body.RepositoryURL, ReferenceName: body.RepositoryReferenceName},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	repo := GitRepository{
+		URL:           "https://github.com/acme/myapp.git",
+		ReferenceName: "refs/heads/production",
+		ComposeFile:   "deploy/docker-compose.yml",
+		Username:      "deployer",
+		Password:      "ghp_secret",
+	}
+	autoUpdate := &AutoUpdateSettings{Interval: "5m0s", Webhook: "0b5e7d4c-1111-4222-8333-444455556666"}
+
+	stack, err := client.CreateGitStack(context.Background(), "myapp", "", repo, autoUpdate, []EnvVar{{Name: "APP_ENV", Value: "production"}}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 7, stack.ID)
+	require.NotNil(t, stack.GitConfig)
+	assert.Equal(t, "refs/heads/production", stack.GitConfig.ReferenceName)
+}
+
+func TestClient_UpdateAndRedeployGitStack(t *testing.T) {
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		assert.Equal(t, "3", r.URL.Query().Get("endpointId"))
+
+		switch r.URL.Path {
+		case "/api/stacks/7/git":
+			var body updateGitStackRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "refs/tags/v1.2.0", body.RepositoryReferenceName)
+			assert.True(t, body.RepositoryAuthentication)
+			assert.Equal(t, 2, body.RepositoryGitCredentialID)
+			assert.Nil(t, body.AutoUpdate)
+			assert.Equal(t, []EnvVar{}, body.Env)
+		case "/api/stacks/7/git/redeploy":
+			var body redeployGitStackRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "refs/tags/v1.2.0", body.RepositoryReferenceName)
+			assert.Equal(t, 2, body.RepositoryGitCredentialID)
+			assert.True(t, body.PullImage)
+			assert.True(t, body.Prune)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Id": 7}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	repo := GitRepository{URL: "https://github.com/acme/myapp.git", ReferenceName: "refs/tags/v1.2.0", CredentialID: 2}
+
+	require.NoError(t, client.UpdateGitStack(context.Background(), 7, repo, nil, nil, 3))
+	require.NoError(t, client.RedeployGitStack(context.Background(), 7, repo, nil, true, 3))
+	assert.Equal(t, []string{"POST /api/stacks/7/git", "PUT /api/stacks/7/git/redeploy"}, calls)
+}
+
+func TestClient_RedeployGitStack_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"message": "Unable to clone git repository", "details": "authentication required"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	err := client.RedeployGitStack(context.Background(), 7, GitRepository{}, nil, true, 3)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Unable to clone git repository", apiErr.Message)
+}
+
+func TestNewWebhookID(t *testing.T) {
+	id := NewWebhookID()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), id)
+	assert.NotEqual(t, id, NewWebhookID())
+}
+
+func TestClient_WebhookURL(t *testing.T) {
+	client := NewClient("https://portainer.example.com/", "test-token")
+	assert.Equal(t, "https://portainer.example.com/api/stacks/webhooks/abc", client.WebhookURL("abc"))
+}
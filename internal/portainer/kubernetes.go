@@ -0,0 +1,120 @@
+package portainer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// CreateKubernetesStack creates a new stack in Portainer on a Kubernetes environment. The
+// content is a compose file converted to manifests by Portainer when composeFormat is set,
+// Kubernetes manifests otherwise.
+func (c *Client) CreateKubernetesStack(ctx context.Context, name, namespace, content string, composeFormat bool, environmentID int) (*Stack, error) {
+	reqBody := map[string]interface{}{
+		"stackName":        name,
+		"namespace":        namespace,
+		"composeFormat":    composeFormat,
+		"stackFileContent": content,
+	}
+	return c.createStack(ctx, "kubernetes", reqBody, environmentID)
+}
+
+// GetStackPods retrieves the pods of a Kubernetes stack via the Kubernetes proxy. Portainer
+// labels the workloads of the stack, and their pods, with the stack name.
+func (c *Client) GetStackPods(ctx context.Context, environmentID int, namespace, stackName string) ([]Pod, error) {
+	params := url.Values{}
+	params.Set("labelSelector", fmt.Sprintf("%s=%s", KubernetesStackLabel, stackName))
+
+	var pods PodList
+	endpoint := fmt.Sprintf("%s/pods?%s", kubernetesNamespacePath(environmentID, namespace), params.Encode())
+	if err := c.getJSON(ctx, endpoint, &pods); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// GetPodLogs retrieves logs for a container of a pod via the Kubernetes proxy
+func (c *Client) GetPodLogs(ctx context.Context, environmentID int, namespace, podName, container string, tail int) ([]LogEntry, error) {
+	return c.getLogs(ctx, podLogsPath(environmentID, namespace, podName), kubernetesLogParams(container, tail, false), false)
+}
+
+// StreamPodLogs follows the logs of a container of a pod via the Kubernetes proxy.
+// onEntry is called for every log line until the stream ends or ctx is cancelled.
+func (c *Client) StreamPodLogs(ctx context.Context, environmentID int, namespace, podName, container string, tail int, onEntry func(LogEntry)) error {
+	return c.streamLogs(ctx, podLogsPath(environmentID, namespace, podName), kubernetesLogParams(container, tail, true), false, onEntry)
+}
+
+// kubernetesNamespacePath returns the Kubernetes API path of a namespace through the proxy
+func kubernetesNamespacePath(environmentID int, namespace string) string {
+	return fmt.Sprintf("/api/endpoints/%d/kubernetes/api/v1/namespaces/%s", environmentID, url.PathEscape(namespace))
+}
+
+func podLogsPath(environmentID int, namespace, podName string) string {
+	return fmt.Sprintf("%s/pods/%s/log", kubernetesNamespacePath(environmentID, namespace), url.PathEscape(podName))
+}
+
+// kubernetesLogParams returns the query of the pod logs endpoint. Kubernetes does not
+// multiplex stdout and stderr, the lines are returned as plain text.
+func kubernetesLogParams(container string, tail int, follow bool) url.Values {
+	params := url.Values{}
+	params.Set("container", container)
+	if tail > 0 {
+		params.Set("tailLines", fmt.Sprintf("%d", tail))
+	}
+	if follow {
+		params.Set("follow", "true")
+	}
+	params.Set("timestamps", "true")
+	return params
+}
+
+// Ready returns the number of ready containers of the pod, and its number of containers
+func (p *Pod) Ready() (ready, total int) {
+	for _, status := range p.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+	}
+	return ready, len(p.Spec.Containers)
+}
+
+// Restarts returns the number of container restarts of the pod
+func (p *Pod) Restarts() int {
+	restarts := 0
+	for _, status := range p.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// StatusText returns the status kubectl displays for the pod: the reason a container is not
+// running (e.g. CrashLoopBackOff) when there is one, the pod phase otherwise
+func (p *Pod) StatusText() string {
+	if p.Metadata.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	if p.Status.Reason != "" {
+		return p.Status.Reason
+	}
+	for _, status := range p.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" {
+			return waiting.Reason
+		}
+		if terminated := status.State.Terminated; terminated != nil && terminated.Reason != "" && p.Status.Phase != "Succeeded" {
+			return terminated.Reason
+		}
+	}
+	if p.Status.Phase == "" {
+		return "Unknown"
+	}
+	return p.Status.Phase
+}
+
+// ContainerNames returns the names of the containers of the pod
+func (p *Pod) ContainerNames() []string {
+	names := make([]string, len(p.Spec.Containers))
+	for i, container := range p.Spec.Containers {
+		names[i] = container.Name
+	}
+	return names
+}
@@ -0,0 +1,162 @@
+package portainer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CreateKubernetesStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/stacks/create/kubernetes/string", r.URL.Path)
+		assert.Equal(t, "5", r.URL.Query().Get("endpointId"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "shop", body["stackName"])
+		assert.Equal(t, "shop-staging", body["namespace"])
+		assert.Equal(t, true, body["composeFormat"])
+		assert.Equal(t, "services: {}\n", body["stackFileContent"])
+
+		w.Write([]byte(`{"Id": 9, "Name": "shop", "Type": 3, "EndpointId": 5, "Namespace": "shop-staging"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	stack, err := client.CreateKubernetesStack(context.Background(), "shop", "shop-staging", "services: {}\n", true, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 9, stack.ID)
+	assert.True(t, stack.IsKubernetes())
+	assert.Equal(t, "shop-staging", stack.Namespace)
+}
+
+func TestEnvironment_IsKubernetes(t *testing.T) {
+	tests := []struct {
+		envType    int
+		kubernetes bool
+	}{
+		{EnvironmentTypeDocker, false},
+		{EnvironmentTypeEdgeAgentOnDocker, false},
+		{EnvironmentTypeKubernetesLocal, true},
+		{EnvironmentTypeAgentOnKubernetes, true},
+		{EnvironmentTypeEdgeAgentOnKubernetes, true},
+	}
+
+	for _, tt := range tests {
+		environment := &Environment{Type: tt.envType}
+		assert.Equal(t, tt.kubernetes, environment.IsKubernetes(), "type %d", tt.envType)
+	}
+}
+
+func TestClient_GetStackPods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/5/kubernetes/api/v1/namespaces/shop/pods", r.URL.Path)
+		assert.Equal(t, KubernetesStackLabel+"=shop", r.URL.Query().Get("labelSelector"))
+		w.Write([]byte(`{"kind": "PodList", "items": [{
+			"metadata": {"name": "web-7d9f-x2x", "namespace": "shop", "creationTimestamp": "2024-05-01T10:00:00Z"},
+			"spec": {"nodeName": "node-1", "containers": [{"name": "web", "image": "nginx"}, {"name": "proxy", "image": "envoy"}]},
+			"status": {"phase": "Running", "containerStatuses": [
+				{"name": "web", "ready": true, "restartCount": 1, "state": {"running": {}}},
+				{"name": "proxy", "ready": false, "restartCount": 3, "state": {"waiting": {"reason": "CrashLoopBackOff"}}}
+			]}
+		}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	pods, err := client.GetStackPods(context.Background(), 5, "shop", "shop")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+
+	pod := pods[0]
+	assert.Equal(t, "web-7d9f-x2x", pod.Metadata.Name)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), pod.Metadata.CreationTimestamp)
+	assert.Equal(t, []string{"web", "proxy"}, pod.ContainerNames())
+
+	ready, total := pod.Ready()
+	assert.Equal(t, 1, ready)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 4, pod.Restarts())
+	assert.Equal(t, "CrashLoopBackOff", pod.StatusText())
+}
+
+func TestPod_StatusText(t *testing.T) {
+	deleted := time.Now()
+	tests := []struct {
+		name string
+		pod  Pod
+		want string
+	}{
+		{name: "running", pod: Pod{Status: PodStatus{Phase: "Running"}}, want: "Running"},
+		{name: "no phase", pod: Pod{}, want: "Unknown"},
+		{name: "evicted", pod: Pod{Status: PodStatus{Phase: "Failed", Reason: "Evicted"}}, want: "Evicted"},
+		{name: "terminating", pod: Pod{Metadata: PodMetadata{DeletionTimestamp: &deleted}, Status: PodStatus{Phase: "Running"}}, want: "Terminating"},
+		{
+			name: "completed job",
+			pod: Pod{Status: PodStatus{Phase: "Succeeded", ContainerStatuses: []ContainerStatus{
+				{State: ContainerState{Terminated: &ContainerStateReason{Reason: "Completed"}}},
+			}}},
+			want: "Succeeded",
+		},
+		{
+			name: "out of memory",
+			pod: Pod{Status: PodStatus{Phase: "Running", ContainerStatuses: []ContainerStatus{
+				{State: ContainerState{Terminated: &ContainerStateReason{Reason: "OOMKilled"}}},
+			}}},
+			want: "OOMKilled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.pod.StatusText())
+		})
+	}
+}
+
+func TestClient_GetPodLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/5/kubernetes/api/v1/namespaces/shop/pods/web-7d9f-x2x/log", r.URL.Path)
+		assert.Equal(t, "web", r.URL.Query().Get("container"))
+		assert.Equal(t, "50", r.URL.Query().Get("tailLines"))
+		assert.Equal(t, "true", r.URL.Query().Get("timestamps"))
+		assert.Empty(t, r.URL.Query().Get("follow"))
+		w.Write([]byte("2024-05-01T10:00:00.000000001Z listening on :8080\n2024-05-01T10:00:01Z ready\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	entries, err := client.GetPodLogs(context.Background(), 5, "shop", "web-7d9f-x2x", "web", 50)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "listening on :8080", entries[0].Message)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC), entries[1].Timestamp)
+}
+
+func TestClient_StreamPodLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("follow"))
+		assert.Empty(t, r.URL.Query().Get("tailLines"))
+		w.Write([]byte("2024-05-01T10:00:00Z started\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	var messages []string
+	err := client.StreamPodLogs(context.Background(), 5, "shop", "web-7d9f-x2x", "web", 0, func(entry LogEntry) {
+		messages = append(messages, entry.Message)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"started"}, messages)
+}
@@ -0,0 +1,137 @@
+package portainer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Docker multiplexes stdout and stderr on the logs endpoint of non-TTY containers.
+// Each frame starts with an 8 byte header: [stream, 0, 0, 0, size1, size2, size3, size4]
+const logFrameHeaderSize = 8
+
+// Stream types used in the frame header
+const (
+	frameStdout = 1
+	frameStderr = 2
+)
+
+// ansiEscapePattern matches ANSI escape sequences (colors, cursor movement)
+var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b[@-Z\\-_]`)
+
+// demuxLogStream reads a Docker log stream and calls onEntry for every complete line.
+// Docker multiplexes stdout and stderr unless the container has a TTY, streams that are not
+// multiplexed are reported as LogStreamTTY. The caller knows it from the container
+// configuration: peeking at the stream would block a follow until 8 bytes are logged.
+func demuxLogStream(r io.Reader, multiplexed bool, onEntry func(LogEntry)) error {
+	if !multiplexed {
+		return scanLines(r, onEntry)
+	}
+
+	br := bufio.NewReader(r)
+
+	// Keep partial lines per stream since a line may span several frames
+	pending := map[LogStream]*bytes.Buffer{
+		LogStreamStdout: {},
+		LogStreamStderr: {},
+	}
+	hdr := make([]byte, logFrameHeaderSize)
+
+	for {
+		if _, err := io.ReadFull(br, hdr); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return err
+		}
+
+		size := binary.BigEndian.Uint32(hdr[4:])
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return err
+		}
+
+		stream := frameStream(hdr[0])
+		buf := pending[stream]
+		buf.Write(payload)
+
+		for {
+			idx := bytes.IndexByte(buf.Bytes(), '\n')
+			if idx < 0 {
+				break
+			}
+			line := string(buf.Next(idx + 1))
+			onEntry(parseLogLine(stream, line))
+		}
+	}
+
+	// Flush any trailing data without a newline, stdout first for a stable output
+	for _, stream := range []LogStream{LogStreamStdout, LogStreamStderr} {
+		if buf := pending[stream]; buf.Len() > 0 {
+			onEntry(parseLogLine(stream, buf.String()))
+		}
+	}
+
+	return nil
+}
+
+// frameStream maps a frame header stream type to a LogStream
+func frameStream(streamType byte) LogStream {
+	if streamType == frameStderr {
+		return LogStreamStderr
+	}
+	return LogStreamStdout
+}
+
+// scanLines reads plain text lines from a non-multiplexed (TTY) stream
+func scanLines(r io.Reader, onEntry func(LogEntry)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		onEntry(parseLogLine(LogStreamTTY, scanner.Text()))
+	}
+	return scanner.Err()
+}
+
+// parseLogLine splits the timestamp added by Docker from the message
+func parseLogLine(stream LogStream, line string) LogEntry {
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	entry := LogEntry{Stream: stream}
+	if ts, msg, found := strings.Cut(line, " "); found {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			entry.Timestamp = t
+			line = msg
+		}
+	} else if t, err := time.Parse(time.RFC3339Nano, line); err == nil {
+		// Empty log line with only a timestamp
+		entry.Timestamp = t
+		line = ""
+	}
+
+	entry.Message = sanitizeLogMessage(line)
+	return entry
+}
+
+// sanitizeLogMessage strips escape sequences, control characters and invalid UTF-8
+// so that binary output cannot corrupt the terminal
+func sanitizeLogMessage(msg string) string {
+	msg = ansiEscapePattern.ReplaceAllString(msg, "")
+	msg = strings.ToValidUTF8(msg, "")
+
+	return strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, msg)
+}
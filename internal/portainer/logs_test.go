@@ -0,0 +1,157 @@
+package portainer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logFrame builds a Docker stdcopy frame for the given stream and payload
+func logFrame(stream byte, payload string) []byte {
+	header := make([]byte, logFrameHeaderSize)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, []byte(payload)...)
+}
+
+// collectEntries demultiplexes r and returns every entry
+func collectEntries(t *testing.T, r *bytes.Buffer, multiplexed bool) []LogEntry {
+	var entries []LogEntry
+	err := demuxLogStream(r, multiplexed, func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
+	require.NoError(t, err)
+	return entries
+}
+
+func TestDemuxLogStream_Multiplexed(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(logFrame(1, "2023-01-01T12:00:00.123456789Z first line\n"))
+	stream.Write(logFrame(2, "2023-01-01T12:00:01Z error line\n"))
+	stream.Write(logFrame(1, "2023-01-01T12:00:02Z second line\n"))
+
+	entries := collectEntries(t, &stream, true)
+
+	require.Len(t, entries, 3)
+	assert.Equal(t, LogStreamStdout, entries[0].Stream)
+	assert.Equal(t, time.Date(2023, 1, 1, 12, 0, 0, 123456789, time.UTC), entries[0].Timestamp)
+	assert.Equal(t, "first line", entries[0].Message)
+	assert.Equal(t, LogStreamStderr, entries[1].Stream)
+	assert.Equal(t, "error line", entries[1].Message)
+	assert.Equal(t, LogStreamStdout, entries[2].Stream)
+	assert.Equal(t, "second line", entries[2].Message)
+}
+
+func TestDemuxLogStream_LineSpanningFrames(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(logFrame(1, "2023-01-01T12:00:00Z partial"))
+	stream.Write(logFrame(1, " line\n2023-01-01T12:00:01Z next\n"))
+	stream.Write(logFrame(1, "2023-01-01T12:00:02Z no newline"))
+
+	entries := collectEntries(t, &stream, true)
+
+	require.Len(t, entries, 3)
+	assert.Equal(t, "partial line", entries[0].Message)
+	assert.Equal(t, "next", entries[1].Message)
+	assert.Equal(t, "no newline", entries[2].Message)
+}
+
+func TestDemuxLogStream_TTY(t *testing.T) {
+	stream := bytes.NewBufferString("2023-01-01T12:00:00Z tty line\r\n2023-01-01T12:00:01Z another\n")
+
+	entries := collectEntries(t, stream, false)
+
+	require.Len(t, entries, 2)
+	assert.Equal(t, LogStreamTTY, entries[0].Stream)
+	assert.Equal(t, "tty line", entries[0].Message)
+	assert.Equal(t, LogStreamTTY, entries[1].Stream)
+	assert.Equal(t, "another", entries[1].Message)
+}
+
+func TestDemuxLogStream_TrailingLinesOrder(t *testing.T) {
+	// The partial lines left at the end of the stream are flushed stdout first, every time
+	for i := 0; i < 20; i++ {
+		var stream bytes.Buffer
+		stream.Write(logFrame(2, "2023-01-01T12:00:00Z stderr tail"))
+		stream.Write(logFrame(1, "2023-01-01T12:00:01Z stdout tail"))
+
+		entries := collectEntries(t, &stream, true)
+
+		require.Len(t, entries, 2)
+		assert.Equal(t, LogStreamStdout, entries[0].Stream)
+		assert.Equal(t, "stdout tail", entries[0].Message)
+		assert.Equal(t, LogStreamStderr, entries[1].Stream)
+		assert.Equal(t, "stderr tail", entries[1].Message)
+	}
+}
+
+func TestDemuxLogStream_Empty(t *testing.T) {
+	assert.Empty(t, collectEntries(t, &bytes.Buffer{}, true))
+	assert.Empty(t, collectEntries(t, &bytes.Buffer{}, false))
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		expectedTime  time.Time
+		expectedMsg   string
+		expectNoStamp bool
+	}{
+		{
+			name:         "timestamp and message",
+			line:         "2023-01-01T12:00:00Z hello world",
+			expectedTime: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+			expectedMsg:  "hello world",
+		},
+		{
+			name:          "no timestamp",
+			line:          "hello world",
+			expectedMsg:   "hello world",
+			expectNoStamp: true,
+		},
+		{
+			name:         "timestamp only",
+			line:         "2023-01-01T12:00:00Z",
+			expectedTime: time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+			expectedMsg:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := parseLogLine(LogStreamStdout, tt.line)
+			assert.Equal(t, tt.expectedMsg, entry.Message)
+			if tt.expectNoStamp {
+				assert.True(t, entry.Timestamp.IsZero())
+			} else {
+				assert.True(t, tt.expectedTime.Equal(entry.Timestamp))
+			}
+		})
+	}
+}
+
+func TestSanitizeLogMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain text", input: "hello world", expected: "hello world"},
+		{name: "keeps tabs", input: "key\tvalue", expected: "key\tvalue"},
+		{name: "strips ANSI colors", input: "\x1b[31mred\x1b[0m text", expected: "red text"},
+		{name: "strips control characters", input: "bell\x07 and\x00 nul", expected: "bell and nul"},
+		{name: "strips invalid UTF-8", input: "bad\xff\xfe bytes", expected: "bad bytes"},
+		{name: "keeps unicode", input: "café ✓", expected: "café ✓"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeLogMessage(tt.input))
+		})
+	}
+}
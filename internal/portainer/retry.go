@@ -0,0 +1,160 @@
+package portainer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Default retry policy of the client
+const (
+	DefaultRetryMaxAttempts    = 4
+	DefaultRetryInitialBackoff = 500 * time.Millisecond
+	DefaultRetryMaxBackoff     = 10 * time.Second
+
+	// maxRetryAfter caps the delay requested by the server so that a deploy does not hang
+	maxRetryAfter = time.Minute
+)
+
+// RetryPolicy controls how idempotent requests are retried on transient failures.
+// Zero values use the defaults, MaxAttempts of 1 disables retries.
+type RetryPolicy struct {
+	MaxAttempts    int           // total number of attempts, including the first one
+	InitialBackoff time.Duration // delay before the first retry, doubled on every retry
+	MaxBackoff     time.Duration // maximum delay between two attempts
+}
+
+// RetryEvent describes a failed attempt that is about to be retried
+type RetryEvent struct {
+	Method      string
+	Path        string
+	Attempt     int // attempt that failed, starting at 1
+	MaxAttempts int
+	Delay       time.Duration // delay before the next attempt
+	Err         error         // network error or unexpected status
+}
+
+// withDefaults returns the policy with defaults applied to unset values
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry (1 for the first retry): exponential
+// backoff capped at MaxBackoff, with jitter so that clients do not retry in lockstep
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxBackoff)
+
+	// Equal jitter: half of the delay is fixed, the other half is random
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// do sends a request. Requests that are safe to repeat (GET, HEAD) are retried on network
+// errors and transient statuses, other requests are sent once.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return c.httpClient.Do(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if ctx.Err() != nil || attempt >= c.retry.MaxAttempts || isPermanentError(err) {
+			if err != nil && attempt > 1 {
+				return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return resp, err
+		}
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			delay = c.retry.backoff(attempt)
+		case isRetryableStatus(resp.StatusCode):
+			delay = c.retry.backoff(attempt)
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, maxRetryAfter)
+			}
+			err = fmt.Errorf("server returned status %d", resp.StatusCode)
+			// Drain the body so that the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		if c.onRetry != nil {
+			c.onRetry(RetryEvent{
+				Method:      req.Method,
+				Path:        req.URL.Path,
+				Attempt:     attempt,
+				MaxAttempts: c.retry.MaxAttempts,
+				Delay:       delay,
+				Err:         err,
+			})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed after %d attempts: %w", attempt, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryableStatus reports whether a status is transient: rate limiting or an unavailable
+// server, typically a proxy or load balancer in front of Portainer. 500 is included as
+// Portainer returns it when the Docker proxy fails to reach the agent, and only GET and HEAD
+// requests are retried.
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isPermanentError reports whether a request error cannot be solved by retrying,
+// like a certificate that is not trusted
+func isPermanentError(err error) bool {
+	var (
+		verificationErr *tls.CertificateVerificationError
+		recordHeaderErr tls.RecordHeaderError
+		mismatchErr     *FingerprintMismatchError
+	)
+	return errors.As(err, &verificationErr) || errors.As(err, &recordHeaderErr) || errors.As(err, &mismatchErr)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
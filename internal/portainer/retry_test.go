@@ -0,0 +1,230 @@
+package portainer
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetry keeps the tests quick while exercising the retry loop
+var fastRetry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestClient_RetryTransientStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Environment{{ID: 1, Name: "local"}})
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{
+		Retry:   fastRetry,
+		OnRetry: func(event RetryEvent) { events = append(events, event) },
+	})
+
+	environments, err := client.GetEnvironments(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, environments, 1)
+	assert.Equal(t, int32(3), calls.Load())
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "GET", events[0].Method)
+	assert.Equal(t, "/api/endpoints", events[0].Path)
+	assert.Equal(t, 1, events[0].Attempt)
+	assert.Equal(t, 3, events[0].MaxAttempts)
+	assert.Equal(t, time.Duration(0), events[0].Delay) // Retry-After is honoured
+	assert.Contains(t, events[0].Err.Error(), "503")
+	assert.Equal(t, 2, events[1].Attempt)
+}
+
+func TestClient_RetryNetworkError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Drop the connection without a response
+			panic(http.ErrAbortHandler)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"NCPU": 4})
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{Retry: fastRetry})
+
+	info, err := client.GetDockerInfo(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), info["NCPU"])
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_RetryExhausted(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"message": "Bad gateway"}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{Retry: fastRetry})
+
+	_, err := client.GetStack(context.Background(), "test-stack", 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Bad gateway")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_RetryInternalServerError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Portainer fails this way when the Docker proxy cannot reach the agent
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message": "Unable to proxy the request via the Docker socket"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"NCPU": 4})
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{Retry: fastRetry})
+
+	info, err := client.GetDockerInfo(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), info["NCPU"])
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_NoRetryForNonIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{Retry: fastRetry})
+
+	err := client.StopStack(context.Background(), 1, 1)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_NoRetryForClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{Retry: fastRetry})
+
+	_, err := client.GetEnvironments(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_NoRetryForCertificateErrors(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	// The self-signed test certificate is not trusted
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{Retry: fastRetry})
+
+	_, err := client.GetEnvironments(context.Background())
+	var verificationErr *tls.CertificateVerificationError
+	require.ErrorAs(t, err, &verificationErr)
+	assert.Equal(t, int32(1), connections.Load())
+}
+
+func TestClient_RetryCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewClientWithOptions(server.URL, "test-token", ClientOptions{
+		Retry:   fastRetry,
+		OnRetry: func(RetryEvent) { cancel() },
+	})
+
+	start := time.Now()
+	_, err := client.GetEnvironments(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}.withDefaults()
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := policy.backoff(tt.retry)
+			assert.GreaterOrEqual(t, delay, tt.max/2)
+			assert.LessOrEqual(t, delay, tt.max)
+		}
+	}
+}
+
+func TestRetryPolicy_WithDefaults(t *testing.T) {
+	policy := RetryPolicy{}.withDefaults()
+
+	assert.Equal(t, DefaultRetryMaxAttempts, policy.MaxAttempts)
+	assert.Equal(t, DefaultRetryInitialBackoff, policy.InitialBackoff)
+	assert.Equal(t, DefaultRetryMaxBackoff, policy.MaxBackoff)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	delay, ok := parseRetryAfter("5")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+
+	delay, ok = parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
@@ -0,0 +1,109 @@
+package portainer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// GetSwarmID returns the ID of the Docker Swarm cluster an environment belongs to, empty when
+// the environment is a standalone Docker host
+func (c *Client) GetSwarmID(ctx context.Context, environmentID int) (string, error) {
+	info, err := c.GetDockerInfo(ctx, environmentID)
+	if err != nil {
+		return "", err
+	}
+	return swarmClusterID(info)
+}
+
+// swarmClusterID extracts the cluster ID from the swarm state of a Docker info response
+func swarmClusterID(info map[string]interface{}) (string, error) {
+	swarm, _ := info["Swarm"].(map[string]interface{})
+	if state, _ := swarm["LocalNodeState"].(string); state != "active" {
+		return "", nil
+	}
+
+	// Only managers know the cluster, stacks cannot be deployed through a worker
+	cluster, _ := swarm["Cluster"].(map[string]interface{})
+	id, _ := cluster["ID"].(string)
+	if id == "" {
+		return "", fmt.Errorf("the environment is a swarm worker node, stacks must be deployed through a manager node")
+	}
+	return id, nil
+}
+
+// GetStackServices retrieves the services of a swarm stack via Docker proxy
+func (c *Client) GetStackServices(ctx context.Context, environmentID int, stackName string) ([]Service, error) {
+	var services []Service
+	if err := c.listStackObjects(ctx, environmentID, "services", stackName, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// GetStackTasks retrieves the tasks of a swarm stack via Docker proxy, including the tasks
+// that were shut down and replaced
+func (c *Client) GetStackTasks(ctx context.Context, environmentID int, stackName string) ([]Task, error) {
+	var tasks []Task
+	if err := c.listStackObjects(ctx, environmentID, "tasks", stackName, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// listStackObjects lists the swarm objects (services or tasks) labelled with the stack namespace
+func (c *Client) listStackObjects(ctx context.Context, environmentID int, kind, stackName string, out any) error {
+	// Docker stack deploy labels the services and their tasks with the stack name
+	filters := map[string][]string{
+		"label": {fmt.Sprintf("%s=%s", SwarmNamespaceLabel, stackName)},
+	}
+
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("/api/endpoints/%d/docker/%s?filters=%s", environmentID, kind, url.QueryEscape(string(filtersJSON)))
+	return c.getJSON(ctx, endpoint, out)
+}
+
+// GetTaskLogs retrieves logs for a specific swarm task via Docker proxy.
+// tty is the TTY setting of the task container, whose logs are multiplexed without it.
+func (c *Client) GetTaskLogs(ctx context.Context, environmentID int, taskID string, tty bool, tail int) ([]LogEntry, error) {
+	return c.getLogs(ctx, fmt.Sprintf("/api/endpoints/%d/docker/tasks/%s/logs", environmentID, taskID), dockerLogParams(tail, false), !tty)
+}
+
+// StreamTaskLogs follows the logs of a swarm task via Docker proxy.
+// onEntry is called for every log line until the stream ends or ctx is cancelled.
+func (c *Client) StreamTaskLogs(ctx context.Context, environmentID int, taskID string, tty bool, tail int, onEntry func(LogEntry)) error {
+	return c.streamLogs(ctx, fmt.Sprintf("/api/endpoints/%d/docker/tasks/%s/logs", environmentID, taskID), dockerLogParams(tail, true), !tty, onEntry)
+}
+
+// TaskName returns the name Docker gives to a task of a service: the service name followed
+// by the replica number, or by the node ID for global services
+func TaskName(serviceName string, task Task) string {
+	if task.Slot > 0 {
+		return fmt.Sprintf("%s.%d", serviceName, task.Slot)
+	}
+	return fmt.Sprintf("%s.%s", serviceName, task.NodeID)
+}
+
+// CurrentTasks returns the most recent task of every replica (or node for global services),
+// older tasks were shut down and replaced by Docker
+func CurrentTasks(tasks []Task) []Task {
+	latest := make(map[string]int)
+	var current []Task
+	for _, task := range tasks {
+		key := TaskName(task.ServiceID, task)
+		i, seen := latest[key]
+		switch {
+		case !seen:
+			latest[key] = len(current)
+			current = append(current, task)
+		case task.CreatedAt.After(current[i].CreatedAt):
+			current[i] = task
+		}
+	}
+	return current
+}
@@ -0,0 +1,260 @@
+package portainer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwarmClusterID(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "standalone",
+			info: `{"NCPU": 4, "Swarm": {"LocalNodeState": "inactive"}}`,
+		},
+		{
+			name: "no swarm state",
+			info: `{"NCPU": 4}`,
+		},
+		{
+			name: "manager",
+			info: `{"Swarm": {"LocalNodeState": "active", "ControlAvailable": true, "Cluster": {"ID": "jpofkc0i9uo9wtx1zesuk649w"}}}`,
+			want: "jpofkc0i9uo9wtx1zesuk649w",
+		},
+		{
+			name:    "worker",
+			info:    `{"Swarm": {"LocalNodeState": "active", "ControlAvailable": false}}`,
+			wantErr: "swarm worker node",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.info), &info))
+
+			id, err := swarmClusterID(info)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, id)
+		})
+	}
+}
+
+func TestClient_GetSwarmID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/2/docker/info", r.URL.Path)
+		w.Write([]byte(`{"Swarm": {"LocalNodeState": "active", "Cluster": {"ID": "jpofkc0i9uo9wtx1zesuk649w"}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	id, err := client.GetSwarmID(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "jpofkc0i9uo9wtx1zesuk649w", id)
+}
+
+func TestClient_CreateSwarmStack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/stacks/create/swarm/string", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("endpointId"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "myapp", body["name"])
+		assert.Equal(t, "jpofkc0i9uo9wtx1zesuk649w", body["swarmID"])
+		assert.Equal(t, "services:\n  web:\n    image: nginx\n", body["stackFileContent"])
+		assert.Equal(t, []interface{}{}, body["env"])
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Stack{ID: 4, Name: "myapp", Type: StackTypeSwarm, EnvironmentID: 2, SwarmID: "jpofkc0i9uo9wtx1zesuk649w"})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	stack, err := client.CreateSwarmStack(context.Background(), "myapp", "jpofkc0i9uo9wtx1zesuk649w", "services:\n  web:\n    image: nginx\n", nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stack.ID)
+	assert.True(t, stack.IsSwarm())
+}
+
+func TestClient_CreateGitStack_Swarm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/stacks/create/swarm/repository", r.URL.Path)
+
+		var body createGitStackRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "jpofkc0i9uo9wtx1zesuk649w", body.SwarmID)
+
+		json.NewEncoder(w).Encode(Stack{ID: 9, Name: "myapp", Type: StackTypeSwarm})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+	repo := GitRepository{URL: "https://github.com/acme/myapp.git", ReferenceName: "refs/heads/main", ComposeFile: "docker-compose.yml"}
+
+	stack, err := client.CreateGitStack(context.Background(), "myapp", "jpofkc0i9uo9wtx1zesuk649w", repo, nil, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 9, stack.ID)
+}
+
+func TestClient_GetStackServices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/endpoints/2/docker/services", r.URL.Path)
+		assert.Equal(t, `{"label":["com.docker.stack.namespace=myapp"]}`, r.URL.Query().Get("filters"))
+
+		w.Write([]byte(`[{
+			"ID": "svc1",
+			"Spec": {
+				"Name": "myapp_web",
+				"Labels": {"com.docker.stack.namespace": "myapp"},
+				"TaskTemplate": {"ContainerSpec": {"Image": "nginx:latest@sha256:abc"}},
+				"Mode": {"Replicated": {"Replicas": 3}}
+			},
+			"Endpoint": {"Ports": [{"Protocol": "tcp", "TargetPort": 80, "PublishedPort": 8080, "PublishMode": "ingress"}]}
+		}, {
+			"ID": "svc2",
+			"Spec": {"Name": "myapp_agent", "Mode": {"Global": {}}}
+		}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	services, err := client.GetStackServices(context.Background(), 2, "myapp")
+	require.NoError(t, err)
+	require.Len(t, services, 2)
+	assert.Equal(t, "myapp_web", services[0].Spec.Name)
+	assert.Equal(t, "nginx:latest@sha256:abc", services[0].Spec.TaskTemplate.ContainerSpec.Image)
+	require.NotNil(t, services[0].Spec.Mode.Replicated)
+	assert.Equal(t, uint64(3), services[0].Spec.Mode.Replicated.Replicas)
+	assert.Equal(t, 8080, services[0].Endpoint.Ports[0].PublishedPort)
+	assert.Nil(t, services[1].Spec.Mode.Replicated)
+	assert.NotNil(t, services[1].Spec.Mode.Global)
+}
+
+func TestClient_GetStackTasks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/2/docker/tasks", r.URL.Path)
+		assert.Equal(t, `{"label":["com.docker.stack.namespace=myapp"]}`, r.URL.Query().Get("filters"))
+
+		w.Write([]byte(`[{
+			"ID": "task1",
+			"ServiceID": "svc1",
+			"Slot": 1,
+			"NodeID": "node1",
+			"DesiredState": "running",
+			"Status": {"Timestamp": "2024-05-01T10:00:00Z", "State": "running", "Message": "started"},
+			"CreatedAt": "2024-05-01T09:59:58Z"
+		}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	tasks, err := client.GetStackTasks(context.Background(), 2, "myapp")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "svc1", tasks[0].ServiceID)
+	assert.Equal(t, 1, tasks[0].Slot)
+	assert.Equal(t, TaskStateRunning, tasks[0].Status.State)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), tasks[0].Status.Timestamp)
+}
+
+func TestClient_GetTaskLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/2/docker/tasks/task1/logs", r.URL.Path)
+		assert.Equal(t, "20", r.URL.Query().Get("tail"))
+		assert.Equal(t, "true", r.URL.Query().Get("timestamps"))
+
+		w.Write(logFrame(1, "2024-05-01T10:00:00Z Listening on :80\n"))
+		w.Write(logFrame(2, "2024-05-01T10:00:01Z Connection refused\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	entries, err := client.GetTaskLogs(context.Background(), 2, "task1", false, 20)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Listening on :80", entries[0].Message)
+	assert.Equal(t, LogStreamStderr, entries[1].Stream)
+}
+
+func TestClient_StreamTaskLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/endpoints/2/docker/tasks/task1/logs", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("follow"))
+
+		w.Write(logFrame(1, "2024-05-01T10:00:00Z Listening on :80\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	var entries []LogEntry
+	err := client.StreamTaskLogs(context.Background(), 2, "task1", false, 10, func(entry LogEntry) {
+		entries = append(entries, entry)
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "Listening on :80", entries[0].Message)
+}
+
+func TestClient_GetTaskLogs_TTY(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("2024-05-01T10:00:00Z ok\r\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "test-token")
+
+	entries, err := client.GetTaskLogs(context.Background(), 2, "task1", true, 20)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ok", entries[0].Message)
+	assert.Equal(t, LogStreamTTY, entries[0].Stream)
+}
+
+func TestTaskName(t *testing.T) {
+	assert.Equal(t, "myapp_web.2", TaskName("myapp_web", Task{Slot: 2, NodeID: "node1"}))
+	assert.Equal(t, "myapp_agent.node1", TaskName("myapp_agent", Task{NodeID: "node1"}))
+}
+
+func TestCurrentTasks(t *testing.T) {
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tasks := []Task{
+		{ID: "web1-old", ServiceID: "web", Slot: 1, DesiredState: TaskStateShutdown, CreatedAt: base},
+		{ID: "web2", ServiceID: "web", Slot: 2, DesiredState: TaskStateRunning, CreatedAt: base},
+		{ID: "web1", ServiceID: "web", Slot: 1, DesiredState: TaskStateRunning, CreatedAt: base.Add(time.Minute)},
+		{ID: "agent-node1", ServiceID: "agent", NodeID: "node1", DesiredState: TaskStateRunning, CreatedAt: base},
+		{ID: "agent-node2", ServiceID: "agent", NodeID: "node2", DesiredState: TaskStateRunning, CreatedAt: base},
+	}
+
+	current := CurrentTasks(tasks)
+
+	var ids []string
+	for _, task := range current {
+		ids = append(ids, task.ID)
+	}
+	assert.Equal(t, []string{"web1", "web2", "agent-node1", "agent-node2"}, ids)
+}
@@ -0,0 +1,176 @@
+package portainer
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TLSOptions configures how the client verifies the Portainer server and authenticates to it
+type TLSOptions struct {
+	SkipVerify  bool
+	CAFile      string // PEM bundle trusted in addition to the system certificate authorities
+	CertFile    string // client certificate for mutual TLS
+	KeyFile     string // private key of CertFile
+	ServerName  string // name verified against the server certificate instead of the URL host
+	Fingerprint string // pinned SHA-256 fingerprint of the server certificate
+}
+
+// FingerprintMismatchError is returned when the server certificate does not match the pinned fingerprint
+type FingerprintMismatchError struct {
+	Expected string
+	Actual   string
+}
+
+// Error implements the error interface
+func (e *FingerprintMismatchError) Error() string {
+	return fmt.Sprintf("server certificate fingerprint %s does not match the pinned fingerprint %s", e.Actual, e.Expected)
+}
+
+// NewTLSConfig builds the TLS configuration of the client. A pinned fingerprint replaces
+// the certificate chain verification, so that self-signed certificates can be trusted.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: opts.SkipVerify,
+		ServerName:         opts.ServerName,
+	}
+
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificate found in CA file '%s'", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key files must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if opts.Fingerprint != "" {
+		expected, err := ParseFingerprint(opts.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("server did not present a certificate")
+			}
+			if actual := CertificateFingerprint(state.PeerCertificates[0]); actual != expected {
+				return &FingerprintMismatchError{Expected: expected, Actual: actual}
+			}
+			return nil
+		}
+	}
+
+	return config, nil
+}
+
+// CertificateFingerprint returns the SHA-256 fingerprint of cert as colon separated hex pairs
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return formatFingerprint(sum[:])
+}
+
+// ParseFingerprint normalizes a SHA-256 fingerprint, with or without colons and in any case
+func ParseFingerprint(fingerprint string) (string, error) {
+	raw := strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", "")
+	sum, err := hex.DecodeString(raw)
+	if err != nil || len(sum) != sha256.Size {
+		return "", fmt.Errorf("invalid fingerprint '%s', expected a SHA-256 hash in hex", fingerprint)
+	}
+	return formatFingerprint(sum), nil
+}
+
+func formatFingerprint(sum []byte) string {
+	pairs := make([]string, len(sum))
+	for i, b := range sum {
+		pairs[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(pairs, ":")
+}
+
+// ServerCertificate describes the certificate presented by a Portainer server
+type ServerCertificate struct {
+	Certificate *x509.Certificate
+	Fingerprint string
+	VerifyErr   error // why the system certificate authorities do not trust the certificate, nil when they do
+}
+
+// Trusted reports whether the certificate is trusted by the system certificate authorities
+func (s *ServerCertificate) Trusted() bool {
+	return s.VerifyErr == nil
+}
+
+// InspectServerCertificate connects to an https Portainer URL and returns the certificate
+// presented by the server, whether or not it is trusted
+func InspectServerCertificate(ctx context.Context, rawURL string) (*ServerCertificate, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("URL must use https to inspect the server certificate")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			InsecureSkipVerify: true, // the certificate is verified below, and reported rather than rejected
+			ServerName:         u.Hostname(),
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errors.New("server did not present a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, verifyErr := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       u.Hostname(),
+		Intermediates: intermediates,
+	})
+
+	return &ServerCertificate{
+		Certificate: certs[0],
+		Fingerprint: CertificateFingerprint(certs[0]),
+		VerifyErr:   verifyErr,
+	}, nil
+}
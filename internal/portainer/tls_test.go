@@ -0,0 +1,199 @@
+package portainer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTLSEnvironmentsServer starts an https server answering the environments endpoint
+func newTLSEnvironmentsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]Environment{{ID: 1, Name: "local"}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writePEM writes a PEM block to a file in a temporary directory and returns its path
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+	return path
+}
+
+// newClientCertificate generates a self-signed client certificate and returns its certificate and key files
+func newClientCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pctl"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return writePEM(t, "client.crt", "CERTIFICATE", der), writePEM(t, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+func newClientWithTLS(t *testing.T, serverURL string, opts TLSOptions) *Client {
+	t.Helper()
+	tlsConfig, err := NewTLSConfig(opts)
+	require.NoError(t, err)
+	return NewClientWithOptions(serverURL, "test-token", ClientOptions{
+		TLSConfig: tlsConfig,
+		Retry:     RetryPolicy{MaxAttempts: 1},
+	})
+}
+
+func TestNewTLSConfig_CAFile(t *testing.T) {
+	server := newTLSEnvironmentsServer(t)
+
+	// The test server certificate is not trusted by the system
+	_, err := NewClient(server.URL, "test-token").GetEnvironments(context.Background())
+	require.Error(t, err)
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	environments, err := newClientWithTLS(t, server.URL, TLSOptions{CAFile: caFile}).GetEnvironments(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, environments, 1)
+}
+
+func TestNewTLSConfig_ServerName(t *testing.T) {
+	server := newTLSEnvironmentsServer(t)
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	// The test server certificate is valid for example.com
+	_, err := newClientWithTLS(t, server.URL, TLSOptions{CAFile: caFile, ServerName: "example.com"}).GetEnvironments(context.Background())
+	require.NoError(t, err)
+
+	_, err = newClientWithTLS(t, server.URL, TLSOptions{CAFile: caFile, ServerName: "portainer.internal"}).GetEnvironments(context.Background())
+	var hostnameErr x509.HostnameError
+	assert.ErrorAs(t, err, &hostnameErr)
+}
+
+func TestNewTLSConfig_ClientCertificate(t *testing.T) {
+	var presented int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented = len(r.TLS.PeerCertificates)
+		json.NewEncoder(w).Encode([]Environment{})
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	_, err := newClientWithTLS(t, server.URL, TLSOptions{CAFile: caFile}).GetEnvironments(context.Background())
+	require.Error(t, err, "the server requires a client certificate")
+
+	certFile, keyFile := newClientCertificate(t)
+	_, err = newClientWithTLS(t, server.URL, TLSOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}).GetEnvironments(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, presented)
+}
+
+func TestNewTLSConfig_Fingerprint(t *testing.T) {
+	server := newTLSEnvironmentsServer(t)
+	fingerprint := CertificateFingerprint(server.Certificate())
+
+	t.Run("matching fingerprint", func(t *testing.T) {
+		// Lower case without colons is accepted too
+		pinned := strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+		_, err := newClientWithTLS(t, server.URL, TLSOptions{Fingerprint: pinned}).GetEnvironments(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("different fingerprint", func(t *testing.T) {
+		pinned := strings.Repeat("AB:", 31) + "AB"
+		_, err := newClientWithTLS(t, server.URL, TLSOptions{Fingerprint: pinned}).GetEnvironments(context.Background())
+
+		var mismatch *FingerprintMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, pinned, mismatch.Expected)
+		assert.Equal(t, fingerprint, mismatch.Actual)
+	})
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	certFile, keyFile := newClientCertificate(t)
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0600))
+
+	tests := []struct {
+		name     string
+		opts     TLSOptions
+		expected string
+	}{
+		{name: "missing CA file", opts: TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, expected: "failed to read CA file"},
+		{name: "CA file without certificate", opts: TLSOptions{CAFile: notPEM}, expected: "no PEM certificate found"},
+		{name: "certificate without key", opts: TLSOptions{CertFile: certFile}, expected: "must be set together"},
+		{name: "key without certificate", opts: TLSOptions{KeyFile: keyFile}, expected: "must be set together"},
+		{name: "invalid key pair", opts: TLSOptions{CertFile: certFile, KeyFile: notPEM}, expected: "failed to load client certificate"},
+		{name: "invalid fingerprint", opts: TLSOptions{Fingerprint: "AB:CD"}, expected: "invalid fingerprint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTLSConfig(tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
+
+func TestParseFingerprint(t *testing.T) {
+	expected := strings.Repeat("0A:", 31) + "0A"
+
+	for _, input := range []string{expected, strings.ToLower(expected), strings.Repeat("0a", 32), " " + expected + "\n"} {
+		fingerprint, err := ParseFingerprint(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, fingerprint)
+	}
+
+	_, err := ParseFingerprint("ZZ" + strings.Repeat("00", 31))
+	assert.Error(t, err)
+}
+
+func TestInspectServerCertificate(t *testing.T) {
+	server := newTLSEnvironmentsServer(t)
+
+	cert, err := InspectServerCertificate(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	assert.Equal(t, server.Certificate().Raw, cert.Certificate.Raw)
+	assert.Equal(t, CertificateFingerprint(server.Certificate()), cert.Fingerprint)
+	assert.False(t, cert.Trusted(), "the test server certificate is self-signed")
+	assert.Error(t, cert.VerifyErr)
+
+	_, err = InspectServerCertificate(context.Background(), "http://portainer.example.com")
+	assert.Error(t, err)
+}
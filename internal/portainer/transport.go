@@ -0,0 +1,270 @@
+package portainer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyFunc selects the proxy of a request, nil meaning a direct connection
+type ProxyFunc func(*http.Request) (*url.URL, error)
+
+// DialFunc opens the network connection to the Portainer server
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// NewProxyFunc returns the proxy selection of the client. Without proxyURL, the
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables are used. Hosts matching
+// noProxy, a comma separated list of hosts, domains, IP addresses and CIDRs, are
+// always reached directly.
+func NewProxyFunc(proxyURL, noProxy string) (ProxyFunc, error) {
+	var proxy ProxyFunc = http.ProxyFromEnvironment
+
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL '%s'", proxyURL)
+		}
+		switch u.Scheme {
+		case "http", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme '%s', must be http, socks5 or socks5h", u.Scheme)
+		}
+		proxy = func(*http.Request) (*url.URL, error) {
+			return u, nil
+		}
+	}
+
+	if noProxy == "" {
+		return proxy, nil
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if matchesNoProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		return proxy(req)
+	}, nil
+}
+
+// matchesNoProxy reports whether u is excluded from proxying by a NO_PROXY style list.
+// Domain entries also match their subdomains, and entries with a port only match that port.
+func matchesNoProxy(u *url.URL, noProxy string) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" || u.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if entryHost, entryPort, err := net.SplitHostPort(entry); err == nil {
+			if entryPort != port {
+				continue
+			}
+			entry = entryHost
+		}
+
+		if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		domain := strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// websocketProxy adapts the proxy of the client to the websocket dialer, which only
+// knows the socks5 scheme and always lets the proxy resolve host names
+func websocketProxy(proxy ProxyFunc) ProxyFunc {
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if err != nil || u == nil || u.Scheme != "socks5h" {
+			return u, err
+		}
+		socks := *u
+		socks.Scheme = "socks5"
+		return &socks, nil
+	}
+}
+
+// SSHJumpDialer returns a dial function reaching Portainer through an SSH jump host, like
+// 'ssh -W host:port jump'. The jump host is [user@]host[:port] or a Host alias of the ssh
+// configuration, and ssh must authenticate without prompting (keys or an agent).
+func SSHJumpDialer(jump, sshCommand string) DialFunc {
+	if sshCommand == "" {
+		sshCommand = "ssh"
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialSSH(ctx, sshCommand, sshJumpArgs(jump, addr), jump, addr)
+	}
+}
+
+// sshJumpArgs returns the ssh arguments forwarding the standard input and output to addr
+func sshJumpArgs(jump, addr string) []string {
+	destination := jump
+	if !strings.Contains(destination, "://") && strings.Contains(destination, ":") {
+		// ssh only accepts a port in the destination in its URI form
+		destination = "ssh://" + destination
+	}
+	return []string{"-o", "BatchMode=yes", "-W", addr, destination}
+}
+
+func dialSSH(ctx context.Context, command string, args []string, jump, addr string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	stdinReader, stdinWriter, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		stdinReader.Close()
+		stdinWriter.Close()
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+
+	// Like a dialed TCP connection, the tunnel is not bound to ctx: it lives until Close
+	cmd := exec.Command(command, args...)
+	cmd.Stdin = stdinReader
+	cmd.Stdout = stdoutWriter
+	conn := &sshConn{
+		cmd:    cmd,
+		stdin:  stdinWriter,
+		stdout: stdoutReader,
+		local:  sshAddr("ssh"),
+		remote: sshAddr(jump + "->" + addr),
+		done:   make(chan struct{}),
+	}
+	cmd.Stderr = &conn.stderr
+
+	err = cmd.Start()
+	stdinReader.Close()
+	stdoutWriter.Close()
+	if err != nil {
+		stdinWriter.Close()
+		stdoutReader.Close()
+		return nil, fmt.Errorf("failed to start %s for jump host %s: %w", command, jump, err)
+	}
+
+	go func() {
+		conn.waitErr = cmd.Wait()
+		close(conn.done)
+	}()
+
+	return conn, nil
+}
+
+// sshConn is a connection tunnelled through the standard input and output of an ssh process
+type sshConn struct {
+	cmd     *exec.Cmd
+	stdin   *os.File
+	stdout  *os.File
+	stderr  bytes.Buffer // read once the process exited
+	local   net.Addr
+	remote  net.Addr
+	done    chan struct{}
+	waitErr error
+
+	closeOnce sync.Once
+}
+
+func (c *sshConn) Read(b []byte) (int, error) {
+	n, err := c.stdout.Read(b)
+	if errors.Is(err, io.EOF) {
+		// The tunnel closed, report why ssh exited if it failed
+		<-c.done
+		if c.waitErr != nil {
+			if msg := strings.TrimSpace(c.stderr.String()); msg != "" {
+				return n, fmt.Errorf("ssh jump host: %s", msg)
+			}
+			return n, fmt.Errorf("ssh jump host: %w", c.waitErr)
+		}
+	}
+	return n, err
+}
+
+func (c *sshConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+// Close closes the tunnel and stops the ssh process
+func (c *sshConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.stdout.Close()
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+	})
+	return nil
+}
+
+func (c *sshConn) LocalAddr() net.Addr  { return c.local }
+func (c *sshConn) RemoteAddr() net.Addr { return c.remote }
+
+// SetDeadline sets the read and write deadlines. Pipes do not support deadlines on every
+// platform, the connection is then closed when the request is cancelled instead.
+func (c *sshConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *sshConn) SetReadDeadline(t time.Time) error {
+	return ignoreNoDeadline(c.stdout.SetReadDeadline(t))
+}
+
+func (c *sshConn) SetWriteDeadline(t time.Time) error {
+	return ignoreNoDeadline(c.stdin.SetWriteDeadline(t))
+}
+
+func ignoreNoDeadline(err error) error {
+	if errors.Is(err, os.ErrNoDeadline) {
+		return nil
+	}
+	return err
+}
+
+// sshAddr describes the ends of an ssh tunnel
+type sshAddr string
+
+func (a sshAddr) Network() string { return "ssh" }
+func (a sshAddr) String() string  { return string(a) }
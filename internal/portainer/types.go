@@ -1,21 +1,110 @@
 package portainer
 
+import "time"
+
+// Labels set by Docker Compose, Docker Swarm and Portainer on the resources they create for a stack
+const (
+	ComposeProjectLabel  = "com.docker.compose.project"
+	ComposeServiceLabel  = "com.docker.compose.service"
+	SwarmNamespaceLabel  = "com.docker.stack.namespace"
+	KubernetesStackLabel = "io.portainer.kubernetes.application.stack"
+)
+
 // Environment represents a Portainer environment/endpoint
 type Environment struct {
 	ID   int    `json:"Id"`
 	Name string `json:"Name"`
+	Type int    `json:"Type"`
 	URL  string `json:"URL"`
 }
 
+// Environment type values reported by Portainer
+const (
+	EnvironmentTypeDocker                = 1
+	EnvironmentTypeAgentOnDocker         = 2
+	EnvironmentTypeAzure                 = 3
+	EnvironmentTypeEdgeAgentOnDocker     = 4
+	EnvironmentTypeKubernetesLocal       = 5
+	EnvironmentTypeAgentOnKubernetes     = 6
+	EnvironmentTypeEdgeAgentOnKubernetes = 7
+)
+
+// IsKubernetes reports whether the environment is a Kubernetes cluster, which Portainer
+// reaches through its Kubernetes proxy instead of the Docker proxy
+func (e *Environment) IsKubernetes() bool {
+	switch e.Type {
+	case EnvironmentTypeKubernetesLocal, EnvironmentTypeAgentOnKubernetes, EnvironmentTypeEdgeAgentOnKubernetes:
+		return true
+	}
+	return false
+}
+
+// IsEdge reports whether the environment is managed through an Edge agent, which Portainer
+// only reaches asynchronously: the Docker proxy cannot be used, stacks are deployed as edge stacks
+func (e *Environment) IsEdge() bool {
+	return e.Type == EnvironmentTypeEdgeAgentOnDocker || e.Type == EnvironmentTypeEdgeAgentOnKubernetes
+}
+
 // Stack represents a Portainer stack
 type Stack struct {
-	ID            int    `json:"Id"`
-	Name          string `json:"Name"`
-	StackFile     string `json:"EntryPoint"` // StackFile maps to Portainer API's EntryPoint field
-	EnvironmentID int    `json:"EndpointId"`
-	Status        int    `json:"Status"`
+	ID            int                 `json:"Id"`
+	Name          string              `json:"Name"`
+	Type          int                 `json:"Type"`
+	StackFile     string              `json:"EntryPoint"` // StackFile maps to Portainer API's EntryPoint field
+	EnvironmentID int                 `json:"EndpointId"`
+	SwarmID       string              `json:"SwarmId"`         // empty unless Type is StackTypeSwarm
+	Namespace     string              `json:"Namespace"`       // empty unless Type is StackTypeKubernetes
+	ComposeFormat bool                `json:"IsComposeFormat"` // Kubernetes stack converted from a compose file
+	Status        int                 `json:"Status"`
+	Env           []EnvVar            `json:"Env"`
+	GitConfig     *GitConfig          `json:"GitConfig"`  // nil unless the stack is deployed from a git repository
+	AutoUpdate    *AutoUpdateSettings `json:"AutoUpdate"` // nil unless Portainer updates the git stack by itself
+}
+
+// IsSwarm reports whether the stack is deployed on a Docker Swarm cluster
+func (s *Stack) IsSwarm() bool {
+	return s.Type == StackTypeSwarm
+}
+
+// IsKubernetes reports whether the stack is deployed on a Kubernetes cluster
+func (s *Stack) IsKubernetes() bool {
+	return s.Type == StackTypeKubernetes
 }
 
+// GitConfig describes the repository of a git stack
+type GitConfig struct {
+	URL            string `json:"URL"`
+	ReferenceName  string `json:"ReferenceName"`
+	ConfigFilePath string `json:"ConfigFilePath"`
+	ConfigHash     string `json:"ConfigHash"` // commit deployed last
+}
+
+// AutoUpdateSettings configures how Portainer redeploys a git stack when its repository changes
+type AutoUpdateSettings struct {
+	Interval       string `json:"Interval,omitempty"` // polling interval as a Go duration, empty when not polling
+	Webhook        string `json:"Webhook,omitempty"`  // webhook ID, empty without webhook
+	ForceUpdate    bool   `json:"ForceUpdate"`
+	ForcePullImage bool   `json:"ForcePullImage"`
+}
+
+// StackFilters narrows the stacks listed by Portainer
+type StackFilters struct {
+	EnvironmentID int `json:"EndpointID,omitempty"`
+}
+
+// Stack status values reported by Portainer
+const (
+	StackStatusActive   = 1
+	StackStatusInactive = 2
+)
+
+// Stack type values reported by Portainer
+const (
+	StackTypeSwarm      = 1
+	StackTypeCompose    = 2
+	StackTypeKubernetes = 3
+)
+
 // CreateStackRequest represents the request payload for creating a stack
 type CreateStackRequest struct {
 	Name             string   `json:"Name"`
@@ -36,18 +125,104 @@ type UpdateStackRequest struct {
 	Prune     bool   `json:"Prune"`
 }
 
+// StackFile represents the compose file of a deployed stack
+type StackFile struct {
+	StackFileContent string `json:"StackFileContent"`
+}
+
 // StackDetails represents detailed stack information from Portainer
 type StackDetails struct {
-	ID            int    `json:"Id"`
-	Name          string `json:"Name"`
-	Status        int    `json:"Status"`
-	EnvironmentID int    `json:"EndpointId"`
-	CreatedAt     int64  `json:"creationDate"`
-	UpdatedAt     int64  `json:"updateDate"`
-	CreatedBy     string `json:"createdBy"`
-	UpdatedBy     string `json:"updatedBy"`
-	ProjectPath   string `json:"projectPath"`
-	EntryPoint    string `json:"EntryPoint"`
+	ID            int                 `json:"Id"`
+	Name          string              `json:"Name"`
+	Type          int                 `json:"Type"`
+	Status        int                 `json:"Status"`
+	EnvironmentID int                 `json:"EndpointId"`
+	SwarmID       string              `json:"SwarmId"`
+	Namespace     string              `json:"Namespace"`
+	ComposeFormat bool                `json:"IsComposeFormat"`
+	CreatedAt     int64               `json:"creationDate"`
+	UpdatedAt     int64               `json:"updateDate"`
+	CreatedBy     string              `json:"createdBy"`
+	UpdatedBy     string              `json:"updatedBy"`
+	ProjectPath   string              `json:"projectPath"`
+	EntryPoint    string              `json:"EntryPoint"`
+	Env           []EnvVar            `json:"Env"`
+	GitConfig     *GitConfig          `json:"GitConfig"`
+	AutoUpdate    *AutoUpdateSettings `json:"AutoUpdate"`
+}
+
+// Stack returns the stack described by the details
+func (d *StackDetails) Stack() *Stack {
+	return &Stack{
+		ID:            d.ID,
+		Name:          d.Name,
+		Type:          d.Type,
+		StackFile:     d.EntryPoint,
+		EnvironmentID: d.EnvironmentID,
+		SwarmID:       d.SwarmID,
+		Namespace:     d.Namespace,
+		ComposeFormat: d.ComposeFormat,
+		Status:        d.Status,
+		Env:           d.Env,
+		GitConfig:     d.GitConfig,
+		AutoUpdate:    d.AutoUpdate,
+	}
+}
+
+// EdgeGroup represents a group of Edge environments edge stacks are deployed to
+type EdgeGroup struct {
+	ID        int    `json:"Id"`
+	Name      string `json:"Name"`
+	Dynamic   bool   `json:"Dynamic"`   // environments are selected by tags
+	Endpoints []int  `json:"Endpoints"` // environments of a static group
+}
+
+// EdgeStack represents a stack deployed by Portainer to the environments of edge groups
+type EdgeStack struct {
+	ID           int                     `json:"Id"`
+	Name         string                  `json:"Name"`
+	EdgeGroups   []int                   `json:"EdgeGroups"`
+	Status       map[int]EdgeStackStatus `json:"Status"` // deployment status by environment ID
+	CreationDate int64                   `json:"CreationDate"`
+	Version      int                     `json:"StackFileVersion"`
+	EnvVars      []EnvVar                `json:"EnvVars"`
+}
+
+// EdgeStackStatus is the deployment status of an edge stack on one environment
+type EdgeStackStatus struct {
+	EnvironmentID int                     `json:"EndpointID"`
+	Status        []EdgeStackStatusDetail `json:"Status"` // status history, the last entry is the current one
+}
+
+// EdgeStackStatusDetail is one step of the deployment of an edge stack on an environment
+type EdgeStackStatusDetail struct {
+	Type  int    `json:"Type"`
+	Error string `json:"Error"`
+	Time  int64  `json:"Time"`
+}
+
+// Edge stack status types reported by Portainer
+const (
+	EdgeStatusPending             = 0
+	EdgeStatusDeploymentReceived  = 1
+	EdgeStatusError               = 2
+	EdgeStatusAcknowledged        = 3
+	EdgeStatusRemoved             = 4
+	EdgeStatusRemoteUpdateSuccess = 5
+	EdgeStatusImagesPulled        = 6
+	EdgeStatusRunning             = 7
+	EdgeStatusDeploying           = 8
+	EdgeStatusRemoving            = 9
+	EdgeStatusPausedDeploying     = 10
+	EdgeStatusCompleted           = 11
+)
+
+// Current returns the current deployment step, pending when the environment did not report yet
+func (s EdgeStackStatus) Current() EdgeStackStatusDetail {
+	if len(s.Status) == 0 {
+		return EdgeStackStatusDetail{Type: EdgeStatusPending}
+	}
+	return s.Status[len(s.Status)-1]
 }
 
 // Container represents a Docker container
@@ -62,6 +237,17 @@ type Container struct {
 	Ports   []Port            `json:"Ports"`
 }
 
+// ContainerInspect represents the details of a Docker container
+type ContainerInspect struct {
+	ID     string          `json:"Id"`
+	Config ContainerConfig `json:"Config"`
+}
+
+// ContainerConfig is the configuration a container was created with
+type ContainerConfig struct {
+	Tty bool `json:"Tty"` // logs are not multiplexed when set
+}
+
 // Port represents container port mapping
 type Port struct {
 	PrivatePort int    `json:"PrivatePort"`
@@ -70,8 +256,189 @@ type Port struct {
 	IP          string `json:"IP"`
 }
 
-// APIError represents an error response from the Portainer API
-type APIError struct {
-	Message string `json:"message"`
-	Details string `json:"details"`
+// Service represents a Docker Swarm service
+type Service struct {
+	ID       string          `json:"ID"`
+	Spec     ServiceSpec     `json:"Spec"`
+	Endpoint ServiceEndpoint `json:"Endpoint"`
+}
+
+// ServiceSpec is the desired state of a service
+type ServiceSpec struct {
+	Name         string              `json:"Name"`
+	Labels       map[string]string   `json:"Labels"`
+	TaskTemplate ServiceTaskTemplate `json:"TaskTemplate"`
+	Mode         ServiceMode         `json:"Mode"`
+}
+
+// ServiceTaskTemplate describes the tasks of a service
+type ServiceTaskTemplate struct {
+	ContainerSpec ServiceContainerSpec `json:"ContainerSpec"`
+}
+
+// ServiceContainerSpec describes the container of a service task
+type ServiceContainerSpec struct {
+	Image string `json:"Image"`
+	TTY   bool   `json:"TTY"` // logs are not multiplexed when set
+}
+
+// ServiceMode tells whether a service runs a number of replicas or one task per node
+type ServiceMode struct {
+	Replicated *ReplicatedService `json:"Replicated,omitempty"`
+	Global     *struct{}          `json:"Global,omitempty"`
+}
+
+// ReplicatedService is the mode of a service running a number of replicas
+type ReplicatedService struct {
+	Replicas uint64 `json:"Replicas"`
+}
+
+// ServiceEndpoint describes the ports published by a service
+type ServiceEndpoint struct {
+	Ports []ServicePort `json:"Ports"`
+}
+
+// ServicePort represents a port published by a service
+type ServicePort struct {
+	Protocol      string `json:"Protocol"`
+	TargetPort    int    `json:"TargetPort"`
+	PublishedPort int    `json:"PublishedPort"`
+	PublishMode   string `json:"PublishMode"`
+}
+
+// Task represents a Docker Swarm task, a container scheduled for a service
+type Task struct {
+	ID           string              `json:"ID"`
+	ServiceID    string              `json:"ServiceID"`
+	Slot         int                 `json:"Slot"` // replica number, 0 for global services
+	NodeID       string              `json:"NodeID"`
+	DesiredState string              `json:"DesiredState"`
+	Status       TaskStatus          `json:"Status"`
+	CreatedAt    time.Time           `json:"CreatedAt"`
+	Spec         ServiceTaskTemplate `json:"Spec"`
+}
+
+// TaskStatus is the current state of a task
+type TaskStatus struct {
+	Timestamp time.Time `json:"Timestamp"`
+	State     string    `json:"State"`
+	Message   string    `json:"Message"`
+	Err       string    `json:"Err"`
+}
+
+// Task states reported by Docker Swarm
+const (
+	TaskStateRunning  = "running"
+	TaskStateShutdown = "shutdown"
+)
+
+// PodList is the response of the Kubernetes API listing pods
+type PodList struct {
+	Items []Pod `json:"items"`
+}
+
+// Pod represents a Kubernetes pod, only the fields displayed by pctl are decoded
+type Pod struct {
+	Metadata PodMetadata `json:"metadata"`
+	Spec     PodSpec     `json:"spec"`
+	Status   PodStatus   `json:"status"`
+}
+
+// PodMetadata holds the name, labels and lifecycle timestamps of a pod
+type PodMetadata struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Labels            map[string]string `json:"labels"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	DeletionTimestamp *time.Time        `json:"deletionTimestamp"` // set while the pod is terminating
+}
+
+// PodSpec lists the containers of a pod and the node it is scheduled on
+type PodSpec struct {
+	NodeName   string         `json:"nodeName"`
+	Containers []PodContainer `json:"containers"`
+}
+
+// PodContainer is a container declared by a pod
+type PodContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+// PodStatus is the observed state of a pod
+type PodStatus struct {
+	Phase             string            `json:"phase"` // Pending, Running, Succeeded, Failed or Unknown
+	Reason            string            `json:"reason"`
+	ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+}
+
+// ContainerStatus is the observed state of a container of a pod
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	Ready        bool           `json:"ready"`
+	RestartCount int            `json:"restartCount"`
+	State        ContainerState `json:"state"`
+}
+
+// ContainerState tells why a container is not running, at most one field is set
+type ContainerState struct {
+	Waiting    *ContainerStateReason `json:"waiting"`
+	Terminated *ContainerStateReason `json:"terminated"`
+}
+
+// ContainerStateReason is the reason a container is waiting or terminated, e.g. CrashLoopBackOff
+type ContainerStateReason struct {
+	Reason string `json:"reason"`
+}
+
+// Volume represents a Docker volume
+type Volume struct {
+	Name       string            `json:"Name"`
+	Driver     string            `json:"Driver"`
+	Mountpoint string            `json:"Mountpoint"`
+	Labels     map[string]string `json:"Labels"`
+	Scope      string            `json:"Scope"`
+}
+
+// Image represents a Docker image
+type Image struct {
+	ID       string   `json:"Id"`
+	RepoTags []string `json:"RepoTags"`
+	Size     int64    `json:"Size"`
+	Created  int64    `json:"Created"`
+}
+
+// LogStream identifies the output stream a log entry was written to
+type LogStream string
+
+const (
+	LogStreamStdout LogStream = "stdout"
+	LogStreamStderr LogStream = "stderr"
+	LogStreamTTY    LogStream = "tty" // combined output of a container running with a TTY
+)
+
+// LogEntry represents a single container log line
+type LogEntry struct {
+	Stream    LogStream
+	Timestamp time.Time // zero when the line had no timestamp
+	Message   string
+}
+
+// ExecConfig represents the request payload for creating an exec instance in a container
+type ExecConfig struct {
+	AttachStdin  bool     `json:"AttachStdin"`
+	AttachStdout bool     `json:"AttachStdout"`
+	AttachStderr bool     `json:"AttachStderr"`
+	Tty          bool     `json:"Tty"`
+	User         string   `json:"User,omitempty"`
+	WorkingDir   string   `json:"WorkingDir,omitempty"`
+	Env          []string `json:"Env,omitempty"`
+	Cmd          []string `json:"Cmd"`
+}
+
+// ExecInspect represents the state of an exec instance
+type ExecInspect struct {
+	ID       string `json:"ID"`
+	Running  bool   `json:"Running"`
+	ExitCode int    `json:"ExitCode"`
 }
@@ -2,6 +2,8 @@ package spinner
 
 import (
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -21,6 +23,7 @@ type SpinnerModel struct {
 	spinner        spinner.Model
 	message        string
 	successMessage string
+	status         string // transient notice shown under the spinner, such as a retry
 	done           bool
 	err            error
 }
@@ -67,6 +70,10 @@ func (m SpinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = msg.err
 		m.done = true
 		return m, tea.Quit
+
+	case spinnerStatusMsg:
+		m.status = msg.status
+		return m, nil
 	}
 
 	return m, nil
@@ -89,14 +96,40 @@ func (m SpinnerModel) View() string {
 		return successStyle.Render(successMsg)
 	}
 
-	return fmt.Sprintf("\n%s %s\n",
+	view := fmt.Sprintf("\n%s %s\n",
 		m.spinner.View(),
 		infoStyle.Render(m.message))
+	if m.status != "" {
+		view += warningStyle.Render("  "+m.status) + "\n"
+	}
+	return view
 }
 
 // Message types for spinner updates
 type spinnerCompleteMsg struct{}
 type spinnerErrorMsg struct{ err error }
+type spinnerStatusMsg struct{ status string }
+
+var (
+	// active is the running spinner program, which receives Notify messages
+	active   *tea.Program
+	activeMu sync.Mutex
+)
+
+// Notify shows a notice under the running spinner, such as a retry of a failed request.
+// The notice is printed to stderr when no spinner is running, so that it does not mix with
+// command output such as pctl exec -T or JSON progress.
+func Notify(message string) {
+	activeMu.Lock()
+	p := active
+	activeMu.Unlock()
+
+	if p == nil {
+		fmt.Fprintln(os.Stderr, warningStyle.Render(message))
+		return
+	}
+	p.Send(spinnerStatusMsg{status: message})
+}
 
 // RunWithSpinner runs a function with a spinner display
 func RunWithSpinner(message string, operation func() error) error {
@@ -108,8 +141,18 @@ func RunWithSpinnerAndSuccess(message, successMessage string, operation func() e
 	// Create spinner model with custom success message
 	model := NewSpinnerModelWithSuccess(message, successMessage)
 
-	// Create tea program
-	p := tea.NewProgram(model)
+	// Create tea program. The spinner does not read the keyboard nor handle signals so that
+	// Ctrl-C reaches the command context and cancels the operation.
+	p := tea.NewProgram(model, tea.WithInput(nil), tea.WithoutSignalHandler())
+
+	activeMu.Lock()
+	active = p
+	activeMu.Unlock()
+	defer func() {
+		activeMu.Lock()
+		active = nil
+		activeMu.Unlock()
+	}()
 
 	// Channel to receive operation result
 	resultChan := make(chan error, 1)
@@ -2,10 +2,13 @@ package spinner
 
 import (
 	"errors"
+	"io"
+	"os"
 	"testing"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSpinnerModel(t *testing.T) {
@@ -107,6 +110,61 @@ func TestSpinnerModel_Update_Error(t *testing.T) {
 	assert.NotNil(t, cmd)
 }
 
+func TestSpinnerModel_Update_Status(t *testing.T) {
+	model := NewSpinnerModel("Testing spinner")
+
+	// Simulate a retry notice
+	updatedModel, _ := model.Update(spinnerStatusMsg{status: "retrying in 1s (attempt 2/4)"})
+
+	spinnerModel := updatedModel.(SpinnerModel)
+	assert.False(t, spinnerModel.done)
+	assert.Contains(t, spinnerModel.View(), "Testing spinner")
+	assert.Contains(t, spinnerModel.View(), "retrying in 1s (attempt 2/4)")
+}
+
+func TestRunWithSpinner_Notify(t *testing.T) {
+	// Notices sent while the operation runs must not block it
+	err := RunWithSpinner("Testing operation", func() error {
+		Notify("retrying in 1s (attempt 2/4)")
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
+
+// captureOutput returns what fn writes to stdout and stderr
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	require.NoError(t, err)
+	stderrReader, stderrWriter, err := os.Pipe()
+	require.NoError(t, err)
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdoutWriter, stderrWriter
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	fn()
+	stdoutWriter.Close()
+	stderrWriter.Close()
+
+	out, err := io.ReadAll(stdoutReader)
+	require.NoError(t, err)
+	errOut, err := io.ReadAll(stderrReader)
+	require.NoError(t, err)
+	return string(out), string(errOut)
+}
+
+func TestNotify_WithoutSpinner(t *testing.T) {
+	stdout, stderr := captureOutput(t, func() {
+		Notify("Retrying request (attempt 2/3)")
+	})
+
+	// Notices must not mix with command output written to stdout
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "Retrying request (attempt 2/3)")
+}
+
 func TestRunWithSpinner(t *testing.T) {
 	// Test successful operation
 	err := RunWithSpinner("Testing operation", func() error {
@@ -0,0 +1,126 @@
+// Package state keeps data pctl reuses between runs in the .pctl directory of the project
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DirName is the directory holding the local state, next to pctl.yml
+	DirName = ".pctl"
+
+	// FileName is the state file in DirName
+	FileName = "state.json"
+)
+
+// State is the local state of a project
+type State struct {
+	Stacks []StackEntry `json:"stacks"`
+}
+
+// StackEntry remembers the ID of a stack, which is only valid for the Portainer instance,
+// environment and name it was resolved for
+type StackEntry struct {
+	PortainerURL  string `json:"portainer_url"`
+	EnvironmentID int    `json:"environment_id"`
+	Name          string `json:"name"`
+	ID            int    `json:"id"`
+}
+
+// Load reads the state from dir, an empty state is returned when it does not exist yet
+func Load(dir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return &state, nil
+}
+
+// Save writes the state to dir. The directory is created with a .gitignore so that the
+// state is not committed, and the file is replaced atomically.
+func (s *State) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignore); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(gitignore, []byte("*\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write state .gitignore: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, FileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, FileName)); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	return nil
+}
+
+// StackID returns the remembered ID of a stack
+func (s *State) StackID(portainerURL string, environmentID int, name string) (int, bool) {
+	if i := s.stackIndex(portainerURL, environmentID, name); i >= 0 {
+		return s.Stacks[i].ID, true
+	}
+	return 0, false
+}
+
+// SetStackID remembers the ID of a stack
+func (s *State) SetStackID(portainerURL string, environmentID int, name string, id int) {
+	if i := s.stackIndex(portainerURL, environmentID, name); i >= 0 {
+		s.Stacks[i].ID = id
+		return
+	}
+	s.Stacks = append(s.Stacks, StackEntry{
+		PortainerURL:  portainerURL,
+		EnvironmentID: environmentID,
+		Name:          name,
+		ID:            id,
+	})
+}
+
+// ForgetStack removes the remembered ID of a stack
+func (s *State) ForgetStack(portainerURL string, environmentID int, name string) {
+	if i := s.stackIndex(portainerURL, environmentID, name); i >= 0 {
+		s.Stacks = append(s.Stacks[:i], s.Stacks[i+1:]...)
+	}
+}
+
+func (s *State) stackIndex(portainerURL string, environmentID int, name string) int {
+	for i, entry := range s.Stacks {
+		if entry.PortainerURL == portainerURL && entry.EnvironmentID == environmentID && entry.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	state, err := Load(filepath.Join(t.TempDir(), DirName))
+	require.NoError(t, err)
+	assert.Empty(t, state.Stacks)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("{not json"), 0644))
+
+	_, err := Load(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse state")
+}
+
+func TestState_SaveAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), DirName)
+
+	state := &State{}
+	state.SetStackID("https://portainer.example.com", 1, "myapp", 42)
+	state.SetStackID("https://portainer.example.com", 2, "myapp", 7)
+	require.NoError(t, state.Save(dir))
+
+	gitignore, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+	require.NoError(t, err)
+	assert.Equal(t, "*\n", string(gitignore))
+
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+
+	// No temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestState_StackID(t *testing.T) {
+	state := &State{}
+
+	_, ok := state.StackID("https://portainer.example.com", 1, "myapp")
+	assert.False(t, ok)
+
+	state.SetStackID("https://portainer.example.com", 1, "myapp", 42)
+	id, ok := state.StackID("https://portainer.example.com", 1, "myapp")
+	assert.True(t, ok)
+	assert.Equal(t, 42, id)
+
+	// The ID is only valid for the same instance, environment and name
+	_, ok = state.StackID("https://portainer.prod.example.com", 1, "myapp")
+	assert.False(t, ok)
+	_, ok = state.StackID("https://portainer.example.com", 2, "myapp")
+	assert.False(t, ok)
+	_, ok = state.StackID("https://portainer.example.com", 1, "other")
+	assert.False(t, ok)
+
+	// Updating keeps a single entry
+	state.SetStackID("https://portainer.example.com", 1, "myapp", 43)
+	assert.Len(t, state.Stacks, 1)
+	id, _ = state.StackID("https://portainer.example.com", 1, "myapp")
+	assert.Equal(t, 43, id)
+
+	state.ForgetStack("https://portainer.example.com", 1, "myapp")
+	_, ok = state.StackID("https://portainer.example.com", 1, "myapp")
+	assert.False(t, ok)
+	assert.Empty(t, state.Stacks)
+}
@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -125,7 +126,7 @@ func ValidatePortainerConnection(t require.TestingT, cfg *IntegrationConfig) {
 	client := portainer.NewClientWithTLS(cfg.PortainerURL, cfg.APIToken, true)
 
 	// Try to get the specific environment - this validates URL reachability, auth, and environment existence
-	environments, err := client.GetEnvironments()
+	environments, err := client.GetEnvironments(context.Background())
 	require.NoError(t, err, "Failed to connect to Portainer or authenticate")
 
 	// Check if our environment ID exists
@@ -144,7 +145,7 @@ func ValidatePortainerConnectionSimple(cfg *IntegrationConfig) error {
 	client := portainer.NewClientWithTLS(cfg.PortainerURL, cfg.APIToken, true)
 
 	// Try to get the specific environment - this validates URL reachability, auth, and environment existence
-	environments, err := client.GetEnvironments()
+	environments, err := client.GetEnvironments(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to connect to Portainer or authenticate: %w", err)
 	}
@@ -225,7 +226,7 @@ func CreateTestConfigForLoadMode(t require.TestingT, tempDir string, integration
 
 // CleanupStack ensures a stack is deleted from Portainer
 func CleanupStack(t require.TestingT, client *portainer.Client, stackName string, environmentID int) {
-	stack, err := client.GetStack(stackName, environmentID)
+	stack, err := client.GetStack(context.Background(), stackName, environmentID)
 	if err != nil {
 		// Stack might not exist, which is fine
 		return
@@ -233,7 +234,7 @@ func CleanupStack(t require.TestingT, client *portainer.Client, stackName string
 
 	if stack != nil {
 		// Actually delete the stack
-		err = client.DeleteStack(stack.ID, environmentID)
+		err = client.DeleteStack(context.Background(), stack.ID, environmentID)
 		if err != nil {
 			// Just log the error, don't fail the test
 			fmt.Printf("Warning: Failed to delete stack %s (ID: %d): %v\n", stackName, stack.ID, err)
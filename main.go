@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/deviantony/pctl/cmd/deploy"
+	"github.com/deviantony/pctl/cmd/build"
+	"github.com/deviantony/pctl/cmd/diff"
+	"github.com/deviantony/pctl/cmd/down"
+	"github.com/deviantony/pctl/cmd/exec"
 	initcmd "github.com/deviantony/pctl/cmd/init"
+	"github.com/deviantony/pctl/cmd/login"
+	"github.com/deviantony/pctl/cmd/logout"
 	"github.com/deviantony/pctl/cmd/logs"
 	"github.com/deviantony/pctl/cmd/ps"
-	"github.com/deviantony/pctl/cmd/redeploy"
+	"github.com/deviantony/pctl/cmd/restart"
+	"github.com/deviantony/pctl/cmd/start"
+	"github.com/deviantony/pctl/cmd/stop"
+	"github.com/deviantony/pctl/cmd/up"
 	"github.com/deviantony/pctl/cmd/version"
+	"github.com/deviantony/pctl/internal/cmdutil"
+	"github.com/deviantony/pctl/internal/config"
 
 	"github.com/spf13/cobra"
 )
@@ -20,20 +34,51 @@ var rootCmd = &cobra.Command{
 	Long: `pctl is a developer companion tool for deploying and managing Docker Compose 
 applications via Portainer. It streamlines the deployment workflow by providing 
 simple commands to create, deploy, and redeploy stacks through Portainer's API.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		config.SetTarget(target)
+	},
 }
 
+// target selects a named target from pctl.yml
+var target string
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels in-flight builds, loads and deploys; a second Ctrl-C exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	// Commands forwarding an exit code, like exec, have already reported the failure
+	var exitErr *cmdutil.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&target, "target", "", "Target from pctl.yml to use (defaults to $PCTL_TARGET, then default_target)")
+
 	rootCmd.AddCommand(initcmd.InitCmd)
-	rootCmd.AddCommand(deploy.DeployCmd)
+	rootCmd.AddCommand(login.LoginCmd)
+	rootCmd.AddCommand(logout.LogoutCmd)
+	rootCmd.AddCommand(up.UpCmd)
+	rootCmd.AddCommand(diff.DiffCmd)
+	rootCmd.AddCommand(build.BuildCmd)
+	rootCmd.AddCommand(down.DownCmd)
+	rootCmd.AddCommand(start.StartCmd)
+	rootCmd.AddCommand(stop.StopCmd)
+	rootCmd.AddCommand(restart.RestartCmd)
 	rootCmd.AddCommand(logs.LogsCmd)
 	rootCmd.AddCommand(ps.PsCmd)
-	rootCmd.AddCommand(redeploy.RedeployCmd)
+	rootCmd.AddCommand(exec.ExecCmd)
 	rootCmd.AddCommand(version.VersionCmd)
 }
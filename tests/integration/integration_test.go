@@ -3,6 +3,7 @@
 package integration
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -137,7 +138,7 @@ func TestIntegration_DeploySimpleStack(t *testing.T) {
 	require.NoError(t, err, "Compose file should exist")
 
 	// Verify stack doesn't exist yet
-	stack, err := portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack")
 	assert.Nil(t, stack, "Stack should not exist initially")
 
@@ -155,7 +156,7 @@ func TestIntegration_DeploySimpleStack(t *testing.T) {
 	t.Logf("pctl deploy output: %s", output)
 
 	// Verify stack was created (even if pctl crashed after deployment)
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after deploy")
 	require.NotNil(t, stack, "Stack should exist after deploy")
 	assert.Equal(t, stackName, stack.Name, "Stack name should match")
@@ -188,7 +189,7 @@ func TestIntegration_RedeployStack(t *testing.T) {
 	require.NoError(t, err, "Compose file should exist")
 
 	// Verify stack doesn't exist yet
-	stack, err := portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack")
 	assert.Nil(t, stack, "Stack should not exist initially")
 
@@ -204,7 +205,7 @@ func TestIntegration_RedeployStack(t *testing.T) {
 	t.Logf("pctl deploy output: %s", output)
 
 	// Verify stack was created
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after deploy")
 	require.NotNil(t, stack, "Stack should exist after deploy")
 
@@ -222,13 +223,102 @@ func TestIntegration_RedeployStack(t *testing.T) {
 	t.Logf("pctl redeploy output: %s", output)
 
 	// Verify stack still exists after redeploy
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after redeploy")
 	require.NotNil(t, stack, "Stack should still exist after redeploy")
 
 	t.Logf("Successfully redeployed stack: %s (ID: %d)", stack.Name, stack.ID)
 }
 
+func TestIntegration_UpCommand(t *testing.T) {
+	// Create temporary directory for test
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	// Generate unique stack name
+	stackName := testutil.GenerateTestStackName()
+	t.Cleanup(func() {
+		testutil.CleanupStack(t, portainerClient, stackName, integrationConfig.EnvironmentID)
+	})
+
+	// Create test config
+	testutil.CreateTestConfig(t, tempDir, integrationConfig, stackName)
+
+	// Create simple compose file
+	testutil.CreateSimpleComposeFile(t, tempDir)
+
+	// First run creates the stack
+	output, err := runPctlCommand(t, "up")
+	if err != nil || !strings.Contains(output, "Stack deployed successfully!") {
+		t.Logf("pctl up output: %s", output)
+		t.Fatalf("first pctl up did not create the stack: %v", err)
+	}
+
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after up")
+	require.NotNil(t, stack, "Stack should exist after up")
+	stackID := stack.ID
+
+	// Second run updates the same stack
+	output, err = runPctlCommand(t, "up")
+	if err != nil || !strings.Contains(output, "Stack redeployed successfully!") {
+		t.Logf("pctl up output: %s", output)
+		t.Fatalf("second pctl up did not update the stack: %v", err)
+	}
+
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after second up")
+	require.NotNil(t, stack, "Stack should still exist after second up")
+	assert.Equal(t, stackID, stack.ID, "Stack should have been updated in place")
+}
+
+func TestIntegration_UpWithStackEnv(t *testing.T) {
+	// Create temporary directory for test
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	// Generate unique stack name
+	stackName := testutil.GenerateTestStackName()
+	t.Cleanup(func() {
+		testutil.CleanupStack(t, portainerClient, stackName, integrationConfig.EnvironmentID)
+	})
+
+	// Create test config
+	testutil.CreateTestConfig(t, tempDir, integrationConfig, stackName)
+
+	// Create simple compose file with a .env file next to it
+	testutil.CreateSimpleComposeFile(t, tempDir)
+	err := os.WriteFile(filepath.Join(tempDir, ".env"), []byte("APP_TEST_VAR=from-dotenv\n"), 0644)
+	require.NoError(t, err, "Failed to write .env file")
+
+	output, err := runPctlCommand(t, "up")
+	if err != nil || !strings.Contains(output, "Stack deployed successfully!") {
+		t.Logf("pctl up output: %s", output)
+		t.Fatalf("pctl up failed: %v", err)
+	}
+
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after up")
+	require.NotNil(t, stack, "Stack should exist after up")
+	assert.Contains(t, stack.Env, portainer.EnvVar{Name: "APP_TEST_VAR", Value: "from-dotenv"})
+
+	// Variables must survive a redeploy
+	output, err = runPctlCommand(t, "up")
+	if err != nil || !strings.Contains(output, "Stack redeployed successfully!") {
+		t.Logf("pctl up output: %s", output)
+		t.Fatalf("second pctl up failed: %v", err)
+	}
+
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after second up")
+	require.NotNil(t, stack, "Stack should exist after second up")
+	assert.Contains(t, stack.Env, portainer.EnvVar{Name: "APP_TEST_VAR", Value: "from-dotenv"})
+}
+
 func TestIntegration_RedeployStackForceRebuild(t *testing.T) {
 	// Create temporary directory for test
 	tempDir := t.TempDir()
@@ -253,7 +343,7 @@ func TestIntegration_RedeployStackForceRebuild(t *testing.T) {
 	require.NoError(t, err, "Compose file should exist")
 
 	// Verify stack doesn't exist yet
-	stack, err := portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack")
 	assert.Nil(t, stack, "Stack should not exist initially")
 
@@ -269,7 +359,7 @@ func TestIntegration_RedeployStackForceRebuild(t *testing.T) {
 	t.Logf("pctl deploy output: %s", output)
 
 	// Verify stack was created
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after deploy")
 	require.NotNil(t, stack, "Stack should exist after deploy")
 
@@ -287,7 +377,7 @@ func TestIntegration_RedeployStackForceRebuild(t *testing.T) {
 	t.Logf("pctl redeploy -f output: %s", output)
 
 	// Verify stack still exists after force rebuild redeploy
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after force rebuild redeploy")
 	require.NotNil(t, stack, "Stack should still exist after force rebuild redeploy")
 
@@ -318,7 +408,7 @@ func TestIntegration_PsCommand(t *testing.T) {
 	require.NoError(t, err, "Compose file should exist")
 
 	// Verify stack doesn't exist yet
-	stack, err := portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack")
 	assert.Nil(t, stack, "Stack should not exist initially")
 
@@ -334,7 +424,7 @@ func TestIntegration_PsCommand(t *testing.T) {
 	t.Logf("pctl deploy output: %s", output)
 
 	// Verify stack was created
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after deploy")
 	require.NotNil(t, stack, "Stack should exist after deploy")
 
@@ -383,7 +473,7 @@ func TestIntegration_LogsCommand(t *testing.T) {
 	require.NoError(t, err, "Compose file should exist")
 
 	// Verify stack doesn't exist yet
-	stack, err := portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack")
 	assert.Nil(t, stack, "Stack should not exist initially")
 
@@ -399,7 +489,7 @@ func TestIntegration_LogsCommand(t *testing.T) {
 	t.Logf("pctl deploy output: %s", output)
 
 	// Verify stack was created
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after deploy")
 	require.NotNil(t, stack, "Stack should exist after deploy")
 
@@ -422,6 +512,116 @@ func TestIntegration_LogsCommand(t *testing.T) {
 	t.Logf("Successfully ran logs command for stack: %s", stackName)
 }
 
+func TestIntegration_StopStartCommands(t *testing.T) {
+	// Create temporary directory for test
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	// Generate unique stack name
+	stackName := testutil.GenerateTestStackName()
+	t.Cleanup(func() {
+		testutil.CleanupStack(t, portainerClient, stackName, integrationConfig.EnvironmentID)
+	})
+
+	// Create test config
+	testutil.CreateTestConfig(t, tempDir, integrationConfig, stackName)
+
+	// Create simple compose file
+	testutil.CreateSimpleComposeFile(t, tempDir)
+
+	output, err := runPctlCommand(t, "up")
+	if err != nil && !strings.Contains(output, "Stack deployed successfully!") {
+		t.Logf("pctl up output: %s", output)
+		t.Fatalf("pctl up failed: %v", err)
+	}
+
+	// Stop the stack, its definition must be kept
+	output, err = runPctlCommand(t, "stop")
+	if err != nil || !strings.Contains(output, "Stack stopped successfully!") {
+		t.Logf("pctl stop output: %s", output)
+		t.Fatalf("pctl stop failed: %v", err)
+	}
+
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after stop")
+	require.NotNil(t, stack, "Stack should still exist after stop")
+	assert.Equal(t, portainer.StackStatusInactive, stack.Status, "Stack should be inactive after stop")
+
+	// Start it again
+	output, err = runPctlCommand(t, "start")
+	if err != nil || !strings.Contains(output, "Stack started successfully!") {
+		t.Logf("pctl start output: %s", output)
+		t.Fatalf("pctl start failed: %v", err)
+	}
+
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after start")
+	require.NotNil(t, stack, "Stack should exist after start")
+	assert.Equal(t, portainer.StackStatusActive, stack.Status, "Stack should be active after start")
+
+	// Restart all containers
+	output, err = runPctlCommand(t, "restart")
+	if err != nil || !strings.Contains(output, "container(s) successfully!") {
+		t.Logf("pctl restart output: %s", output)
+		t.Fatalf("pctl restart failed: %v", err)
+	}
+}
+
+func TestIntegration_DownCommand(t *testing.T) {
+	// Create temporary directory for test
+	tempDir := t.TempDir()
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+	os.Chdir(tempDir)
+
+	// Generate unique stack name
+	stackName := testutil.GenerateTestStackName()
+	t.Cleanup(func() {
+		testutil.CleanupStack(t, portainerClient, stackName, integrationConfig.EnvironmentID)
+	})
+
+	// Create test config
+	testutil.CreateTestConfig(t, tempDir, integrationConfig, stackName)
+
+	// Create simple compose file
+	testutil.CreateSimpleComposeFile(t, tempDir)
+
+	t.Logf("Deploying stack: %s", stackName)
+
+	// First deploy the stack
+	output, err := runPctlCommand(t, "deploy")
+	if err != nil && !strings.Contains(output, "Stack deployed successfully!") {
+		t.Logf("pctl deploy output: %s", output)
+		t.Fatalf("pctl deploy failed: %v", err)
+	}
+
+	// Verify stack was created
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after deploy")
+	require.NotNil(t, stack, "Stack should exist after deploy")
+
+	// Now test down command
+	t.Logf("Testing down command for stack: %s", stackName)
+
+	output, err = runPctlCommand(t, "down", "--yes", "--volumes")
+	if err != nil {
+		t.Logf("pctl down output: %s", output)
+		t.Fatalf("pctl down failed: %v", err)
+	}
+
+	t.Logf("pctl down output: %s", output)
+	assert.Contains(t, output, "Stack removed successfully!", "Down output should confirm removal")
+
+	// Verify stack was removed
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
+	require.NoError(t, err, "Should be able to check for stack after down")
+	assert.Nil(t, stack, "Stack should not exist after down")
+
+	t.Logf("Successfully removed stack: %s", stackName)
+}
+
 func TestIntegration_BuildRemoteMode(t *testing.T) {
 	// Create temporary directory for test
 	tempDir := t.TempDir()
@@ -461,7 +661,7 @@ func TestIntegration_BuildRemoteMode(t *testing.T) {
 	require.NoError(t, err, "index.html should exist")
 
 	// Verify stack doesn't exist yet
-	stack, err := portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack")
 	assert.Nil(t, stack, "Stack should not exist initially")
 
@@ -473,7 +673,7 @@ func TestIntegration_BuildRemoteMode(t *testing.T) {
 	t.Logf("pctl deploy output: %s", output)
 
 	// Verify stack was created and image was built
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after remote build deploy")
 	require.NotNil(t, stack, "Stack should exist after remote build deploy")
 
@@ -519,7 +719,7 @@ func TestIntegration_BuildLoadMode(t *testing.T) {
 	require.NoError(t, err, "index.html should exist")
 
 	// Verify stack doesn't exist yet
-	stack, err := portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack")
 	assert.Nil(t, stack, "Stack should not exist initially")
 
@@ -531,7 +731,7 @@ func TestIntegration_BuildLoadMode(t *testing.T) {
 	t.Logf("pctl deploy output: %s", output)
 
 	// Verify stack was created and image was built
-	stack, err = portainerClient.GetStack(stackName, integrationConfig.EnvironmentID)
+	stack, err = portainerClient.GetStack(context.Background(), stackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for stack after load build deploy")
 	require.NotNil(t, stack, "Stack should exist after load build deploy")
 
@@ -546,7 +746,7 @@ func TestIntegration_CleanupNonExistentStack(t *testing.T) {
 	testutil.CleanupStack(t, portainerClient, nonExistentStackName, integrationConfig.EnvironmentID)
 
 	// Verify stack doesn't exist
-	stack, err := portainerClient.GetStack(nonExistentStackName, integrationConfig.EnvironmentID)
+	stack, err := portainerClient.GetStack(context.Background(), nonExistentStackName, integrationConfig.EnvironmentID)
 	require.NoError(t, err, "Should be able to check for non-existent stack")
 	assert.Nil(t, stack, "Non-existent stack should not exist")
 